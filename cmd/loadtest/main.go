@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/rsa"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/client"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/network"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// chatMessages are random messages that bots send in public chat.
+var chatMessages = []string{
+	"hello world",
+	"anyone want to trade?",
+	"selling lobsters",
+	"lol",
+	"how do i get to varrock?",
+}
+
+// stats tracks the state of all bots during a load test.
+type stats struct {
+	connected atomic.Int64
+	rejected  atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+	actions   atomic.Int64
+}
+
+// loadtest spawns a number of headless clients against a server, and reports how long game state updates took while
+// the bots were connected.
+func main() {
+	var address, jaggrabAddress, metricsURL, prefix, password, rsaKeyPath, interfaceActions string
+	var numBots, originX, originY int
+	var duration, rampUp, actionInterval time.Duration
+
+	flag.StringVar(&address, "address", "localhost:43594", "address of the game server")
+	flag.StringVar(&jaggrabAddress, "jaggrab-address", "localhost:43595", "address of the jaggrab server")
+	flag.StringVar(&metricsURL, "metrics-url", "http://localhost:2112/metrics", "url of the server's metrics endpoint")
+	flag.StringVar(&prefix, "username-prefix", "bot", "prefix for bot usernames, followed by the bot number")
+	flag.StringVar(&password, "password", "bot", "password for all bot accounts")
+	flag.StringVar(&rsaKeyPath, "rsa-key", "", "path to the server's rsa private key, if login encryption is enabled")
+	flag.StringVar(&interfaceActions, "interface-actions", "", "comma-separated interface action ids bots may click")
+	flag.IntVar(&numBots, "bots", 10, "number of bots to spawn")
+	flag.IntVar(&originX, "origin-x", 3222, "x-coordinate bots walk around")
+	flag.IntVar(&originY, "origin-y", 3218, "y-coordinate bots walk around")
+	flag.DurationVar(&duration, "duration", time.Minute, "how long to run the load test once all bots are spawned")
+	flag.DurationVar(&rampUp, "ramp-up", 10*time.Second, "period over which bots are spawned")
+	flag.DurationVar(&actionInterval, "action-interval", 3*time.Second, "average interval between bot actions")
+	flag.Parse()
+
+	var publicKey *rsa.PublicKey
+	if rsaKeyPath != "" {
+		key, err := network.LoadRSAPrivateKey(rsaKeyPath)
+		if err != nil {
+			fmt.Printf("failed to load rsa key: %s\n", err)
+			os.Exit(1)
+		}
+
+		publicKey = &key.PublicKey
+	}
+
+	var actions []int
+	for _, s := range strings.Split(interfaceActions, ",") {
+		if s == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			fmt.Printf("invalid interface action: %s\n", s)
+			os.Exit(1)
+		}
+
+		actions = append(actions, id)
+	}
+
+	// bots need to present the same archive checksums as the server's cache
+	crcs, err := client.FetchCRCs(jaggrabAddress)
+	if err != nil {
+		fmt.Printf("failed to fetch archive checksums: %s\n", err)
+		os.Exit(1)
+	}
+
+	// take an initial snapshot of the server's tick durations
+	before, err := scrapeTickDurations(metricsURL)
+	if err != nil {
+		fmt.Printf("failed to read server metrics: %s\n", err)
+		os.Exit(1)
+	}
+
+	st := &stats{}
+	doneChan := make(chan bool)
+	wg := sync.WaitGroup{}
+
+	origin := model.Vector2D{X: originX, Y: originY}
+	spawnDelay := rampUp / time.Duration(max(numBots, 1))
+
+	fmt.Printf("spawning %d bots over %s\n", numBots, rampUp)
+	for i := 1; i <= numBots; i++ {
+		c := client.New(client.Options{
+			Address:   address,
+			Username:  fmt.Sprintf("%s%d", prefix, i),
+			Password:  password,
+			CRCs:      crcs,
+			PublicKey: publicKey,
+		})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runBot(c, origin, actions, actionInterval, st, doneChan)
+		}()
+
+		time.Sleep(spawnDelay)
+	}
+
+	// report progress until the test is complete
+	ticker := time.NewTicker(10 * time.Second)
+	end := time.After(duration)
+
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+			fmt.Printf("connected: %d, rejected: %d, failed: %d, dropped: %d, actions: %d\n",
+				st.connected.Load(), st.rejected.Load(), st.failed.Load(), st.dropped.Load(), st.actions.Load())
+		case <-end:
+			running = false
+		}
+	}
+
+	ticker.Stop()
+
+	after, err := scrapeTickDurations(metricsURL)
+	if err != nil {
+		fmt.Printf("failed to read server metrics: %s\n", err)
+		os.Exit(1)
+	}
+
+	close(doneChan)
+	wg.Wait()
+
+	fmt.Printf("\nbots: %d connected, %d rejected, %d failed, %d dropped\n",
+		st.connected.Load(), st.rejected.Load(), st.failed.Load(), st.dropped.Load())
+	fmt.Printf("actions sent: %d\n", st.actions.Load())
+	printTickDurations(after.Sub(before))
+}
+
+// runBot connects a bot to the server and performs random actions until doneChan is closed.
+func runBot(c *client.Client, origin model.Vector2D, actions []int, interval time.Duration, st *stats, doneChan chan bool) {
+	err := c.Connect()
+	if err != nil {
+		var loginErr *client.LoginError
+		if errors.As(err, &loginErr) {
+			st.rejected.Add(1)
+		} else {
+			st.failed.Add(1)
+		}
+
+		return
+	}
+
+	st.connected.Add(1)
+	defer c.Close()
+
+	for {
+		// wait a random amount of time around the interval before acting again
+		delay := interval/2 + time.Duration(rand.Int63n(int64(interval)+1))
+
+		select {
+		case <-doneChan:
+			return
+		case <-c.Done():
+			st.connected.Add(-1)
+			st.dropped.Add(1)
+			return
+		case <-time.After(delay):
+		}
+
+		err := randomAction(c, origin, actions)
+		if err != nil {
+			continue
+		}
+
+		st.actions.Add(1)
+	}
+}
+
+// randomAction performs a single, random action with a bot.
+func randomAction(c *client.Client, origin model.Vector2D, actions []int) error {
+	n := 3
+	if len(actions) > 0 {
+		n++
+	}
+
+	switch rand.Intn(n) {
+	case 0:
+		return c.KeepAlive()
+
+	case 1:
+		// walk a short distance away from a random spot near the origin
+		start := model.Vector2D{X: origin.X + rand.Intn(21) - 10, Y: origin.Y + rand.Intn(21) - 10}
+		waypoints := make([]model.Vector2D, rand.Intn(5))
+		for i := range waypoints {
+			waypoints[i] = model.Vector2D{X: i + 1, Y: 0}
+		}
+
+		return c.Walk(start, waypoints, rand.Intn(2) == 0)
+
+	case 2:
+		return c.Chat(chatMessages[rand.Intn(len(chatMessages))], model.ChatEffectNone, model.ChatColorYellow)
+
+	default:
+		return c.ClickInterface(actions[rand.Intn(len(actions))])
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/expfmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// tickDurationMetric is the name of the histogram the server uses to record how long game state updates take.
+const tickDurationMetric = "game_state_update_duration"
+
+// histogram is a snapshot of a cumulative histogram.
+type histogram struct {
+	count   uint64
+	sum     float64
+	buckets []bucket
+}
+
+// bucket is a cumulative count of observations less than or equal to an upper bound.
+type bucket struct {
+	upperBound float64
+	count      uint64
+}
+
+// Sub returns the observations made between an earlier snapshot and this one.
+func (h *histogram) Sub(earlier *histogram) *histogram {
+	diff := &histogram{
+		count: h.count - earlier.count,
+		sum:   h.sum - earlier.sum,
+	}
+
+	for i, b := range h.buckets {
+		var prev uint64
+		if i < len(earlier.buckets) {
+			prev = earlier.buckets[i].count
+		}
+
+		diff.buckets = append(diff.buckets, bucket{
+			upperBound: b.upperBound,
+			count:      b.count - prev,
+		})
+	}
+
+	return diff
+}
+
+// quantile returns the upper bound of the bucket that contains the given quantile, or +Inf if the quantile exceeds
+// the largest bucket.
+func (h *histogram) quantile(q float64) float64 {
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	for _, b := range h.buckets {
+		if b.count >= rank {
+			return b.upperBound
+		}
+	}
+
+	return math.Inf(1)
+}
+
+// scrapeTickDurations reads the current tick duration histogram from the server's metrics endpoint.
+func scrapeTickDurations(url string) (*histogram, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse metrics")
+	}
+
+	// the histogram is only present once the server has recorded at least one observation
+	family, ok := families[tickDurationMetric]
+	if !ok || len(family.GetMetric()) == 0 {
+		return &histogram{}, nil
+	}
+
+	h := family.GetMetric()[0].GetHistogram()
+	snapshot := &histogram{
+		count: h.GetSampleCount(),
+		sum:   h.GetSampleSum(),
+	}
+
+	for _, b := range h.GetBucket() {
+		snapshot.buckets = append(snapshot.buckets, bucket{
+			upperBound: b.GetUpperBound(),
+			count:      b.GetCumulativeCount(),
+		})
+	}
+
+	return snapshot, nil
+}
+
+// printTickDurations reports a summary of tick durations.
+func printTickDurations(h *histogram) {
+	if h.count == 0 {
+		fmt.Println("no game state updates were recorded; is metrics collection enabled on the server?")
+		return
+	}
+
+	// durations are recorded in nanoseconds
+	format := func(ns float64) string {
+		if math.IsInf(ns, 1) {
+			return "+Inf"
+		}
+
+		return time.Duration(ns).String()
+	}
+
+	fmt.Printf("game state updates: %d\n", h.count)
+	fmt.Printf("mean duration: %s\n", format(h.sum/float64(h.count)))
+	fmt.Printf("p50 <= %s, p95 <= %s, p99 <= %s\n", format(h.quantile(0.5)), format(h.quantile(0.95)),
+		format(h.quantile(0.99)))
+}
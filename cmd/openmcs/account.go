@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/auth"
+	"github.com/mbpolan/openmcs/internal/store"
+	"os"
+)
+
+// runAccount creates, resets or deletes a player account directly in the persistent store.
+func runAccount(args []string) {
+	if len(args) == 0 {
+		fmt.Printf("usage: openmcs account <create|reset|delete> [flags]\n")
+		os.Exit(1)
+	}
+
+	action := args[0]
+
+	var configPath, username, password string
+	fs := flag.NewFlagSet("account "+action, flag.ExitOnError)
+	fs.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	fs.StringVar(&username, "username", "", "username of the account")
+	fs.StringVar(&password, "password", "", "password for the account (create and reset only)")
+	_ = fs.Parse(args[1:])
+
+	if username == "" {
+		fmt.Printf("-username is required\n")
+		os.Exit(1)
+	}
+
+	accounts, closeStore, err := openAccountManager(configPath)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	defer closeStore()
+
+	switch action {
+	case "create":
+		_, err = accounts.Create(username, password)
+	case "reset":
+		err = accounts.ResetPassword(username, password)
+	case "delete":
+		err = accounts.Delete(username)
+	default:
+		err = fmt.Errorf("unknown account action: %s", action)
+	}
+
+	if err != nil {
+		fmt.Printf("failed to %s account: %s\n", action, err)
+		closeStore()
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: %s\n", action, username)
+}
+
+// openAccountManager loads the server configuration and prepares an account manager backed by the persistent store.
+// The returned function closes the store.
+func openAccountManager(configPath string) (*auth.AccountManager, func(), error) {
+	cfg, db, err := store.Open(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeStore := func() {
+		_ = db.Close()
+	}
+
+	hasher, err := auth.NewPasswordHasher(cfg.Login.PasswordHashing)
+	if err != nil {
+		closeStore()
+		return nil, nil, fmt.Errorf("failed to create password hasher: %s", err)
+	}
+
+	return auth.NewAccountManager(cfg.Registration, hasher, db), closeStore, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/moderation"
+	"github.com/mbpolan/openmcs/internal/store"
+	"os"
+	"time"
+)
+
+// runBan issues or lifts a ban against a player account, network address or client machine.
+func runBan(args []string) {
+	if len(args) == 0 {
+		fmt.Printf("usage: openmcs ban <issue|lift> [flags]\n")
+		os.Exit(1)
+	}
+
+	action := args[0]
+
+	var configPath, targetName, value, reason, issuer, durationText string
+	fs := flag.NewFlagSet("ban "+action, flag.ExitOnError)
+	fs.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	fs.StringVar(&targetName, "target", "account", "what to ban: account, address or uid")
+	fs.StringVar(&value, "value", "", "the username, network address or client uid to ban")
+	fs.StringVar(&reason, "reason", "", "why the ban was issued (issue only)")
+	fs.StringVar(&issuer, "issuer", "console", "who issued the ban (issue only)")
+	fs.StringVar(&durationText, "duration", "perm", "how long the ban lasts, such as 12h or 7d (issue only)")
+	_ = fs.Parse(args[1:])
+
+	if value == "" {
+		fmt.Printf("-value is required\n")
+		os.Exit(1)
+	}
+
+	target, err := moderation.ParseBanTarget(targetName)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	duration, err := moderation.ParseDuration(durationText)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	_, db, err := store.Open(configPath)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	defer db.Close()
+	bans := moderation.NewBanList(db)
+
+	switch action {
+	case "issue":
+		ban, err := bans.Issue(target, value, reason, issuer, duration)
+		if err != nil {
+			fmt.Printf("failed to issue ban: %s\n", err)
+			break
+		}
+
+		expires := "never"
+		if ban.ExpiresAt != nil {
+			expires = ban.ExpiresAt.Format(time.RFC3339)
+		}
+
+		fmt.Printf("banned %s %s (expires: %s)\n", targetName, ban.Value, expires)
+		return
+
+	case "lift":
+		n, err := bans.Lift(target, value)
+		if err != nil {
+			fmt.Printf("failed to lift bans: %s\n", err)
+			break
+		}
+
+		fmt.Printf("lifted %d ban(s) for %s %s\n", n, targetName, value)
+		return
+
+	default:
+		fmt.Printf("unknown ban action: %s\n", action)
+	}
+
+	_ = db.Close()
+	os.Exit(1)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/network"
+	"os"
+)
+
+// loginBlockOverhead is the number of bytes the client counts in the login packet length besides the encrypted login
+// block: 40 bytes of client version, memory mode and archive checksums, and the block's own length prefix.
+const loginBlockOverhead = 41
+
+// maxRSAKeyBits is the largest key size supported by the client, which encodes the length of the login packet in a
+// single byte. The encrypted block of a key with n bits takes up to n/8+1 bytes, since the client encodes it as a
+// signed integer that may need a leading sign byte.
+const maxRSAKeyBits = 1704
+
+// runGenRSA generates a new RSA key pair for encrypting login requests, and prints the public key components that
+// need to be embedded in the client.
+func runGenRSA(args []string) {
+	var outPath string
+	var bits int
+
+	fs := flag.NewFlagSet("genrsa", flag.ExitOnError)
+	fs.StringVar(&outPath, "out", "data/rsa.pem", "path where the PEM-encoded private key will be written")
+	fs.IntVar(&bits, "bits", 1024, "size of the key in bits")
+	_ = fs.Parse(args)
+
+	if bits > maxRSAKeyBits {
+		fmt.Printf("-bits cannot exceed %d\n", maxRSAKeyBits)
+		os.Exit(1)
+	}
+
+	key, err := network.GenerateRSAPrivateKey(outPath, bits)
+	if err != nil {
+		fmt.Printf("failed to generate rsa key: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("wrote private key to: %s\n", outPath)
+	fmt.Printf("set server.rsaKeyFile in config.yaml to this path, and embed the following in the client:\n")
+	fmt.Printf("modulus: %s\n", key.N.String())
+	fmt.Printf("exponent: %d\n", key.E)
+}
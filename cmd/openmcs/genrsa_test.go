@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func Test_maxRSAKeyBits(t *testing.T) {
+	// the largest block a key can produce is its byte length plus a sign byte
+	blockLen := maxRSAKeyBits/8 + 1
+	assert.LessOrEqual(t, blockLen+loginBlockOverhead, math.MaxUint8)
+
+	// the next larger key size would no longer fit
+	blockLen = (maxRSAKeyBits+8)/8 + 1
+	assert.Greater(t, blockLen+loginBlockOverhead, math.MaxUint8)
+}
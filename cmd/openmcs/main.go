@@ -12,6 +12,24 @@ import (
 )
 
 func main() {
+	// handle subcommands that do not start the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "genrsa":
+			runGenRSA(os.Args[2:])
+			return
+		case "account":
+			runAccount(os.Args[2:])
+			return
+		case "ban":
+			runBan(os.Args[2:])
+			return
+		case "reports":
+			runReports(os.Args[2:])
+			return
+		}
+	}
+
 	var configPath string
 	flag.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
 	flag.Parse()
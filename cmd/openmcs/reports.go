@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/moderation"
+	"github.com/mbpolan/openmcs/internal/store"
+	"os"
+	"time"
+)
+
+// runReports lists or resolves open abuse reports.
+func runReports(args []string) {
+	if len(args) == 0 {
+		fmt.Printf("usage: openmcs reports <list|resolve> [flags]\n")
+		os.Exit(1)
+	}
+
+	action := args[0]
+
+	var configPath, moderator string
+	var id int
+	fs := flag.NewFlagSet("reports "+action, flag.ExitOnError)
+	fs.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	fs.IntVar(&id, "id", 0, "identifier of the report to resolve (resolve only)")
+	fs.StringVar(&moderator, "moderator", "console", "who resolved the report (resolve only)")
+	_ = fs.Parse(args[1:])
+
+	_, db, err := store.Open(configPath)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	defer db.Close()
+	reports := moderation.NewReportQueue(db)
+
+	switch action {
+	case "list":
+		open, err := reports.Open()
+		if err != nil {
+			fmt.Printf("failed to load reports: %s\n", err)
+			break
+		}
+
+		for _, report := range open {
+			fmt.Printf("#%d [%s] %s reported %s for rule %d", report.ID, report.CreatedAt.Format(time.RFC3339),
+				report.Reporter, report.Offender, report.Reason)
+			if report.Muted {
+				fmt.Printf(" (muted)")
+			}
+
+			fmt.Printf("\n")
+
+			for _, entry := range report.Chat {
+				channel := "public"
+				if entry.Recipient != "" {
+					channel = "to " + entry.Recipient
+				}
+
+				fmt.Printf("    [%s] (%s) %s\n", entry.SentAt.Format(time.RFC3339), channel, entry.Text)
+			}
+		}
+
+		fmt.Printf("%d open report(s)\n", len(open))
+		return
+
+	case "resolve":
+		found, err := reports.Resolve(id, moderator)
+		if err != nil {
+			fmt.Printf("failed to resolve report: %s\n", err)
+			break
+		} else if !found {
+			fmt.Printf("no open report #%d\n", id)
+			break
+		}
+
+		fmt.Printf("resolved report #%d\n", id)
+		return
+
+	default:
+		fmt.Printf("unknown reports action: %s\n", action)
+	}
+
+	_ = db.Close()
+	os.Exit(1)
+}
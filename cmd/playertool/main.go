@@ -0,0 +1,222 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/auth"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/store/playerdata"
+	"os"
+	"time"
+)
+
+// playertool exports a player and all of their persistent data to a JSON document, imports such a document into a
+// persistent store, or rolls a player back to one of the snapshots taken when they were saved.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Printf("usage: playertool <export|import|snapshots|rollback> [flags]\n")
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	case "snapshots":
+		err = runSnapshots(os.Args[2:])
+	case "rollback":
+		err = runRollback(os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown action: %s", os.Args[1])
+	}
+
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+}
+
+// runExport writes a player's data to a file.
+func runExport(args []string) error {
+	var configPath, username, outPath string
+	var includePasswordHash bool
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	fs.StringVar(&username, "username", "", "username of the player to export")
+	fs.StringVar(&outPath, "out", "", "path to write the document to")
+	fs.BoolVar(&includePasswordHash, "include-password-hash", false, "include the player's password hash")
+	_ = fs.Parse(args)
+
+	if username == "" {
+		return fmt.Errorf("-username is required")
+	} else if outPath == "" {
+		return fmt.Errorf("-out is required")
+	}
+
+	_, db, err := store.Open(configPath)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	doc, err := playerdata.Export(db.Driver(), username, playerdata.ExportOptions{
+		IncludePasswordHash: includePasswordHash,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export player: %s", err)
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %s", err)
+	}
+
+	err = playerdata.Write(f, doc)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write document: %s", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write document: %s", err)
+	}
+
+	fmt.Printf("exported %s to %s\n", doc.Player.Username, outPath)
+	return nil
+}
+
+// runImport creates a player from a document written by runExport.
+func runImport(args []string) error {
+	var configPath, inPath, username, password, conflict string
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	fs.StringVar(&inPath, "in", "", "path to read the document from")
+	fs.StringVar(&username, "username", "", "import the player under this username instead of the exported one")
+	fs.StringVar(&password, "password", "", "new password for the player, required if the document has no password hash")
+	fs.StringVar(&conflict, "on-conflict", "fail", "what to do if the username is taken: fail, overwrite or rename")
+	_ = fs.Parse(args)
+
+	if inPath == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	policy, err := playerdata.ParseConflictPolicy(conflict)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(inPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %s", err)
+	}
+
+	doc, err := playerdata.Read(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+
+	cfg, db, err := store.Open(configPath)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	var passwordHash string
+	if password != "" {
+		hasher, err := auth.NewPasswordHasher(cfg.Login.PasswordHashing)
+		if err != nil {
+			return fmt.Errorf("failed to create password hasher: %s", err)
+		}
+
+		passwordHash, err = hasher.Hash(password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %s", err)
+		}
+	}
+
+	p, err := playerdata.Import(db.Driver(), doc, playerdata.ImportOptions{
+		Username:         username,
+		PasswordHash:     passwordHash,
+		OnConflict:       policy,
+		ValidateUsername: auth.NewUsernamePolicy(cfg.Registration.Username).Validate,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import player: %s", err)
+	}
+
+	fmt.Printf("imported %s as %s (id: %d)\n", doc.Player.Username, p.Username, p.ID)
+	return nil
+}
+
+// runSnapshots lists the snapshots of a player, newest first.
+func runSnapshots(args []string) error {
+	var configPath, username string
+	fs := flag.NewFlagSet("snapshots", flag.ExitOnError)
+	fs.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	fs.StringVar(&username, "username", "", "username of the player")
+	_ = fs.Parse(args)
+
+	if username == "" {
+		return fmt.Errorf("-username is required")
+	}
+
+	cfg, db, err := store.Open(configPath)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	snapshots, err := playerdata.NewHistory(db.Driver(), cfg.Store.SnapshotRetention).List(username)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshots: %s", err)
+	}
+
+	for _, snapshot := range snapshots {
+		fmt.Printf("#%d [%s] schema version %d\n", snapshot.ID, snapshot.CreatedAt.Format(time.RFC3339),
+			snapshot.SchemaVersion)
+	}
+
+	fmt.Printf("%d snapshot(s)\n", len(snapshots))
+	return nil
+}
+
+// runRollback schedules a player to be rolled back to one of their snapshots. The rollback is applied by the server the
+// next time the player logs in, so it is safe to schedule while they are online.
+func runRollback(args []string) error {
+	var configPath, username string
+	var id int
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	fs.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	fs.StringVar(&username, "username", "", "username of the player")
+	fs.IntVar(&id, "id", 0, "identifier of the snapshot to roll back to")
+	_ = fs.Parse(args)
+
+	if username == "" {
+		return fmt.Errorf("-username is required")
+	} else if id == 0 {
+		return fmt.Errorf("-id is required")
+	}
+
+	cfg, db, err := store.Open(configPath)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	snapshot, err := playerdata.NewHistory(db.Driver(), cfg.Store.SnapshotRetention).Schedule(username, id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule rollback: %s", err)
+	}
+
+	fmt.Printf("%s will be rolled back to snapshot #%d from %s on their next login\n", username, snapshot.ID,
+		snapshot.CreatedAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/game"
+	"github.com/mbpolan/openmcs/internal/logger"
+	"github.com/mbpolan/openmcs/internal/recording"
+	"github.com/mbpolan/openmcs/internal/server"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/telemetry"
+	"os"
+)
+
+// replay feeds a recorded session to a fresh game instance, and reports any differences between the responses that
+// were originally sent to the player and the ones produced by the replay.
+func main() {
+	var configPath, sessionPath string
+	var maxDiffs int
+	flag.StringVar(&configPath, "config-dir", ".", "directory where server config.yaml is located")
+	flag.StringVar(&sessionPath, "session", "", "path to a recorded session file")
+	flag.IntVar(&maxDiffs, "max-diffs", 20, "maximum number of differences to report")
+	flag.Parse()
+
+	if sessionPath == "" {
+		fmt.Println("-session is required")
+		os.Exit(1)
+	}
+
+	// load server configuration
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Printf("failed to load configuration: %s\n", err)
+		os.Exit(1)
+	}
+
+	// set up logging
+	err = logger.Setup(logger.Options{
+		LogLevel: cfg.Server.LogLevel,
+	})
+	if err != nil {
+		fmt.Printf("failed to initialize logger: %s\n", err)
+		os.Exit(1)
+	}
+
+	// read the recorded session
+	f, err := os.Open(sessionPath)
+	if err != nil {
+		fmt.Printf("failed to open session: %s\n", err)
+		os.Exit(1)
+	}
+
+	session, err := recording.Read(f)
+	_ = f.Close()
+	if err != nil {
+		fmt.Printf("failed to read session: %s\n", err)
+		os.Exit(1)
+	}
+
+	// create a game instance just like the server would, but without starting its game loop
+	g, err := newGame(cfg)
+	if err != nil {
+		fmt.Printf("failed to create game: %s\n", err)
+		os.Exit(1)
+	}
+
+	buf := &bytes.Buffer{}
+	err = server.Replay(g, session, buf)
+	if err != nil {
+		fmt.Printf("failed to replay session: %s\n", err)
+		os.Exit(1)
+	}
+
+	replayed, err := recording.Read(buf)
+	if err != nil {
+		fmt.Printf("failed to read replayed session: %s\n", err)
+		os.Exit(1)
+	}
+
+	// compare the responses that were sent to the player
+	expected := session.Filter(recording.Outbound)
+	actual := replayed.Filter(recording.Outbound)
+	diffs := recording.Diff(expected, actual)
+
+	fmt.Printf("replayed %d inbound packets for player %s\n",
+		len(session.Filter(recording.Inbound)), session.Player.Username)
+	fmt.Printf("outbound packets: %d recorded, %d replayed, %d differences\n", len(expected), len(actual), len(diffs))
+
+	for i, diff := range diffs {
+		if i >= maxDiffs {
+			fmt.Printf("... and %d more\n", len(diffs)-maxDiffs)
+			break
+		}
+
+		fmt.Printf("#%d: %s\n", diff.Index, describe(diff))
+	}
+
+	if len(diffs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// newGame creates a game instance using the server configuration and persistent store.
+func newGame(cfg *config.Config) (*game.Game, error) {
+	s, err := store.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.Close()
+
+	attributes, err := s.LoadItemAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	// telemetry is never started, so nothing is recorded during a replay
+	tel, err := telemetry.Setup(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return game.NewGame(game.Options{
+		Config:         cfg,
+		ItemAttributes: attributes,
+		Telemetry:      tel,
+	})
+}
+
+// describe returns a human-readable explanation of a difference.
+func describe(diff recording.Difference) string {
+	if diff.Actual == nil {
+		return fmt.Sprintf("missing opcode %02x (%d bytes) recorded on tick %d",
+			diff.Expected.Opcode, len(diff.Expected.Payload), diff.Expected.Tick)
+	}
+
+	if diff.Expected == nil {
+		return fmt.Sprintf("unexpected opcode %02x (%d bytes) replayed on tick %d",
+			diff.Actual.Opcode, len(diff.Actual.Payload), diff.Actual.Tick)
+	}
+
+	if diff.Offset == -1 {
+		return fmt.Sprintf("expected opcode %02x on tick %d, got %02x on tick %d",
+			diff.Expected.Opcode, diff.Expected.Tick, diff.Actual.Opcode, diff.Actual.Tick)
+	}
+
+	return fmt.Sprintf("opcode %02x on tick %d differs at byte %d (%d bytes recorded, %d bytes replayed)",
+		diff.Expected.Opcode, diff.Expected.Tick, diff.Offset, len(diff.Expected.Payload), len(diff.Actual.Payload))
+}
@@ -20,10 +20,13 @@ type archiveFile struct {
 type Archive struct {
 	data  []byte
 	files map[int32]archiveFile
+	raw   []byte
 }
 
 // NewArchive returns a new handle for an archive with data.
 func NewArchive(data []byte) (*Archive, error) {
+	raw := data
+
 	// read archive properties from the raw data
 	r := NewDataReader(data)
 	compressedSize, err := r.Uint24()
@@ -96,9 +99,15 @@ func NewArchive(data []byte) (*Archive, error) {
 	return &Archive{
 		data:  data,
 		files: files,
+		raw:   raw,
 	}, nil
 }
 
+// Raw returns the archive data exactly as it's stored in the game cache, without any decompression applied.
+func (a *Archive) Raw() []byte {
+	return a.raw
+}
+
 // File returns the data associated with a file in the archive.
 func (a *Archive) File(name string) ([]byte, error) {
 	hash := int32(0)
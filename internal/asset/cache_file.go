@@ -45,6 +45,8 @@ func (c *CacheFile) Data(index int) ([]byte, error) {
 		return nil, err
 	}
 
+	defer dataFile.Close()
+
 	dataFileInfo, err := dataFile.Stat()
 	if err != nil {
 		return nil, err
@@ -56,6 +58,8 @@ func (c *CacheFile) Data(index int) ([]byte, error) {
 		return nil, err
 	}
 
+	defer idxFile.Close()
+
 	// seek to the position of the requested archive in the cache index file
 	_, err = idxFile.Seek(int64(index*6), 0)
 	if err != nil {
@@ -1,7 +1,10 @@
 package asset
 
 import (
+	"fmt"
 	"github.com/mbpolan/openmcs/internal/model"
+	"hash/crc32"
+	"sync"
 )
 
 const (
@@ -9,6 +12,12 @@ const (
 	cacheMap      = 4
 )
 
+// NumCaches is the number of cache indices available in the game cache.
+const NumCaches = 5
+
+// NumArchives is the number of archives in the main cache whose checksums are validated by the client.
+const NumArchives = 9
+
 const (
 	archiveConfig    int = 2
 	archiveInterface     = 3
@@ -20,6 +29,8 @@ type Manager struct {
 	baseDir  string
 	archives map[int]*Archive
 	caches   map[int]*CacheFile
+	crcs     []uint32
+	mu       sync.Mutex
 }
 
 // NewManager returns a new asset Manager instance with game assets located at the given baseDir. You should call
@@ -34,12 +45,18 @@ func NewManager(baseDir string) *Manager {
 
 // Close releases all resources related to located assets.
 func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.archives = map[int]*Archive{}
 	m.caches = map[int]*CacheFile{}
 }
 
 // Interfaces returns a slice of model.Interface data extracted from game assets.
 func (m *Manager) Interfaces() ([]*model.Interface, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	archive, err := m.archive(cacheMain, archiveInterface)
 	if err != nil {
 		return nil, err
@@ -51,6 +68,9 @@ func (m *Manager) Interfaces() ([]*model.Interface, error) {
 
 // Map returns the world map extracted from game assets and populated with objects.
 func (m *Manager) Map(objects []*model.WorldObject) (*model.Map, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	versions, err := m.archive(cacheMain, archiveVersions)
 	if err != nil {
 		return nil, err
@@ -67,6 +87,9 @@ func (m *Manager) Map(objects []*model.WorldObject) (*model.Map, error) {
 
 // Items returns a slice of model.Item data extracted from game assets.
 func (m *Manager) Items() ([]*model.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	archive, err := m.archive(cacheMain, archiveConfig)
 	if err != nil {
 		return nil, err
@@ -78,6 +101,9 @@ func (m *Manager) Items() ([]*model.Item, error) {
 
 // WorldObjects returns a slice of model.WorldObject data extracted from game assets.
 func (m *Manager) WorldObjects() ([]*model.WorldObject, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	archive, err := m.archive(cacheMain, archiveConfig)
 	if err != nil {
 		return nil, err
@@ -88,6 +114,56 @@ func (m *Manager) WorldObjects() ([]*model.WorldObject, error) {
 	return woLoader.Load()
 }
 
+// ArchiveData returns the raw data for an archive located in the main cache.
+func (m *Manager) ArchiveData(id int) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	archive, err := m.archive(cacheMain, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return archive.Raw(), nil
+}
+
+// ArchiveCRCs returns the CRC32 checksums of each archive in the main cache, indexed by archive ID. The first archive
+// is unused by the client, and its checksum is always zero. Checksums are only computed once and reused on subsequent
+// calls.
+func (m *Manager) ArchiveCRCs() ([]uint32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.crcs != nil {
+		return m.crcs, nil
+	}
+
+	crcs := make([]uint32, NumArchives)
+	for i := 1; i < NumArchives; i++ {
+		archive, err := m.archive(cacheMain, i)
+		if err != nil {
+			return nil, err
+		}
+
+		crcs[i] = crc32.ChecksumIEEE(archive.Raw())
+	}
+
+	m.crcs = crcs
+	return crcs, nil
+}
+
+// CacheData returns the raw, unprocessed data for a file located in a cache index.
+func (m *Manager) CacheData(cacheID int, fileID int) ([]byte, error) {
+	if cacheID < 0 || cacheID >= NumCaches {
+		return nil, fmt.Errorf("invalid cache index: %d", cacheID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.cache(cacheID).Data(fileID)
+}
+
 // cache returns a CacheFile for the given index ID.
 func (m *Manager) cache(id int) *CacheFile {
 	if cache, ok := m.caches[id]; ok {
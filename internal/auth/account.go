@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+// AccountManager creates, updates and deletes player accounts.
+type AccountManager struct {
+	cfg    config.RegistrationConfig
+	hasher PasswordHasher
+	mu     sync.Mutex
+	policy *UsernamePolicy
+	store  *store.Store
+}
+
+// NewAccountManager creates an account manager that persists accounts in a store. New accounts are given the starter
+// state described by the registration configuration.
+func NewAccountManager(cfg config.RegistrationConfig, hasher PasswordHasher, store *store.Store) *AccountManager {
+	return &AccountManager{
+		cfg:    cfg,
+		hasher: hasher,
+		policy: NewUsernamePolicy(cfg.Username),
+		store:  store,
+	}
+}
+
+// Create registers a new player account with a username and password. The player will be shown the character
+// designer the first time they log in.
+func (m *AccountManager) Create(username, password string) (*model.Player, error) {
+	err := m.policy.Validate(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if password == "" {
+		return nil, fmt.Errorf("password cannot be empty")
+	}
+
+	hash, err := m.hasher.Hash(password)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash password")
+	}
+
+	// prevent concurrent registrations from racing to claim the same username
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the client treats usernames that differ only in case, or in spaces and underscores, as the same player
+	existing, err := m.store.LoadPlayer(m.policy.normalize(username))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check for existing player")
+	} else if existing != nil {
+		return nil, fmt.Errorf("player %s already exists", existing.Username)
+	}
+
+	player := m.newPlayer(username)
+	player.PasswordHash = hash
+
+	err = m.store.CreatePlayer(player)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create player")
+	}
+
+	return player, nil
+}
+
+// ResetPassword changes the password of an existing player account.
+func (m *AccountManager) ResetPassword(username, password string) error {
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+
+	player, err := m.load(username)
+	if err != nil {
+		return err
+	}
+
+	hash, err := m.hasher.Hash(password)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash password")
+	}
+
+	return m.store.SavePasswordHash(player.ID, hash)
+}
+
+// Delete removes a player account and all of its data.
+func (m *AccountManager) Delete(username string) error {
+	player, err := m.load(username)
+	if err != nil {
+		return err
+	}
+
+	return m.store.DeletePlayer(player.ID)
+}
+
+// load loads an existing player account, returning an error if it does not exist.
+func (m *AccountManager) load(username string) (*model.Player, error) {
+	player, err := m.store.LoadPlayer(username)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load player")
+	} else if player == nil {
+		return nil, fmt.Errorf("player %s does not exist", username)
+	}
+
+	return player, nil
+}
+
+// newPlayer returns a player model with the configured starter position, skills and inventory.
+func (m *AccountManager) newPlayer(username string) *model.Player {
+	player := model.NewPlayer(username)
+	player.UpdateDesign = true
+	player.GlobalPos = model.Vector3D{
+		X: m.cfg.Spawn.X,
+		Y: m.cfg.Spawn.Y,
+		Z: m.cfg.Spawn.Z,
+	}
+
+	for _, skill := range m.cfg.Skills {
+		skillType := model.SkillType(skill.Skill)
+		if _, ok := player.Skills[skillType]; !ok || skill.Level < 1 || skill.Level > 99 {
+			continue
+		}
+
+		player.Skills[skillType].StatLevel = skill.Level
+		player.SetSkillExperience(skillType, model.SkillExperienceLevels[skill.Level])
+	}
+
+	// items are placeholders until the player is added to the game, which replaces them with their full models
+	for i, item := range m.cfg.Inventory {
+		if i >= model.MaxInventorySlots {
+			break
+		}
+
+		player.SetInventoryItem(&model.Item{ID: item.ItemID}, max(item.Amount, 1), i)
+	}
+
+	return player
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/store/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+func Test_AccountManager_Create_normalizedUsername(t *testing.T) {
+	d, err := driver.NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	m := NewAccountManager(config.RegistrationConfig{}, &BcryptHasher{Cost: bcrypt.MinCost}, store.NewWithDriver(&config.Config{}, d))
+
+	_, err = m.Create("bob smith", "secret")
+	require.NoError(t, err)
+
+	// the client treats spaces and underscores alike, so these usernames would be indistinguishable from the first
+	for _, username := range []string{"bob smith", "Bob Smith", "bob_smith", "BOB_SMITH"} {
+		_, err = m.Create(username, "secret")
+		assert.Error(t, err, username)
+	}
+
+	_, err = m.Create("bob smith2", "secret")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"math"
+	"strings"
+)
+
+// Algorithm names that can be configured for hashing passwords.
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// argon2idSaltLength is the length of the random salt generated for each argon2id hash, in bytes.
+const argon2idSaltLength = 16
+
+// argon2idKeyLength is the length of an argon2id hash, in bytes.
+const argon2idKeyLength = 32
+
+// PasswordHasher computes and verifies hashes of player passwords.
+type PasswordHasher interface {
+	// Hash computes a hash of a password that is suitable for storage.
+	Hash(password string) (string, error)
+	// Verify determines if a password matches a previously computed hash.
+	Verify(password, hash string) (bool, error)
+	// NeedsUpgrade returns true if a hash should be recomputed, either because it uses an outdated algorithm or
+	// because its cost parameters differ from the hasher's.
+	NeedsUpgrade(hash string) bool
+}
+
+// NewPasswordHasher returns a hasher that creates hashes using the configured algorithm. The hasher can verify
+// hashes created by any supported algorithm, including legacy unsalted SHA-512/256 hashes.
+func NewPasswordHasher(cfg config.PasswordHashingConfig) (PasswordHasher, error) {
+	var preferred PasswordHasher
+
+	switch strings.ToLower(cfg.Algorithm) {
+	case AlgorithmArgon2id:
+		// argon2 requires at least one pass and thread, and at least 8 KiB of memory per thread
+		params := cfg.Argon2id
+		if params.Iterations < 1 || int64(params.Iterations) > math.MaxUint32 {
+			return nil, fmt.Errorf("argon2id iterations must be between 1 and %d", uint32(math.MaxUint32))
+		} else if params.Parallelism < 1 || params.Parallelism > math.MaxUint8 {
+			return nil, fmt.Errorf("argon2id parallelism must be between 1 and %d", math.MaxUint8)
+		} else if params.MemoryKiB < 8*params.Parallelism || int64(params.MemoryKiB) > math.MaxUint32 {
+			return nil, fmt.Errorf("argon2id memory must be between %d KiB and %d KiB", 8*params.Parallelism,
+				uint32(math.MaxUint32))
+		}
+
+		preferred = &Argon2idHasher{
+			Memory:      uint32(cfg.Argon2id.MemoryKiB),
+			Iterations:  uint32(cfg.Argon2id.Iterations),
+			Parallelism: uint8(cfg.Argon2id.Parallelism),
+		}
+	case AlgorithmBcrypt:
+		if cfg.Bcrypt.Cost < bcrypt.MinCost || cfg.Bcrypt.Cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+
+		preferred = &BcryptHasher{
+			Cost: cfg.Bcrypt.Cost,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm: %s", cfg.Algorithm)
+	}
+
+	return &multiHasher{
+		preferred: preferred,
+		hashers: []formatHasher{
+			&Argon2idHasher{},
+			&BcryptHasher{},
+			&legacyHasher{},
+		},
+	}, nil
+}
+
+// formatHasher is a PasswordHasher that can tell if a hash is in the format it creates.
+type formatHasher interface {
+	PasswordHasher
+
+	// recognizes returns true if a hash is in the format created by the hasher, regardless of its parameters.
+	recognizes(hash string) bool
+}
+
+// multiHasher creates hashes with a preferred hasher, and verifies hashes with whichever hasher created them.
+type multiHasher struct {
+	preferred PasswordHasher
+	hashers   []formatHasher
+}
+
+// Hash computes a hash of a password using the preferred hasher.
+func (h *multiHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// Verify determines if a password matches a hash created by any supported hasher.
+func (h *multiHasher) Verify(password, hash string) (bool, error) {
+	for _, hasher := range h.hashers {
+		if hasher.recognizes(hash) {
+			return hasher.Verify(password, hash)
+		}
+	}
+
+	return false, fmt.Errorf("unrecognized password hash format")
+}
+
+// NeedsUpgrade returns true if a hash was not created by the preferred hasher with its current parameters.
+func (h *multiHasher) NeedsUpgrade(hash string) bool {
+	return h.preferred.NeedsUpgrade(hash)
+}
+
+// Argon2idHasher hashes passwords using argon2id with a random salt. Hashes are encoded in the PHC string format.
+type Argon2idHasher struct {
+	// Memory is the amount of memory used to compute a hash, in KiB.
+	Memory uint32
+	// Iterations is the number of passes made over the memory.
+	Iterations uint32
+	// Parallelism is the number of threads used to compute a hash.
+	Parallelism uint8
+}
+
+// argon2idParams are the parameters encoded in an argon2id hash.
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// Hash computes a hash of a password.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Iterations,
+		h.Parallelism, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify determines if a password matches a hash. The hash's own parameters are used, rather than the hasher's.
+func (h *Argon2idHasher) Verify(password, hash string) (bool, error) {
+	params, err := h.parse(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism,
+		uint32(len(params.key)))
+
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+// NeedsUpgrade returns true if a hash is not an argon2id hash, or if it was computed with different parameters.
+func (h *Argon2idHasher) NeedsUpgrade(hash string) bool {
+	params, err := h.parse(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != h.Memory || params.iterations != h.Iterations || params.parallelism != h.Parallelism
+}
+
+// recognizes returns true if a hash is an argon2id hash.
+func (h *Argon2idHasher) recognizes(hash string) bool {
+	_, err := h.parse(hash)
+	return err == nil
+}
+
+// parse extracts the parameters, salt and key from an encoded argon2id hash.
+func (h *Argon2idHasher) parse(hash string) (*argon2idParams, error) {
+	// expect a hash in the form of $argon2id$v=19$m=65536,t=3,p=2$salt$key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, fmt.Errorf("not an argon2id hash")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return nil, err
+	} else if version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version: %d", version)
+	}
+
+	params := &argon2idParams{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil {
+		return nil, err
+	} else if params.iterations < 1 || params.parallelism < 1 {
+		return nil, fmt.Errorf("invalid argon2id parameters: %s", parts[3])
+	}
+
+	params.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, err
+	}
+
+	params.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// BcryptHasher hashes passwords using bcrypt.
+type BcryptHasher struct {
+	// Cost is the bcrypt cost factor.
+	Cost int
+}
+
+// Hash computes a hash of a password.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify determines if a password matches a hash.
+func (h *BcryptHasher) Verify(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NeedsUpgrade returns true if a hash is not a bcrypt hash, or if it was computed with a different cost.
+func (h *BcryptHasher) NeedsUpgrade(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+
+	return cost != h.Cost
+}
+
+// recognizes returns true if a hash is a bcrypt hash.
+func (h *BcryptHasher) recognizes(hash string) bool {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
+
+// legacyHasher verifies unsalted, hex-encoded SHA-512/256 hashes that were used before salted hashing was introduced.
+type legacyHasher struct {
+}
+
+// Hash computes a hash of a password.
+func (h *legacyHasher) Hash(password string) (string, error) {
+	hash := sha512.Sum512_256([]byte(password))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Verify determines if a password matches a hash.
+func (h *legacyHasher) Verify(password, hash string) (bool, error) {
+	expected, _ := h.Hash(password)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(hash))) == 1, nil
+}
+
+// NeedsUpgrade always returns true, since legacy hashes are unsalted and should be replaced whenever possible.
+func (h *legacyHasher) NeedsUpgrade(hash string) bool {
+	return true
+}
+
+// recognizes returns true if a hash is a hex-encoded SHA-512/256 hash.
+func (h *legacyHasher) recognizes(hash string) bool {
+	if len(hash) != sha512.Size256*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
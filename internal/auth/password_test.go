@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_PasswordHasher_Verify(t *testing.T) {
+	hasher, err := NewPasswordHasher(config.PasswordHashingConfig{
+		Algorithm: AlgorithmArgon2id,
+		Argon2id: config.Argon2idConfig{
+			MemoryKiB:   1024,
+			Iterations:  1,
+			Parallelism: 1,
+		},
+	})
+	assert.NoError(t, err)
+
+	hash, err := hasher.Hash("secret")
+	assert.NoError(t, err)
+	assert.False(t, hasher.NeedsUpgrade(hash))
+
+	valid, err := hasher.Verify("secret", hash)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hasher.Verify("wrong", hash)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	// legacy sha512/256 hashes are still accepted, but flagged for an upgrade
+	legacy, _ := (&legacyHasher{}).Hash("secret")
+	assert.True(t, hasher.NeedsUpgrade(legacy))
+
+	valid, err = hasher.Verify("secret", legacy)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// hashes computed with other parameters are flagged for an upgrade
+	bcryptHash, err := (&BcryptHasher{Cost: 4}).Hash("secret")
+	assert.NoError(t, err)
+	assert.True(t, hasher.NeedsUpgrade(bcryptHash))
+
+	valid, err = hasher.Verify("secret", bcryptHash)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	weaker, err := (&Argon2idHasher{Memory: 512, Iterations: 1, Parallelism: 1}).Hash("secret")
+	assert.NoError(t, err)
+	assert.True(t, hasher.NeedsUpgrade(weaker))
+}
+
+func Test_NewPasswordHasher_invalidParameters(t *testing.T) {
+	tests := map[string]config.PasswordHashingConfig{
+		"missing argon2id parameters": {Algorithm: AlgorithmArgon2id},
+		"argon2id iterations": {Algorithm: AlgorithmArgon2id, Argon2id: config.Argon2idConfig{
+			MemoryKiB: 1024, Iterations: 0, Parallelism: 1,
+		}},
+		"argon2id parallelism": {Algorithm: AlgorithmArgon2id, Argon2id: config.Argon2idConfig{
+			MemoryKiB: 4096, Iterations: 1, Parallelism: 256,
+		}},
+		"argon2id memory": {Algorithm: AlgorithmArgon2id, Argon2id: config.Argon2idConfig{
+			MemoryKiB: 8, Iterations: 1, Parallelism: 2,
+		}},
+		"missing bcrypt cost": {Algorithm: AlgorithmBcrypt},
+		"bcrypt cost":         {Algorithm: AlgorithmBcrypt, Bcrypt: config.BcryptConfig{Cost: 32}},
+		"unknown algorithm":   {Algorithm: "md5"},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPasswordHasher(cfg)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := NewPasswordHasher(config.PasswordHashingConfig{
+		Algorithm: AlgorithmBcrypt,
+		Bcrypt:    config.BcryptConfig{Cost: 4},
+	})
+	assert.NoError(t, err)
+}
+
+func Test_PasswordHasher_Verify_invalidArgon2idParameters(t *testing.T) {
+	hasher, err := NewPasswordHasher(config.PasswordHashingConfig{
+		Algorithm: AlgorithmBcrypt,
+		Bcrypt:    config.BcryptConfig{Cost: 4},
+	})
+	assert.NoError(t, err)
+
+	// a stored hash with parameters that argon2 cannot use is rejected instead of computed
+	_, err = hasher.Verify("secret", "$argon2id$v=19$m=1024,t=0,p=0$c2FsdA$a2V5")
+	assert.Error(t, err)
+}
+
+func Test_legacyHasher_NeedsUpgrade(t *testing.T) {
+	h := &legacyHasher{}
+	hash, err := h.Hash("secret")
+	assert.NoError(t, err)
+
+	// legacy hashes are recognized, but always need to be upgraded
+	assert.True(t, h.recognizes(hash))
+	assert.True(t, h.NeedsUpgrade(hash))
+	assert.False(t, h.recognizes("$2a$04$abc"))
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"strings"
+)
+
+// RolePermissions maps player roles to the permissions granted to them.
+type RolePermissions struct {
+	roles map[model.PlayerType]map[model.Permission]bool
+}
+
+// NewRolePermissions creates a permission model from the permissions granted to each role. An error is returned if
+// an unknown role or permission is configured. Roles that are not configured are granted no permissions.
+func NewRolePermissions(cfg config.PermissionsConfig) (*RolePermissions, error) {
+	known := map[model.Permission]bool{
+		model.PermissionAll: true,
+	}
+
+	for _, permission := range model.Permissions {
+		known[permission] = true
+	}
+
+	r := &RolePermissions{
+		roles: map[model.PlayerType]map[model.Permission]bool{},
+	}
+
+	for name, permissions := range cfg.Roles {
+		role, ok := model.PlayerRoles[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown player role: %s", name)
+		}
+
+		granted := map[model.Permission]bool{}
+		for _, p := range permissions {
+			permission := model.Permission(p)
+			if !known[permission] {
+				return nil, fmt.Errorf("unknown permission %s for role %s", p, name)
+			}
+
+			granted[permission] = true
+		}
+
+		r.roles[role] = granted
+	}
+
+	return r, nil
+}
+
+// Allowed determines if a role has been granted a permission.
+func (r *RolePermissions) Allowed(role model.PlayerType, permission model.Permission) bool {
+	granted, ok := r.roles[role]
+	if !ok {
+		return false
+	}
+
+	return granted[model.PermissionAll] || granted[permission]
+}
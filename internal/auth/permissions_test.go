@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_RolePermissions_Allowed(t *testing.T) {
+	permissions, err := NewRolePermissions(config.PermissionsConfig{
+		Roles: map[string][]string{
+			"moderator": {"kick", "mute"},
+			"admin":     {"*"},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, permissions.Allowed(model.PlayerModerator, model.PermissionKick))
+	assert.True(t, permissions.Allowed(model.PlayerModerator, model.PermissionMute))
+	assert.False(t, permissions.Allowed(model.PlayerModerator, model.PermissionSpawnItem))
+	assert.True(t, permissions.Allowed(model.PlayerAdmin, model.PermissionSpawnItem))
+
+	// roles without any configured permissions are not granted anything
+	assert.False(t, permissions.Allowed(model.PlayerNormal, model.PermissionPosition))
+
+	_, err = NewRolePermissions(config.PermissionsConfig{
+		Roles: map[string][]string{
+			"moderator": {"spawnItems"},
+		},
+	})
+	assert.Error(t, err)
+
+	_, err = NewRolePermissions(config.PermissionsConfig{
+		Roles: map[string][]string{
+			"owner": {"*"},
+		},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"strings"
+)
+
+// UsernamePolicy validates the usernames of new player accounts.
+type UsernamePolicy struct {
+	allowed   map[rune]bool
+	maxLength int
+	minLength int
+	reserved  map[string]bool
+}
+
+// NewUsernamePolicy creates a policy from a set of username rules. If no allowed characters are configured, any
+// character is allowed.
+func NewUsernamePolicy(cfg config.UsernamePolicyConfig) *UsernamePolicy {
+	p := &UsernamePolicy{
+		maxLength: cfg.MaxLength,
+		minLength: cfg.MinLength,
+		reserved:  map[string]bool{},
+	}
+
+	if cfg.AllowedCharacters != "" {
+		p.allowed = map[rune]bool{}
+		for _, ch := range strings.ToLower(cfg.AllowedCharacters) {
+			p.allowed[ch] = true
+		}
+	}
+
+	for _, name := range cfg.Reserved {
+		p.reserved[p.normalize(name)] = true
+	}
+
+	return p
+}
+
+// Validate returns an error describing why a username cannot be registered, or nil if it is acceptable.
+func (p *UsernamePolicy) Validate(username string) error {
+	length := len([]rune(username))
+	if length == 0 || length < p.minLength {
+		return fmt.Errorf("username must be at least %d characters", max(p.minLength, 1))
+	} else if p.maxLength > 0 && length > p.maxLength {
+		return fmt.Errorf("username must be at most %d characters", p.maxLength)
+	}
+
+	// usernames cannot start or end with whitespace, since the client trims it
+	if strings.TrimSpace(username) != username {
+		return fmt.Errorf("username cannot start or end with a space")
+	}
+
+	if p.allowed != nil {
+		for _, ch := range strings.ToLower(username) {
+			if !p.allowed[ch] {
+				return fmt.Errorf("username cannot contain the character %q", ch)
+			}
+		}
+	}
+
+	if p.reserved[p.normalize(username)] {
+		return fmt.Errorf("username %s is reserved", username)
+	}
+
+	return nil
+}
+
+// normalize returns a form of a username used for comparisons.
+func (p *UsernamePolicy) normalize(username string) string {
+	return model.NormalizeUsername(username)
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_UsernamePolicy_Validate(t *testing.T) {
+	policy := NewUsernamePolicy(config.UsernamePolicyConfig{
+		MinLength:         3,
+		MaxLength:         12,
+		AllowedCharacters: "abcdefghijklmnopqrstuvwxyz0123456789 _",
+		Reserved:          []string{"Mod Mike"},
+	})
+
+	assert.NoError(t, policy.Validate("Mike"))
+	assert.NoError(t, policy.Validate("Mike_123"))
+	assert.Error(t, policy.Validate(""))
+	assert.Error(t, policy.Validate("Mi"))
+	assert.Error(t, policy.Validate("MikeMikeMikeM"))
+	assert.Error(t, policy.Validate(" Mike"))
+	assert.Error(t, policy.Validate("Mike!"))
+
+	// reserved names are matched ignoring case, treating spaces and underscores alike
+	assert.Error(t, policy.Validate("mod_mike"))
+}
@@ -0,0 +1,397 @@
+package client
+
+import (
+	"crypto/rsa"
+	"encoding/binary"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/common"
+	"github.com/mbpolan/openmcs/internal/network/request"
+	"github.com/mbpolan/openmcs/internal/util"
+	"github.com/pkg/errors"
+	"io"
+	"math/big"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the client version reported to the server.
+const Version = 317
+
+// dialTimeout is the maximum amount of time to wait for a connection to the server.
+const dialTimeout = 10 * time.Second
+
+// login response codes sent by the server.
+const (
+	initAccepted byte = 0x00
+	initLoggedIn byte = 0x02
+)
+
+// LoginError is returned when the server rejects a login attempt.
+type LoginError struct {
+	// Code is the failure code sent by the server.
+	Code byte
+}
+
+// Error returns a description of the error.
+func (e *LoginError) Error() string {
+	return fmt.Sprintf("login rejected with code %d", e.Code)
+}
+
+// Options contains parameters to configure a Client instance.
+type Options struct {
+	// Address is the host and port of the game server.
+	Address string
+	// Username is the player's username.
+	Username string
+	// Password is the player's password.
+	Password string
+	// CRCs are the archive checksums sent at login. These need to match the server's game cache.
+	CRCs []uint32
+	// PublicKey is an optional RSA key used to encrypt the login block. If nil, the block is sent in plaintext.
+	PublicKey *rsa.PublicKey
+	// LowMemory indicates if the client should report that it's running in low-memory mode.
+	LowMemory bool
+}
+
+// Client is a headless game client that can log into the server and perform basic player actions. It does not
+// interpret responses sent by the server, and instead discards them as they arrive.
+type Client struct {
+	conn     net.Conn
+	doneChan chan bool
+	err      error
+	mu       sync.Mutex
+	opts     Options
+	writer   *network.ProtocolWriter
+}
+
+// New returns a new client that is not yet connected to the server.
+func New(opts Options) *Client {
+	return &Client{
+		doneChan: make(chan bool),
+		opts:     opts,
+		writer:   network.NewBufferedWriter(),
+	}
+}
+
+// Connect establishes a connection to the server and logs in the player. If the server rejects the login, a
+// *LoginError is returned describing why.
+func (c *Client) Connect() error {
+	var err error
+	c.conn, err = net.DialTimeout("tcp", c.opts.Address, dialTimeout)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to server")
+	}
+
+	reader := network.NewReader(c.conn)
+
+	sessionKey, err := c.initialize(reader)
+	if err != nil {
+		_ = c.conn.Close()
+		return err
+	}
+
+	err = c.login(reader, sessionKey)
+	if err != nil {
+		_ = c.conn.Close()
+		return err
+	}
+
+	// discard all data sent by the server until the connection is closed
+	go func() {
+		_, err := io.Copy(io.Discard, reader)
+
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+
+		close(c.doneChan)
+	}()
+
+	return nil
+}
+
+// Done returns a channel that is closed once the connection to the server has been terminated.
+func (c *Client) Done() <-chan bool {
+	return c.doneChan
+}
+
+// Err returns the error that caused the connection to terminate, if any.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Close terminates the connection to the server.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	return c.conn.Close()
+}
+
+// KeepAlive sends a request to let the server know the client is still connected.
+func (c *Client) KeepAlive() error {
+	return c.send(func(w *network.ProtocolWriter) error {
+		return w.WriteHeader(request.KeepAliveRequestHeader)
+	})
+}
+
+// Walk sends a request to walk the player to a destination. The start is the first step of the path, in global
+// coordinates, and waypoints are each subsequent step relative to the start.
+func (c *Client) Walk(start model.Vector2D, waypoints []model.Vector2D, run bool) error {
+	return c.send(func(w *network.ProtocolWriter) error {
+		err := w.WriteHeader(request.WalkRequestHeader)
+		if err != nil {
+			return err
+		}
+
+		// write 1 byte for the size of the packet
+		err = w.WriteUint8(byte(len(waypoints)*2 + 5))
+		if err != nil {
+			return err
+		}
+
+		err = w.WriteUint16LEAlt(uint16(start.X))
+		if err != nil {
+			return err
+		}
+
+		for _, wp := range waypoints {
+			err = w.WriteUint8(byte(int8(wp.X)))
+			if err != nil {
+				return err
+			}
+
+			err = w.WriteUint8(byte(int8(wp.Y)))
+			if err != nil {
+				return err
+			}
+		}
+
+		err = w.WriteUint16LE(uint16(start.Y))
+		if err != nil {
+			return err
+		}
+
+		// the control key flag toggles running for this path
+		var ctrl byte = 0x00
+		if run {
+			ctrl = 0xFF
+		}
+
+		return w.WriteUint8(ctrl)
+	})
+}
+
+// Chat sends a public chat message.
+func (c *Client) Chat(text string, effect model.ChatEffect, color model.ChatColor) error {
+	encoded := util.EncodeChat(strings.ToLower(text))
+
+	return c.send(func(w *network.ProtocolWriter) error {
+		err := w.WriteHeader(request.PlayerChatRequestHeader)
+		if err != nil {
+			return err
+		}
+
+		// write 1 byte for the size of the packet, which includes the effect and color
+		err = w.WriteUint8(byte(len(encoded) + 2))
+		if err != nil {
+			return err
+		}
+
+		err = w.WriteUint8(0x80 - common.ChatEffectCode(effect))
+		if err != nil {
+			return err
+		}
+
+		err = w.WriteUint8(0x80 - common.ChatColorCode(color))
+		if err != nil {
+			return err
+		}
+
+		// the message is written in reverse order
+		for i := len(encoded) - 1; i >= 0; i-- {
+			err = w.WriteUint8(encoded[i] + 0x80)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ClickInterface sends a request to perform an action on an interface, such as clicking a button.
+func (c *Client) ClickInterface(actionID int) error {
+	return c.send(func(w *network.ProtocolWriter) error {
+		err := w.WriteHeader(request.InterfaceActionRequestHeader)
+		if err != nil {
+			return err
+		}
+
+		return w.WriteUint16(uint16(actionID))
+	})
+}
+
+// initialize performs the initial handshake with the server, and returns the session key issued by the server.
+func (c *Client) initialize(reader *network.ProtocolReader) (uint64, error) {
+	// the name hash is derived from the player's username
+	nameHash := byte((util.EncodeName(c.opts.Username) >> 16) & 0x1F)
+
+	_, err := c.conn.Write([]byte{request.InitRequestHeader, nameHash})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to send init request")
+	}
+
+	// skip padding bytes sent by the server
+	err = reader.Skip(8)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read init response")
+	}
+
+	code, err := reader.Uint8()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read init response")
+	}
+
+	if code != initAccepted {
+		return 0, &LoginError{Code: code}
+	}
+
+	sessionKey, err := reader.Uint64()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read session key")
+	}
+
+	return sessionKey, nil
+}
+
+// login sends the player's credentials to the server, and enables the packet header cipher once the player is logged
+// in.
+func (c *Client) login(reader *network.ProtocolReader, sessionKey uint64) error {
+	seeds := []uint32{rand.Uint32(), rand.Uint32(), uint32(sessionKey >> 32), uint32(sessionKey)}
+
+	// build the credentials block
+	block := []byte{0x0A}
+	for _, seed := range seeds {
+		block = binary.BigEndian.AppendUint32(block, seed)
+	}
+
+	block = binary.BigEndian.AppendUint32(block, rand.Uint32())
+	block = append(block, c.opts.Username...)
+	block = append(block, 0x0A)
+	block = append(block, c.opts.Password...)
+	block = append(block, 0x0A)
+
+	if c.opts.PublicKey != nil {
+		block = encryptRSABlock(c.opts.PublicKey, block)
+	}
+
+	lowMemory := byte(0x00)
+	if c.opts.LowMemory {
+		lowMemory = 0x01
+	}
+
+	packet := []byte{request.NewLoginRequestHeader, byte(len(block) + 41), 0xFF}
+	packet = binary.BigEndian.AppendUint16(packet, Version)
+	packet = append(packet, lowMemory)
+	for i := 0; i < 9; i++ {
+		var crc uint32
+		if i < len(c.opts.CRCs) {
+			crc = c.opts.CRCs[i]
+		}
+
+		packet = binary.BigEndian.AppendUint32(packet, crc)
+	}
+
+	packet = append(packet, byte(len(block)))
+	packet = append(packet, block...)
+
+	_, err := c.conn.Write(packet)
+	if err != nil {
+		return errors.Wrap(err, "failed to send login request")
+	}
+
+	code, err := reader.Uint8()
+	if err != nil {
+		return errors.Wrap(err, "failed to read login response")
+	}
+
+	if code != initLoggedIn {
+		return &LoginError{Code: code}
+	}
+
+	// skip player type and flagged status
+	err = reader.Skip(2)
+	if err != nil {
+		return errors.Wrap(err, "failed to read login response")
+	}
+
+	// the server expects packet headers to be encoded from now on
+	c.writer.SetCipher(network.NewISAACCipher(seeds))
+	return nil
+}
+
+// send writes a packet to the server.
+func (c *Client) send(write func(w *network.ProtocolWriter) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := write(c.writer)
+	if err != nil {
+		return err
+	}
+
+	buf, err := c.writer.Buffer()
+	if err != nil {
+		return err
+	}
+
+	defer buf.Reset()
+
+	_, err = c.conn.Write(buf.Bytes())
+	return err
+}
+
+// encryptRSABlock encrypts a block of data using raw RSA, without any padding.
+func encryptRSABlock(key *rsa.PublicKey, data []byte) []byte {
+	m := new(big.Int).SetBytes(data)
+	return new(big.Int).Exp(m, big.NewInt(int64(key.E)), key.N).Bytes()
+}
+
+// FetchCRCs requests the table of archive checksums from a JAGGRAB server.
+func FetchCRCs(address string) ([]uint32, error) {
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to jaggrab server")
+	}
+
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(fmt.Sprintf("JAGGRAB /crc%d\n\n", rand.Int31())))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send jaggrab request")
+	}
+
+	// the table contains nine checksums followed by a hash of the table itself
+	data := make([]byte, 40)
+	_, err = io.ReadFull(conn, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read crc table")
+	}
+
+	crcs := make([]uint32, 9)
+	for i := range crcs {
+		crcs[i] = binary.BigEndian.Uint32(data[i*4:])
+	}
+
+	return crcs, nil
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/request"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+// newTestClient returns a client connected to a pipe, and a reader for the other side of the pipe.
+func newTestClient() (*Client, *network.ProtocolReader) {
+	local, remote := net.Pipe()
+
+	c := New(Options{})
+	c.conn = local
+
+	return c, network.NewReader(remote)
+}
+
+func Test_Client_Walk(t *testing.T) {
+	c, r := newTestClient()
+	waypoints := []model.Vector2D{{X: 1, Y: 0}, {X: 2, Y: -1}}
+
+	go func() {
+		_ = c.Walk(model.Vector2D{X: 3200, Y: 3400}, waypoints, true)
+	}()
+
+	_, req, err := request.NewRegistry().Decode(r)
+	assert.NoError(t, err)
+
+	walk := req.(*request.WalkRequest)
+	assert.Equal(t, model.Vector2D{X: 3200, Y: 3400}, walk.Start)
+	assert.Equal(t, waypoints, walk.Waypoints)
+	assert.True(t, walk.ControlPressed)
+}
+
+func Test_Client_Chat(t *testing.T) {
+	c, r := newTestClient()
+
+	go func() {
+		_ = c.Chat("hello world", model.ChatEffectWave, model.ChatColorRed)
+	}()
+
+	_, req, err := request.NewRegistry().Decode(r)
+	assert.NoError(t, err)
+
+	chat := req.(*request.PlayerChatRequest)
+	assert.Equal(t, "hello world", chat.Text)
+	assert.Equal(t, model.ChatEffectWave, chat.Effect)
+	assert.Equal(t, model.ChatColorRed, chat.Color)
+}
+
+func Test_Client_ClickInterface(t *testing.T) {
+	c, r := newTestClient()
+
+	go func() {
+		_ = c.ClickInterface(2458)
+	}()
+
+	_, req, err := request.NewRegistry().Decode(r)
+	assert.NoError(t, err)
+	assert.Equal(t, 2458, req.(*request.InterfaceActionRequest).Action)
+}
+
+func Test_Client_login(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+
+	local, remote := net.Pipe()
+	c := New(Options{
+		Username:  "mike",
+		Password:  "secret",
+		CRCs:      []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8},
+		PublicKey: &key.PublicKey,
+	})
+	c.conn = local
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- c.login(network.NewReader(local), 0x1122334455667788)
+	}()
+
+	req := request.LoginRequest{PrivateKey: key}
+	assert.NoError(t, req.Read(network.NewReader(remote)))
+
+	_, err = remote.Write([]byte{0x02, 0x00, 0x00})
+	assert.NoError(t, err)
+	assert.NoError(t, <-errChan)
+
+	assert.Equal(t, uint16(Version), req.Version)
+	assert.Equal(t, "mike", req.Username)
+	assert.Equal(t, "secret", req.Password)
+	assert.Equal(t, []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8}, req.CRCs)
+	assert.Equal(t, uint64(0x1122334455667788), req.SessionKey())
+}
@@ -4,10 +4,18 @@ import "github.com/spf13/viper"
 
 // Config is the top-level configuration for the server and world.
 type Config struct {
-	Store      StoreConfig      `mapstructure:"store"`
-	Server     ServerConfig     `mapstructure:"server"`
-	Metrics    MetricsConfig    `mapstructure:"metrics"`
-	Interfaces InterfacesConfig `mapstructure:"interfaces"`
+	Store        StoreConfig        `mapstructure:"store"`
+	Server       ServerConfig       `mapstructure:"server"`
+	JAGGRAB      JAGGRABConfig      `mapstructure:"jaggrab"`
+	WebSocket    WebSocketConfig    `mapstructure:"webSocket"`
+	RateLimit    RateLimitConfig    `mapstructure:"rateLimit"`
+	Login        LoginConfig        `mapstructure:"login"`
+	Registration RegistrationConfig `mapstructure:"registration"`
+	Membership   MembershipConfig   `mapstructure:"membership"`
+	Moderation   ModerationConfig   `mapstructure:"moderation"`
+	Permissions  PermissionsConfig  `mapstructure:"permissions"`
+	Metrics      MetricsConfig      `mapstructure:"metrics"`
+	Interfaces   InterfacesConfig   `mapstructure:"interfaces"`
 }
 
 // ServerConfig contains parameters for the game server.
@@ -20,13 +28,154 @@ type ServerConfig struct {
 	LogLevel                 string `mapstructure:"logLevel"`
 	WelcomeMessage           string `mapstructure:"welcomeMessage"`
 	PlayerMaxIdleTimeSeconds int    `mapstructure:"playerMaxIdleTimeSeconds"`
+	PlayerReconnectSeconds   int    `mapstructure:"playerReconnectSeconds"`
+	RSAKeyFile               string `mapstructure:"rsaKeyFile"`
+	RecordingDir             string `mapstructure:"recordingDir"`
+}
+
+// JAGGRABConfig contains parameters for the JAGGRAB and HTTP file servers.
+type JAGGRABConfig struct {
+	Enabled  bool `mapstructure:"enabled"`
+	Port     int  `mapstructure:"port"`
+	HTTPPort int  `mapstructure:"httpPort"`
+}
+
+// WebSocketConfig contains parameters for accepting game connections over WebSocket.
+type WebSocketConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`
+	Port           int      `mapstructure:"port"`
+	Path           string   `mapstructure:"path"`
+	AllowedOrigins []string `mapstructure:"allowedOrigins"`
+}
+
+// RateLimitConfig contains parameters for limiting how many packets a client connection may send.
+type RateLimitConfig struct {
+	Enabled          bool                    `mapstructure:"enabled"`
+	PacketsPerSecond float64                 `mapstructure:"packetsPerSecond"`
+	Burst            int                     `mapstructure:"burst"`
+	PacketsPerTick   int                     `mapstructure:"packetsPerTick"`
+	Opcodes          []OpcodeRateLimitConfig `mapstructure:"opcodes"`
+}
+
+// OpcodeRateLimitConfig contains parameters for limiting how many packets with a particular opcode a client connection
+// may send.
+type OpcodeRateLimitConfig struct {
+	Opcode           int     `mapstructure:"opcode"`
+	PacketsPerSecond float64 `mapstructure:"packetsPerSecond"`
+	Burst            int     `mapstructure:"burst"`
+}
+
+// LoginConfig contains parameters for protecting player accounts against repeated failed login attempts.
+type LoginConfig struct {
+	MaxAttempts          int                   `mapstructure:"maxAttempts"`
+	MaxAddressAttempts   int                   `mapstructure:"maxAddressAttempts"`
+	AttemptWindowSeconds int                   `mapstructure:"attemptWindowSeconds"`
+	LockoutSeconds       int                   `mapstructure:"lockoutSeconds"`
+	MaxLockoutSeconds    int                   `mapstructure:"maxLockoutSeconds"`
+	PasswordHashing      PasswordHashingConfig `mapstructure:"passwordHashing"`
+}
+
+// PasswordHashingConfig contains parameters for hashing player passwords.
+type PasswordHashingConfig struct {
+	Algorithm string         `mapstructure:"algorithm"`
+	Argon2id  Argon2idConfig `mapstructure:"argon2id"`
+	Bcrypt    BcryptConfig   `mapstructure:"bcrypt"`
+}
+
+// Argon2idConfig contains cost parameters for the argon2id password hashing algorithm.
+type Argon2idConfig struct {
+	MemoryKiB   int `mapstructure:"memoryKiB"`
+	Iterations  int `mapstructure:"iterations"`
+	Parallelism int `mapstructure:"parallelism"`
+}
+
+// BcryptConfig contains cost parameters for the bcrypt password hashing algorithm.
+type BcryptConfig struct {
+	Cost int `mapstructure:"cost"`
+}
+
+// RegistrationConfig contains parameters for creating new player accounts.
+type RegistrationConfig struct {
+	AutoRegister bool                 `mapstructure:"autoRegister"`
+	Spawn        SpawnConfig          `mapstructure:"spawn"`
+	Skills       []StarterSkillConfig `mapstructure:"skills"`
+	Inventory    []StarterItemConfig  `mapstructure:"inventory"`
+	Username     UsernamePolicyConfig `mapstructure:"username"`
+}
+
+// SpawnConfig contains the position in global coordinates where new players are placed.
+type SpawnConfig struct {
+	X int `mapstructure:"x"`
+	Y int `mapstructure:"y"`
+	Z int `mapstructure:"z"`
+}
+
+// StarterSkillConfig contains the level a new player starts with in a skill.
+type StarterSkillConfig struct {
+	Skill int `mapstructure:"skill"`
+	Level int `mapstructure:"level"`
+}
+
+// StarterItemConfig contains an item placed in a new player's inventory.
+type StarterItemConfig struct {
+	ItemID int `mapstructure:"itemId"`
+	Amount int `mapstructure:"amount"`
+}
+
+// UsernamePolicyConfig contains rules that usernames of new player accounts must follow.
+type UsernamePolicyConfig struct {
+	MinLength         int      `mapstructure:"minLength"`
+	MaxLength         int      `mapstructure:"maxLength"`
+	AllowedCharacters string   `mapstructure:"allowedCharacters"`
+	Reserved          []string `mapstructure:"reserved"`
+}
+
+// MembershipConfig contains parameters for members-only content.
+type MembershipConfig struct {
+	Areas     []MembersAreaConfig `mapstructure:"areas"`
+	FreeSpawn SpawnConfig         `mapstructure:"freeSpawn"`
+}
+
+// MembersAreaConfig describes a rectangular area, in global coordinates, that only members may enter. The first point
+// is the north-west corner and the second point is the south-east corner.
+type MembersAreaConfig struct {
+	Name string `mapstructure:"name"`
+	X1   int    `mapstructure:"x1"`
+	Y1   int    `mapstructure:"y1"`
+	X2   int    `mapstructure:"x2"`
+	Y2   int    `mapstructure:"y2"`
+}
+
+// ModerationConfig contains parameters for moderating player chat.
+type ModerationConfig struct {
+	ReportChatLines   int `mapstructure:"reportChatLines"`
+	ReportMuteSeconds int `mapstructure:"reportMuteSeconds"`
+}
+
+// PermissionsConfig contains the permissions granted to each player role.
+type PermissionsConfig struct {
+	Roles map[string][]string `mapstructure:"roles"`
 }
 
 // StoreConfig contains parameters for the backend database.
 type StoreConfig struct {
-	Driver        string                 `mapstructure:"driver"`
-	MigrationsDir string                 `mapstructure:"migrationsDir"`
-	SQLite3       *SQLite3DatabaseConfig `mapstructure:"sqlite3"`
+	Driver            string                  `mapstructure:"driver"`
+	MigrationsDir     string                  `mapstructure:"migrationsDir"`
+	AutosaveSeconds   int                     `mapstructure:"autosaveSeconds"`
+	PersistWorldState bool                    `mapstructure:"persistWorldState"`
+	SnapshotRetention int                     `mapstructure:"snapshotRetention"`
+	Pool              StorePoolConfig         `mapstructure:"pool"`
+	SQLite3           *SQLite3DatabaseConfig  `mapstructure:"sqlite3"`
+	Postgres          *PostgresDatabaseConfig `mapstructure:"postgres"`
+	Memory            *MemoryDatabaseConfig   `mapstructure:"memory"`
+}
+
+// StorePoolConfig contains parameters for the pool of connections to the backend database.
+type StorePoolConfig struct {
+	MaxOpenConns           int `mapstructure:"maxOpenConns"`
+	MaxIdleConns           int `mapstructure:"maxIdleConns"`
+	ConnMaxLifetimeSeconds int `mapstructure:"connMaxLifetimeSeconds"`
+	ConnMaxIdleTimeSeconds int `mapstructure:"connMaxIdleTimeSeconds"`
 }
 
 // SQLite3DatabaseConfig contains parameters for a SQLIte3 database.
@@ -34,6 +183,16 @@ type SQLite3DatabaseConfig struct {
 	URI string `mapstructure:"uri"`
 }
 
+// PostgresDatabaseConfig contains parameters for a PostgreSQL database.
+type PostgresDatabaseConfig struct {
+	URI string `mapstructure:"uri"`
+}
+
+// MemoryDatabaseConfig contains parameters for an in-memory database.
+type MemoryDatabaseConfig struct {
+	Fixtures []string `mapstructure:"fixtures"`
+}
+
 // MetricsConfig contains metrics and telemetry configuration options.
 type MetricsConfig struct {
 	Enabled bool `mapstructure:"enabled"`
@@ -2,8 +2,10 @@ package game
 
 import (
 	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/moderation"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ChatCommandType enumerates possible chat commands recognized by the server.
@@ -20,8 +22,40 @@ const (
 	ChatCommandCharacterDesigner
 	ChatCommandReloadScripts
 	ChatCommandAnimate
+	ChatCommandBan
+	ChatCommandUnban
+	ChatCommandMute
+	ChatCommandUnmute
+	ChatCommandListReports
+	ChatCommandResolveReport
+	ChatCommandKick
+	ChatCommandListSnapshots
+	ChatCommandRollback
 )
 
+// chatCommandPermissions maps each chat command to the permission a player needs to use it.
+var chatCommandPermissions = map[ChatCommandType]model.Permission{
+	ChatCommandTypeSpawnItem:     model.PermissionSpawnItem,
+	ChatCommandTypeClearTile:     model.PermissionClearTile,
+	ChatCommandTypePosition:      model.PermissionPosition,
+	ChatCommandTeleport:          model.PermissionTeleport,
+	ChatCommandTeleportRelative:  model.PermissionTeleport,
+	ChatCommandShowInterface:     model.PermissionInterfaces,
+	ChatCommandHideInterfaces:    model.PermissionInterfaces,
+	ChatCommandCharacterDesigner: model.PermissionInterfaces,
+	ChatCommandReloadScripts:     model.PermissionReloadScripts,
+	ChatCommandAnimate:           model.PermissionAnimate,
+	ChatCommandBan:               model.PermissionBan,
+	ChatCommandUnban:             model.PermissionBan,
+	ChatCommandMute:              model.PermissionMute,
+	ChatCommandUnmute:            model.PermissionMute,
+	ChatCommandListReports:       model.PermissionReports,
+	ChatCommandResolveReport:     model.PermissionReports,
+	ChatCommandKick:              model.PermissionKick,
+	ChatCommandListSnapshots:     model.PermissionRollback,
+	ChatCommandRollback:          model.PermissionRollback,
+}
+
 // ChatCommandSpawnItemParams contains parameters for a chat command that spawns a ground Item.
 type ChatCommandSpawnItemParams struct {
 	ItemID             int
@@ -40,6 +74,38 @@ type ChatCommandAnimateParams struct {
 	Delay int
 }
 
+// ChatCommandBanParams contains parameters for issuing or lifting a ban. When issuing an address or client
+// identifier ban, the value is the username of an online player whose address or identifier should be banned.
+type ChatCommandBanParams struct {
+	Target   model.BanTarget
+	Value    string
+	Duration time.Duration
+	Reason   string
+}
+
+// ChatCommandMuteParams contains parameters for muting or unmuting a player.
+type ChatCommandMuteParams struct {
+	Username string
+	Duration time.Duration
+}
+
+// ChatCommandResolveReportParams contains parameters for resolving an abuse report.
+type ChatCommandResolveReportParams struct {
+	ReportID int
+}
+
+// ChatCommandKickParams contains parameters for disconnecting a player.
+type ChatCommandKickParams struct {
+	Username string
+}
+
+// ChatCommandRollbackParams contains parameters for listing a player's snapshots or rolling them back to one. The
+// snapshot ID is only used when rolling back.
+type ChatCommandRollbackParams struct {
+	Username   string
+	SnapshotID int
+}
+
 // ChatCommand is a game command embedded in a player chat message.
 type ChatCommand struct {
 	Type          ChatCommandType
@@ -47,6 +113,11 @@ type ChatCommand struct {
 	SpawnItem     *ChatCommandSpawnItemParams
 	ShowInterface *ChatCommandShowInterfaceParams
 	Animate       *ChatCommandAnimateParams
+	Ban           *ChatCommandBanParams
+	Mute          *ChatCommandMuteParams
+	ResolveReport *ChatCommandResolveReportParams
+	Kick          *ChatCommandKickParams
+	Rollback      *ChatCommandRollbackParams
 }
 
 // ParseChatCommand attempts to parse a chat command from a string of text. If no recognized command is found, then
@@ -57,9 +128,10 @@ func ParseChatCommand(text string) *ChatCommand {
 		return nil
 	}
 
-	// the command is the first element and optional arguments follow
+	// the command is the first element and optional arguments follow. free-form text arguments retain their case.
 	command := parts[0]
 	args := parts[1:]
+	rawArgs := strings.Split(text, " ")[1:]
 
 	switch command {
 	case "i":
@@ -246,8 +318,179 @@ func ParseChatCommand(text string) *ChatCommand {
 			},
 		}
 
+	case "ban", "ipban", "uidban":
+		// ban a player's account, or the network address or client of an online player
+		if len(args) < 1 {
+			return nil
+		}
+
+		target := model.BanTargetAccount
+		if command == "ipban" {
+			target = model.BanTargetAddress
+		} else if command == "uidban" {
+			target = model.BanTargetUID
+		}
+
+		// first required argument is the username, followed by an optional duration and reason
+		params := &ChatCommandBanParams{
+			Target: target,
+			Value:  chatCommandUsername(args[0]),
+		}
+
+		reasonStart := 1
+		if len(args) > 1 {
+			if duration, err := moderation.ParseDuration(args[1]); err == nil {
+				params.Duration = duration
+				reasonStart = 2
+			}
+		}
+
+		if len(rawArgs) > reasonStart {
+			params.Reason = strings.Join(rawArgs[reasonStart:], " ")
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandBan,
+			Ban:  params,
+		}
+
+	case "unban", "unbanip", "unbanuid":
+		// lift bans against a username, network address or client identifier
+		if len(args) != 1 {
+			return nil
+		}
+
+		params := &ChatCommandBanParams{
+			Target: model.BanTargetAccount,
+			Value:  chatCommandUsername(args[0]),
+		}
+
+		if command == "unbanip" {
+			params.Target = model.BanTargetAddress
+			params.Value = args[0]
+		} else if command == "unbanuid" {
+			params.Target = model.BanTargetUID
+			params.Value = args[0]
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandUnban,
+			Ban:  params,
+		}
+
+	case "mute":
+		// mute a player, with an optional duration
+		if len(args) < 1 || len(args) > 2 {
+			return nil
+		}
+
+		params := &ChatCommandMuteParams{
+			Username: chatCommandUsername(args[0]),
+		}
+
+		if len(args) > 1 {
+			duration, err := moderation.ParseDuration(args[1])
+			if err != nil {
+				return nil
+			}
+
+			params.Duration = duration
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandMute,
+			Mute: params,
+		}
+
+	case "unmute":
+		if len(args) != 1 {
+			return nil
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandUnmute,
+			Mute: &ChatCommandMuteParams{
+				Username: chatCommandUsername(args[0]),
+			},
+		}
+
+	case "reports":
+		// list open abuse reports
+		return &ChatCommand{
+			Type: ChatCommandListReports,
+		}
+
+	case "resolve":
+		// resolve an abuse report
+		if len(args) != 1 {
+			return nil
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandResolveReport,
+			ResolveReport: &ChatCommandResolveReportParams{
+				ReportID: id,
+			},
+		}
+
+	case "kick":
+		// disconnect an online player
+		if len(args) != 1 {
+			return nil
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandKick,
+			Kick: &ChatCommandKickParams{
+				Username: chatCommandUsername(args[0]),
+			},
+		}
+
+	case "snapshots":
+		// list the saved snapshots of a player
+		if len(args) != 1 {
+			return nil
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandListSnapshots,
+			Rollback: &ChatCommandRollbackParams{
+				Username: chatCommandUsername(args[0]),
+			},
+		}
+
+	case "rollback":
+		// roll a player back to one of their snapshots the next time they log in
+		if len(args) != 2 {
+			return nil
+		}
+
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil
+		}
+
+		return &ChatCommand{
+			Type: ChatCommandRollback,
+			Rollback: &ChatCommandRollbackParams{
+				Username:   chatCommandUsername(args[0]),
+				SnapshotID: id,
+			},
+		}
+
 	default:
 	}
 
 	return nil
 }
+
+// chatCommandUsername returns a username given as a chat command argument. Since arguments are separated by spaces,
+// spaces in usernames are written as underscores.
+func chatCommandUsername(arg string) string {
+	return strings.ReplaceAll(arg, "_", " ")
+}
@@ -3,18 +3,24 @@ package game
 import (
 	"fmt"
 	"github.com/mbpolan/openmcs/internal/asset"
+	"github.com/mbpolan/openmcs/internal/auth"
 	"github.com/mbpolan/openmcs/internal/config"
 	"github.com/mbpolan/openmcs/internal/interaction"
 	"github.com/mbpolan/openmcs/internal/logger"
 	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/moderation"
 	"github.com/mbpolan/openmcs/internal/network"
 	"github.com/mbpolan/openmcs/internal/network/response"
+	"github.com/mbpolan/openmcs/internal/recording"
+	"github.com/mbpolan/openmcs/internal/store/playerdata"
 	"github.com/mbpolan/openmcs/internal/telemetry"
 	"github.com/mbpolan/openmcs/internal/util"
 	"github.com/pkg/errors"
 	"math"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,6 +36,12 @@ const itemDespawnInterval = 3 * time.Minute
 // maxPlayers is the maximum amount of players that can be connected to the game server.
 const maxPlayers = 2000
 
+// maxListedReports is the maximum amount of open abuse reports listed to a moderator at once.
+const maxListedReports = 10
+
+// maxListedSnapshots is the maximum amount of a player's snapshots listed to an administrator at once.
+const maxListedSnapshots = 10
+
 // maxSkillExperience is the maximum amount of experience a player can have in a skill.
 const maxSkillExperience = 200_000_000
 
@@ -47,31 +59,46 @@ const (
 
 // Options are parameters that configure how the game engine behaves.
 type Options struct {
+	Bans           *moderation.BanList
 	Config         *config.Config
 	ItemAttributes []*model.ItemAttributes
+	Mutes          *moderation.MuteList
+	Reports        *moderation.ReportQueue
+	Snapshots      *playerdata.History
 	Telemetry      telemetry.Telemetry
+	WorldState     *model.WorldState
 }
 
 // Game is the game engine and representation of the game world.
 type Game struct {
+	bans                  *moderation.BanList
 	doneChan              chan bool
+	freeSpawn             model.Vector3D
 	interaction           *interaction.Manager
 	interfaces            map[int]*model.Interface
 	items                 map[int]*model.Item
 	lastPlayerUpdate      time.Time
 	ticker                *time.Ticker
 	mapManager            *MapManager
+	membersAreas          []model.Rectangle
 	mu                    sync.RWMutex
+	mutes                 *moderation.MuteList
 	players               []*playerEntity
 	playerIndices         [maxPlayers]int
 	playerMaxIdleInterval time.Duration
+	playerReconnectWindow time.Duration
 	objects               []*model.WorldObject
+	permissions           *auth.RolePermissions
 	playersOnline         sync.Map
 	removePlayers         map[int]*playerEntity
 	regions               map[model.Vector2D]*RegionManager
+	reportChatLines       int
+	reportMuteDuration    time.Duration
+	reports               *moderation.ReportQueue
 	scripts               *ScriptManager
+	snapshots             *playerdata.History
 	telemetry             telemetry.Telemetry
-	tick                  uint64
+	tick                  atomic.Uint64
 	welcomeMessage        string
 	worldID               int
 	worldMap              *model.Map
@@ -80,19 +107,44 @@ type Game struct {
 // NewGame creates a new game engine using the given configuration.
 func NewGame(opts Options) (*Game, error) {
 	g := &Game{
+		bans:                  opts.Bans,
 		doneChan:              make(chan bool, 1),
 		interaction:           interaction.New(opts.Config.Interfaces),
 		interfaces:            map[int]*model.Interface{},
 		items:                 map[int]*model.Item{},
+		mutes:                 opts.Mutes,
 		playerIndices:         [maxPlayers]int{},
 		playerMaxIdleInterval: time.Duration(int64(opts.Config.Server.PlayerMaxIdleTimeSeconds) * int64(time.Second)),
+		playerReconnectWindow: time.Duration(int64(opts.Config.Server.PlayerReconnectSeconds) * int64(time.Second)),
 		removePlayers:         map[int]*playerEntity{},
+		reportChatLines:       opts.Config.Moderation.ReportChatLines,
+		reportMuteDuration:    time.Duration(opts.Config.Moderation.ReportMuteSeconds) * time.Second,
+		reports:               opts.Reports,
+		snapshots:             opts.Snapshots,
 		telemetry:             opts.Telemetry,
-		tick:                  0,
 		welcomeMessage:        opts.Config.Server.WelcomeMessage,
 		worldID:               opts.Config.Server.WorldID,
 	}
 
+	// load the permissions granted to each player role
+	permissions, err := auth.NewRolePermissions(opts.Config.Permissions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load role permissions")
+	}
+
+	g.permissions = permissions
+
+	// load areas that only members may enter, and where free players are moved to when found in them
+	g.freeSpawn = model.Vector3D{
+		X: opts.Config.Membership.FreeSpawn.X,
+		Y: opts.Config.Membership.FreeSpawn.Y,
+		Z: opts.Config.Membership.FreeSpawn.Z,
+	}
+
+	for _, area := range opts.Config.Membership.Areas {
+		g.membersAreas = append(g.membersAreas, model.MakeRectangle(area.X1, area.Y1, area.X2, area.Y2))
+	}
+
 	// initialize player index tracker
 	for i := range g.playerIndices {
 		g.playerIndices[i] = -1
@@ -119,9 +171,9 @@ func NewGame(opts Options) (*Game, error) {
 	logger.Infof("loaded assets in: %s", time.Now().Sub(start))
 	start = time.Now()
 
-	// initialize the map manager and perform a warm-up for map regions
+	// initialize the map manager and perform a warm-up for map regions, restoring the world state from a previous run
 	g.mapManager = NewMapManager(g.worldMap)
-	g.mapManager.WarmUp()
+	g.mapManager.WarmUp(opts.WorldState, g.items)
 	g.mapManager.Start()
 
 	logger.Infof("finished map warm-up in: %s", time.Now().Sub(start))
@@ -150,6 +202,74 @@ func (g *Game) Run() {
 	go g.loop()
 }
 
+// Tick returns the number of game state updates that have been performed so far.
+func (g *Game) Tick() uint64 {
+	return g.tick.Load()
+}
+
+// Step performs a single game state update. This is done automatically by the game loop once Run is called, and
+// should only be called directly when driving the game manually, such as when replaying a recorded session.
+func (g *Game) Step() error {
+	err := g.handleGameUpdate()
+	if err != nil {
+		return err
+	}
+
+	g.tick.Add(1)
+	return nil
+}
+
+// FlushPlayer blocks until all responses queued for a player have been sent to their client.
+func (g *Game) FlushPlayer(p *model.Player) {
+	pe, unlockFunc := g.findPlayerAndLockGame(p)
+	if pe == nil {
+		unlockFunc()
+		return
+	}
+
+	flush := &flushResponse{done: make(chan bool)}
+	pe.Send(flush)
+	unlockFunc()
+
+	<-flush.done
+}
+
+// SnapshotPlayers returns copies of the persistent data of all players in the game world, including those whose client
+// is waiting to reconnect. Each player is locked only while their snapshot is taken.
+// Concurrency requirements: (a) game state should NOT be locked and (b) all players should NOT be locked.
+func (g *Game) SnapshotPlayers() []*model.Player {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	snapshots := make([]*model.Player, 0, len(g.players))
+	for _, pe := range g.players {
+		pe.mu.Lock()
+		snapshots = append(snapshots, pe.player.Snapshot())
+		pe.mu.Unlock()
+	}
+
+	return snapshots
+}
+
+// SnapshotPlayer returns a copy of the persistent data of a player in the game world, or nil if the player is not in
+// the game world.
+// Concurrency requirements: (a) game state should NOT be locked and (b) this player should NOT be locked.
+func (g *Game) SnapshotPlayer(p *model.Player) *model.Player {
+	pe, unlockFunc := g.findPlayerAndLockAll(p)
+	defer unlockFunc()
+
+	if pe == nil {
+		return nil
+	}
+
+	return pe.player.Snapshot()
+}
+
+// SnapshotWorldState returns the mutable state of the world map, such as ground items, so that it can be saved.
+func (g *Game) SnapshotWorldState() *model.WorldState {
+	return g.mapManager.Snapshot()
+}
+
 // AddFriend attempts to add another player to the player's friends list.
 func (g *Game) AddFriend(p *model.Player, username string) {
 	g.addToList(p, username, true)
@@ -197,10 +317,56 @@ func (g *Game) SetPlayerModes(p *model.Player, publicChat model.ChatMode, privat
 	}
 }
 
-// ProcessAbuseReport handles an abuse report sent by a player.
+// ProcessAbuseReport handles an abuse report sent by a player. The offender's recent chat messages are attached to the
+// report, and moderators may also mute the offender as part of their report.
 func (g *Game) ProcessAbuseReport(p *model.Player, username string, reason int, mute bool) {
-	// TODO: log the report and mute target player, if they exist
+	pe, unlockFunc := g.findPlayerAndLockGame(p)
+	if pe == nil {
+		return
+	}
+
 	logger.Infof("player %s reported %s for abuse reason %d (mute? %t)", p.Username, username, reason, mute)
+
+	report := &model.AbuseReport{
+		Reporter: pe.player.Username,
+		Offender: username,
+		Reason:   reason,
+	}
+
+	// attach the offender's recent chat messages if they are online
+	if target := g.findPlayerByUsername(username); target != nil {
+		target.mu.Lock()
+		report.Offender = target.player.Username
+		report.Chat = append([]*model.ChatLogEntry{}, target.recentChat...)
+		target.mu.Unlock()
+	}
+
+	// only players allowed to mute others can mute the offender when filing a report
+	mute = mute && g.hasPermission(pe.player, model.PermissionMute) && g.mutes != nil
+
+	// the mute and report are persisted once the game state is unlocked
+	unlockFunc()
+
+	if mute {
+		found, err := g.handleMutePlayer(username, true, g.reportMuteDuration)
+		if err != nil {
+			logger.Errorf("failed to mute reported player %s: %s", username, err)
+		}
+
+		report.Muted = found
+	}
+
+	if g.reports == nil {
+		return
+	}
+
+	err := g.reports.Submit(report)
+	if err != nil {
+		logger.Errorf("failed to save abuse report against %s: %s", username, err)
+		return
+	}
+
+	pe.Send(response.NewServerMessageResponse("Thank-you, your abuse report has been received."))
 }
 
 // MarkPlayerActive updates a player's last activity tracker and prevents them from becoming idle.
@@ -287,23 +453,34 @@ func (g *Game) DoSetPlayerDesign(p *model.Player, gender model.EntityGender, bas
 // DoPlayerChatCommand handles a chat command sent by a player.
 func (g *Game) DoPlayerChatCommand(p *model.Player, text string) {
 	pe, unlockFunc := g.findPlayerAndLockAll(p)
-	defer unlockFunc()
 	if pe == nil {
 		return
 	}
 
-	// require the player to have administrator privileges before executing a command
-	if pe.player.Type != model.PlayerAdmin {
-		return
-	}
-
 	// determine if a valid and recognized chat command was sent
 	command := ParseChatCommand(text)
 	if command == nil {
+		unlockFunc()
 		return
 	}
 
-	g.handleChatCommand(pe, command)
+	// require the player's role to grant the command's permission before executing it
+	permission, ok := chatCommandPermissions[command.Type]
+	if !ok || !g.hasPermission(pe.player, permission) {
+		logger.Warnf("denied chat command from player %s (type %d) lacking permission %s: %s", pe.player.Username,
+			pe.player.Type, permission, text)
+		unlockFunc()
+		return
+	}
+
+	// commands that access persistent storage finish once the game state and player are unlocked, so that other
+	// players are not held up while the store is queried
+	finish := g.handleChatCommand(pe, command)
+	unlockFunc()
+
+	if finish != nil {
+		finish()
+	}
 }
 
 // DoPlayerChat broadcasts a player's chat message to nearby players.
@@ -314,6 +491,13 @@ func (g *Game) DoPlayerChat(p *model.Player, effect model.ChatEffect, color mode
 		return
 	}
 
+	// muted players cannot chat
+	if pe.player.IsMuted(time.Now()) {
+		g.handleSendMutedMessage(pe)
+		return
+	}
+
+	pe.RecordChat("", text, g.reportChatLines)
 	pe.lastChatMessage = &model.ChatMessage{
 		Color:  color,
 		Effect: effect,
@@ -357,6 +541,14 @@ func (g *Game) DoPlayerPrivateChat(p *model.Player, recipient string, text strin
 		target.mu.Unlock()
 	}()
 
+	// muted players cannot chat
+	if pe.player.IsMuted(time.Now()) {
+		g.handleSendMutedMessage(pe)
+		return
+	}
+
+	pe.RecordChat(target.player.Username, text, g.reportChatLines)
+
 	// if the target player has their private chat off or if it's in friends-only mode and this player is not on their
 	// friends list, then don't send the message. also don't send messages to a player if the sending player is on
 	// their ignored list.
@@ -432,6 +624,17 @@ func (g *Game) WalkPlayer(p *model.Player, start model.Vector2D, waypoints []mod
 		}
 	}
 
+	// free players cannot walk into members areas, so stop them at the edge of one
+	if !pe.player.IsMember(time.Now()) {
+		for i, w := range path {
+			if g.inMembersArea(w) {
+				path = path[:i]
+				pe.Send(response.NewServerMessageResponse("You need to be a member to go there."))
+				break
+			}
+		}
+	}
+
 	logger.Debugf("path player %s via %+v", p.Username, path)
 	pe.path = path
 	pe.nextPathIdx = 0
@@ -442,11 +645,6 @@ func (g *Game) ValidatePlayer(p *model.Player) ValidationResult {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
-	// check if the server is at capacity
-	if len(g.players) == maxPlayers {
-		return ValidationResultNoCapacity
-	}
-
 	// prevent the player from logging in again if they are already connected
 	for _, tpe := range g.players {
 		if tpe.player.ID == p.ID {
@@ -454,14 +652,21 @@ func (g *Game) ValidatePlayer(p *model.Player) ValidationResult {
 		}
 	}
 
+	// check if the server is at capacity
+	if len(g.players) == maxPlayers {
+		return ValidationResultNoCapacity
+	}
+
 	return ValidationResultSuccess
 }
 
 // AddPlayer joins a player to the world and handles ongoing game events and network interactions. The lowMemory flag
-// indicates if the player opted to play in low-memory mode on the client.
-func (g *Game) AddPlayer(p *model.Player, lowMemory bool, writer *network.ProtocolWriter) {
-	pe := newPlayerEntity(p, writer)
+// indicates if the player opted to play in low-memory mode on the client. If a recorder is provided, all responses
+// sent to the player will also be recorded.
+func (g *Game) AddPlayer(p *model.Player, lowMemory bool, writer *network.ProtocolWriter, recorder *recording.Recorder) {
+	pe := newPlayerEntity(p)
 	pe.isLowMemory = lowMemory
+	pe.Attach(writer, recorder)
 
 	// update the player's inventory and equipment to ensure items match their expected models. if an item does not
 	// match, remove it from its respective location
@@ -487,6 +692,12 @@ func (g *Game) AddPlayer(p *model.Player, lowMemory bool, writer *network.Protoc
 		}
 	}
 
+	// move free players out of members areas, in case their membership expired while they were there
+	if !pe.player.IsMember(time.Now()) && g.inMembersArea(pe.player.GlobalPos.To2D()) {
+		pe.player.GlobalPos = g.freeSpawn
+		pe.DeferSendServerMessage("Your membership has expired, so you have been moved out of the members area.")
+	}
+
 	// add the player to the player list, and assign them their index on the server player list
 	g.mu.Lock()
 	for i, used := range g.playerIndices {
@@ -578,6 +789,11 @@ func (g *Game) AddPlayer(p *model.Player, lowMemory bool, writer *network.Protoc
 	// plan a welcome message
 	pe.DeferSendServerMessage(g.welcomeMessage)
 
+	// remind members how long their subscription lasts
+	if pe.player.IsMember(time.Now()) && pe.player.MemberDays > 0 {
+		pe.DeferSendServerMessage(fmt.Sprintf("You have %d day(s) of membership remaining.", pe.player.MemberDays))
+	}
+
 	// start stat recovery if the player's stats are not at their base levels
 	for skillType, skill := range pe.player.Skills {
 		if skillType != model.SkillTypePrayer && skill.StatLevel < skill.BaseLevel {
@@ -598,6 +814,66 @@ func (g *Game) RemovePlayer(p *model.Player) {
 	g.handleRemovePlayer(pe)
 }
 
+// DetachPlayer handles a player whose client connection, using the given writer, was closed. The player remains in the
+// game world for a short time so that their client can reconnect, after which they are removed. If the player has
+// already been attached to a different connection, this method does nothing. A player who was detached because a
+// response could not be written to their client is treated as if they were still attached to the writer.
+// Concurrency requirements: (a) game state should NOT be locked and (b) this player should NOT be locked.
+func (g *Game) DetachPlayer(p *model.Player, writer *network.ProtocolWriter) {
+	pe, unlockFunc := g.findPlayerAndLockAll(p)
+	defer unlockFunc()
+
+	if pe == nil || (pe.conn.Load() != nil && !pe.Attached(writer)) {
+		return
+	}
+
+	pe.Attach(nil, nil)
+
+	if g.playerReconnectWindow <= 0 {
+		g.handleRemovePlayer(pe)
+		return
+	}
+
+	// keep the time the connection was first lost if a response already failed to be written
+	if pe.detachedAt.IsZero() {
+		pe.detachedAt = time.Now()
+	}
+}
+
+// ResumePlayer attaches a reconnecting client to a player that is still in the game world, and resynchronizes the
+// client's state. The connection the player was previously attached to, if any, is closed, and responses that were
+// still queued for it are discarded. The lowMemory flag indicates if the reconnecting client is running in low-memory
+// mode. The player as known to the game world is returned, or nil if the player is not in the game world and needs to
+// be added instead.
+// Concurrency requirements: (a) game state should NOT be locked and (b) this player should NOT be locked.
+func (g *Game) ResumePlayer(p *model.Player, lowMemory bool, writer *network.ProtocolWriter,
+	recorder *recording.Recorder) *model.Player {
+	pe, unlockFunc := g.findPlayerAndLockAll(p)
+	defer unlockFunc()
+
+	// the player might have already been scheduled for removal
+	if pe == nil || g.removePlayers[pe.player.ID] != nil {
+		return nil
+	}
+
+	// close the previous connection if the server never noticed it was lost
+	prev := pe.Attach(nil, nil)
+	if prev != nil {
+		_ = prev.writer.Close()
+	}
+
+	// responses queued for the previous connection are stale, since the client is sent its full state below
+	pe.DiscardQueued()
+	pe.Attach(writer, recorder)
+
+	pe.isLowMemory = lowMemory
+	pe.detachedAt = time.Time{}
+	pe.lastInteraction = time.Now()
+
+	g.handleResyncPlayer(pe)
+	return pe.player
+}
+
 // DoTakeGroundItem handles a player's request to pick up a ground item at a position, in global coordinates.
 func (g *Game) DoTakeGroundItem(p *model.Player, itemID int, globalPos model.Vector2D) {
 	// validate the item is known
@@ -700,13 +976,52 @@ func (g *Game) DoInteractWithNPC(p *model.Player, actionIndex, targetID int) {
 }
 
 // DoUseItem handles a player's request to use an item.
+// Concurrency requirements: (a) game state should NOT be locked and (b) this player should NOT be locked.
 func (g *Game) DoUseItem(p *model.Player, itemID, interfaceID, actionID int) {
+	// validate the item is known
+	targetItem := g.items[itemID]
+	if targetItem == nil {
+		return
+	}
+
+	pe, unlockFunc := g.findPlayerAndLockAll(p)
+	defer unlockFunc()
+
+	if pe == nil {
+		return
+	}
+
+	// free players cannot use members items
+	if !g.canUseItem(pe, targetItem) {
+		return
+	}
+
 	// TODO
 }
 
 // DoUseInventoryItem handles a player's request to use an inventory item on another item.
+// Concurrency requirements: (a) game state should NOT be locked and (b) this player should NOT be locked.
 func (g *Game) DoUseInventoryItem(p *model.Player, sourceItemID, sourceInterfaceID, sourceSlotID,
 	targetItemID, targetInterfaceID, targetSlotID int) {
+	// validate both items are known
+	sourceItem := g.items[sourceItemID]
+	targetItem := g.items[targetItemID]
+	if sourceItem == nil || targetItem == nil {
+		return
+	}
+
+	pe, unlockFunc := g.findPlayerAndLockAll(p)
+	defer unlockFunc()
+
+	if pe == nil {
+		return
+	}
+
+	// free players cannot use members items, or use other items on them
+	if !g.canUseItem(pe, sourceItem) || !g.canUseItem(pe, targetItem) {
+		return
+	}
+
 	// TODO
 }
 
@@ -751,11 +1066,9 @@ func (g *Game) broadcastPlayerStatus(pe *playerEntity, targets ...string) {
 				update = response.NewOfflineFriendStatusResponse(pe.player.Username)
 			}
 
-			err := update.Write(other.writer)
-			if err != nil {
-				// other player could have disconnected, so we don't treat this as a fatal error
-				logger.Debugf("failed to send friend status update for %s to %s: %s", pe.player.Username, other.player.Username, err)
-			}
+			// queue the update instead of writing it directly, since packet headers must be encoded in the same
+			// order they are sent to the other player's client
+			other.Send(update)
 		}
 	}
 }
@@ -824,7 +1137,7 @@ func (g *Game) loop() {
 		case <-g.ticker.C:
 			start := time.Now()
 
-			err := g.handleGameUpdate()
+			err := g.Step()
 			if err != nil {
 				logger.Errorf("ending game state update due to error: %s", err)
 				return
@@ -844,7 +1157,7 @@ func (g *Game) playerLoop(pe *playerEntity) {
 		select {
 		case <-pe.doneChan:
 			// send a graceful disconnect to the client if possible
-			err := pe.writer.WriteUint8(response.DisconnectResponseHeader)
+			err := pe.Write(&response.DisconnectResponse{})
 			if err != nil {
 				logger.Debugf("failed to write disconnect response to player %d: %s", pe.player.ID, err)
 			}
@@ -852,11 +1165,20 @@ func (g *Game) playerLoop(pe *playerEntity) {
 			return
 
 		case update := <-pe.outChan:
-			// send a response to the player
-			err := update.Write(pe.writer)
+			// send a response to the player. if the connection is broken, keep the player in the game world but
+			// discard further responses until either the client reconnects, or the player is removed.
+			conn := pe.conn.Load()
+			err := pe.writeTo(conn, update)
 			if err != nil {
-				logger.Errorf("ending player loop due to error on update: %s", err)
-				return
+				logger.Debugf("detaching player %d due to error on update: %s", pe.player.ID, err)
+
+				// start the reconnect window now, since the connection might never be reported as closed. if the
+				// window is disabled, the player is removed on the next game state update instead.
+				pe.mu.Lock()
+				if pe.conn.CompareAndSwap(conn, nil) && pe.detachedAt.IsZero() {
+					pe.detachedAt = time.Now()
+				}
+				pe.mu.Unlock()
 			}
 		}
 	}
@@ -1025,6 +1347,52 @@ func (g *Game) handleSetInterfaceSetting(pe *playerEntity, settingID, value int)
 	pe.Send(r)
 }
 
+// handleResyncPlayer sends a player's client the complete state of the player and their surroundings. This is used when
+// a client reconnects, since it may have missed any number of updates in the meantime.
+// Concurrency requirements: (a) game state should be locked and (b) this player should be locked.
+func (g *Game) handleResyncPlayer(pe *playerEntity) {
+	pe.Send(&response.InitPlayerResponse{
+		Member:      pe.player.Member,
+		ServerIndex: pe.index,
+	})
+
+	// forget about other entities the client was tracking, so that they are sent again on the next update
+	pe.tracking = map[int]*playerEntity{}
+	pe.npcTracking = map[int]*npcEntity{}
+
+	// reload the player's map region and position
+	regionOrigin, regionRelative := g.playerRegionPosition(pe)
+	pe.regionOrigin = regionOrigin
+	pe.Send(response.NewLoadRegionResponse(regionOrigin))
+
+	update := response.NewPlayerUpdateResponse(pe.index)
+	update.SetLocalPlayerPosition(regionRelative, true)
+	update.AddAppearanceUpdate(pe.index, pe.player.Username, pe.player.Appearance)
+	pe.Send(update)
+
+	// describe the local region
+	rg := util.RegionOriginToGlobal(regionOrigin)
+	mapUpdates := g.mapManager.State(rg, model.BoundaryNone)
+	if len(mapUpdates) > 0 {
+		pe.Send(mapUpdates...)
+	}
+
+	// restore the player's sidebar interfaces
+	for tab, interfaceID := range pe.tabInterfaces {
+		pe.Send(response.NewSidebarInterfaceResponse(tab, interfaceID))
+	}
+
+	// plan updates for the rest of the player's state
+	pe.DeferSendEquipment()
+	pe.DeferSendInventory()
+	pe.DeferSendModes()
+	pe.DeferSendSkills(nil)
+	pe.DeferSendFriendList()
+	pe.DeferSendIgnoreList()
+	pe.DeferSendRunEnergy()
+	pe.DeferSendWeight()
+}
+
 // handleRemovePlayer adds a player to the list of players that will be removed from the game.
 // Concurrency requirements: (a) game state should be locked and (b) this player may be locked.
 func (g *Game) handleRemovePlayer(pe *playerEntity) {
@@ -1146,9 +1514,11 @@ func (g *Game) handleSendServerMessage(pe *playerEntity, message string) {
 	pe.Send(response.NewServerMessageResponse(message))
 }
 
-// handleChatCommand processes a chat command sent by a player.
-// Concurrency requirements: (a) game state should be locked and (b) this player should NOT be locked.
-func (g *Game) handleChatCommand(pe *playerEntity, command *ChatCommand) {
+// handleChatCommand processes a chat command sent by a player. Commands that access persistent storage return a
+// function that completes them, which should be called once the game state and player are unlocked. Other commands
+// return nil.
+// Concurrency requirements: (a) game state should be locked and (b) this player should be locked.
+func (g *Game) handleChatCommand(pe *playerEntity, command *ChatCommand) func() {
 	switch command.Type {
 	case ChatCommandTypeSpawnItem:
 		params := command.SpawnItem
@@ -1203,7 +1573,379 @@ func (g *Game) handleChatCommand(pe *playerEntity, command *ChatCommand) {
 	case ChatCommandAnimate:
 		// the player requested an animation
 		pe.SetAnimation(command.Animate.ID, -1)
+
+	case ChatCommandBan:
+		// ban a player and disconnect any affected players
+		return g.handleIssueBan(pe, command.Ban)
+
+	case ChatCommandUnban:
+		// lift bans against a player
+		return g.handleLiftBan(pe, command.Ban)
+
+	case ChatCommandMute, ChatCommandUnmute:
+		// mute or unmute a player
+		return g.handleMuteCommand(pe, command.Mute, command.Type == ChatCommandMute)
+
+	case ChatCommandListReports:
+		// list open abuse reports
+		return g.handleListReports(pe)
+
+	case ChatCommandResolveReport:
+		// resolve an abuse report
+		return g.handleResolveReport(pe, command.ResolveReport.ReportID)
+
+	case ChatCommandKick:
+		// disconnect an online player
+		g.handleKickPlayer(pe, command.Kick.Username)
+
+	case ChatCommandListSnapshots:
+		// list the saved snapshots of a player
+		return g.handleListSnapshots(pe, command.Rollback.Username)
+
+	case ChatCommandRollback:
+		// roll a player back to one of their snapshots the next time they log in
+		return g.handleRollback(pe, command.Rollback)
+	}
+
+	return nil
+}
+
+// inMembersArea determines if a position, in global coordinates, lies within an area that only members may enter.
+func (g *Game) inMembersArea(globalPos model.Vector2D) bool {
+	for _, area := range g.membersAreas {
+		if area.Contains(globalPos) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canUseItem determines if a player is able to use an item, informing them if they are not. Members items can only be
+// used by players with an active membership.
+// Concurrency requirements: (a) game state may be locked and (b) this player should be locked.
+func (g *Game) canUseItem(pe *playerEntity, item *model.Item) bool {
+	if !item.MembersOnly || pe.player.IsMember(time.Now()) {
+		return true
+	}
+
+	pe.Send(response.NewServerMessageResponse("You need to be a member to use this item."))
+	return false
+}
+
+// hasPermission determines if a player's role grants them a permission.
+func (g *Game) hasPermission(p *model.Player, permission model.Permission) bool {
+	return g.permissions.Allowed(p.Type, permission)
+}
+
+// handleIssueBan resolves the target of a ban requested by a moderator, and returns a function that issues the ban
+// and disconnects online players that are affected by it. If the ban cannot be issued, nil is returned.
+// Concurrency requirements: (a) game state should be locked and (b) this player may be locked.
+func (g *Game) handleIssueBan(pe *playerEntity, params *ChatCommandBanParams) func() {
+	if g.bans == nil {
+		pe.Send(response.NewServerMessageResponse("Bans are not available"))
+		return nil
+	}
+
+	// address and client bans are resolved from the named player's current connection
+	value := params.Value
+	if params.Target != model.BanTargetAccount {
+		target := g.findPlayerByUsername(params.Value)
+		if target == nil {
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("%s is not online", params.Value)))
+			return nil
+		}
+
+		value = target.player.Address
+		if params.Target == model.BanTargetUID {
+			value = strconv.FormatUint(uint64(target.player.UID), 10)
+		}
+	}
+
+	moderator := pe.player.Username
+
+	return func() {
+		ban, err := g.bans.Issue(params.Target, value, params.Reason, moderator, params.Duration)
+		if err != nil {
+			logger.Errorf("failed to ban %s: %s", params.Value, err)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Failed to ban %s", params.Value)))
+			return
+		}
+
+		logger.Infof("%s banned %s (target %d, value %s): %s", moderator, params.Value, ban.Target, ban.Value,
+			ban.Reason)
+		pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Banned %s", params.Value)))
+
+		g.handleKickBannedPlayers(ban)
+	}
+}
+
+// handleKickBannedPlayers disconnects all online players that are affected by a ban.
+// Concurrency requirements: (a) game state should NOT be locked and (b) all players should NOT be locked.
+func (g *Game) handleKickBannedPlayers(ban *model.Ban) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, tpe := range g.players {
+		if ban.Applies(tpe.player) {
+			g.handleRemovePlayer(tpe)
+		}
+	}
+}
+
+// handleKickPlayer disconnects an online player as requested by a moderator.
+// Concurrency requirements: (a) game state should be locked and (b) this player may be locked.
+func (g *Game) handleKickPlayer(pe *playerEntity, username string) {
+	target := g.findPlayerByUsername(username)
+	if target == nil {
+		pe.Send(response.NewServerMessageResponse(fmt.Sprintf("%s is not online", username)))
+		return
 	}
+
+	logger.Infof("%s kicked %s", pe.player.Username, target.player.Username)
+	pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Kicked %s", target.player.Username)))
+
+	g.handleRemovePlayer(target)
+}
+
+// handleLiftBan returns a function that lifts bans requested by a moderator, or nil if bans are not available.
+// Concurrency requirements: (a) game state may be locked and (b) this player may be locked.
+func (g *Game) handleLiftBan(pe *playerEntity, params *ChatCommandBanParams) func() {
+	if g.bans == nil {
+		pe.Send(response.NewServerMessageResponse("Bans are not available"))
+		return nil
+	}
+
+	moderator := pe.player.Username
+
+	return func() {
+		n, err := g.bans.Lift(params.Target, params.Value)
+		if err != nil {
+			logger.Errorf("failed to lift bans for %s: %s", params.Value, err)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Failed to unban %s", params.Value)))
+			return
+		}
+
+		if n == 0 {
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("%s is not banned", params.Value)))
+			return
+		}
+
+		logger.Infof("%s lifted %d ban(s) for %s", moderator, n, params.Value)
+		pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Unbanned %s", params.Value)))
+	}
+}
+
+// handleMuteCommand returns a function that mutes or unmutes a player as requested by a moderator, or nil if mutes
+// are not available.
+// Concurrency requirements: (a) game state may be locked and (b) this player may be locked.
+func (g *Game) handleMuteCommand(pe *playerEntity, params *ChatCommandMuteParams, mute bool) func() {
+	if g.mutes == nil {
+		pe.Send(response.NewServerMessageResponse("Mutes are not available"))
+		return nil
+	}
+
+	moderator := pe.player.Username
+
+	return func() {
+		found, err := g.handleMutePlayer(params.Username, mute, params.Duration)
+		if err != nil {
+			logger.Errorf("failed to update mute for %s: %s", params.Username, err)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Failed to update mute for %s", params.Username)))
+			return
+		} else if !found {
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("No player named %s exists", params.Username)))
+			return
+		}
+
+		if mute {
+			logger.Infof("%s muted %s for %s", moderator, params.Username, params.Duration)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Muted %s", params.Username)))
+		} else {
+			logger.Infof("%s unmuted %s", moderator, params.Username)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Unmuted %s", params.Username)))
+		}
+	}
+}
+
+// handleMutePlayer mutes a player for a duration, or permanently if the duration is zero, or unmutes them. The change
+// is persisted, and applied right away if the player is online. If no player has the username, false is returned.
+// Concurrency requirements: (a) game state should NOT be locked and (b) all players should NOT be locked.
+func (g *Game) handleMutePlayer(username string, mute bool, duration time.Duration) (bool, error) {
+	var expiresAt *time.Time
+	var found bool
+	var err error
+
+	if mute {
+		expiresAt, found, err = g.mutes.Mute(username, duration)
+	} else {
+		found, err = g.mutes.Unmute(username)
+	}
+
+	if err != nil || !found {
+		return found, err
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	target := g.findPlayerByUsername(username)
+	if target == nil {
+		return true, nil
+	}
+
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	target.player.Muted = mute
+	target.player.MuteExpiry = expiresAt
+
+	if mute {
+		g.handleSendMutedMessage(target)
+	} else {
+		target.Send(response.NewServerMessageResponse("You have been unmuted."))
+	}
+
+	return true, nil
+}
+
+// handleSendMutedMessage informs a player that they are muted.
+// Concurrency requirements: (a) game state may be locked and (b) this player should be locked.
+func (g *Game) handleSendMutedMessage(pe *playerEntity) {
+	msg := "You have been muted and cannot chat."
+	if pe.player.MuteExpiry != nil {
+		msg = fmt.Sprintf("You have been muted and cannot chat until %s.",
+			pe.player.MuteExpiry.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+
+	pe.Send(response.NewServerMessageResponse(msg))
+}
+
+// handleListReports returns a function that sends a moderator a summary of open abuse reports, or nil if abuse
+// reports are not available.
+// Concurrency requirements: (a) game state may be locked and (b) this player may be locked.
+func (g *Game) handleListReports(pe *playerEntity) func() {
+	if g.reports == nil {
+		pe.Send(response.NewServerMessageResponse("Abuse reports are not available"))
+		return nil
+	}
+
+	return func() {
+		reports, err := g.reports.Open()
+		if err != nil {
+			logger.Errorf("failed to load abuse reports: %s", err)
+			pe.Send(response.NewServerMessageResponse("Failed to load abuse reports"))
+			return
+		}
+
+		pe.Send(response.NewServerMessageResponse(fmt.Sprintf("%d open abuse report(s)", len(reports))))
+
+		// only list the oldest reports to avoid flooding the player's chat box
+		for i, report := range reports {
+			if i == maxListedReports {
+				break
+			}
+
+			msg := fmt.Sprintf("#%d: %s reported %s (rule %d, %d message(s))", report.ID, report.Reporter,
+				report.Offender, report.Reason, len(report.Chat))
+			pe.Send(response.NewServerMessageResponse(msg))
+		}
+	}
+}
+
+// handleResolveReport returns a function that marks an abuse report as resolved by a moderator, or nil if abuse
+// reports are not available.
+// Concurrency requirements: (a) game state may be locked and (b) this player may be locked.
+func (g *Game) handleResolveReport(pe *playerEntity, reportID int) func() {
+	if g.reports == nil {
+		pe.Send(response.NewServerMessageResponse("Abuse reports are not available"))
+		return nil
+	}
+
+	moderator := pe.player.Username
+
+	return func() {
+		found, err := g.reports.Resolve(reportID, moderator)
+		if err != nil {
+			logger.Errorf("failed to resolve abuse report %d: %s", reportID, err)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Failed to resolve report #%d", reportID)))
+			return
+		} else if !found {
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("No open report #%d", reportID)))
+			return
+		}
+
+		pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Resolved report #%d", reportID)))
+	}
+}
+
+// handleListSnapshots returns a function that sends an administrator a summary of the most recent snapshots of a
+// player, or nil if snapshots are not available.
+// Concurrency requirements: (a) game state may be locked and (b) this player may be locked.
+func (g *Game) handleListSnapshots(pe *playerEntity, username string) func() {
+	if g.snapshots == nil {
+		pe.Send(response.NewServerMessageResponse("Snapshots are not available"))
+		return nil
+	}
+
+	return func() {
+		snapshots, err := g.snapshots.List(username)
+		if err != nil {
+			logger.Errorf("failed to load snapshots of %s: %s", username, err)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Failed to load snapshots of %s", username)))
+			return
+		}
+
+		pe.Send(response.NewServerMessageResponse(fmt.Sprintf("%d snapshot(s) of %s", len(snapshots), username)))
+
+		// only list the newest snapshots to avoid flooding the player's chat box
+		for i, snapshot := range snapshots {
+			if i == maxListedSnapshots {
+				break
+			}
+
+			msg := fmt.Sprintf("#%d: %s", snapshot.ID, snapshot.CreatedAt.UTC().Format("2006-01-02 15:04:05 UTC"))
+			pe.Send(response.NewServerMessageResponse(msg))
+		}
+	}
+}
+
+// handleRollback returns a function that schedules a player to be rolled back to one of their snapshots the next time
+// they log in, as requested by an administrator, or nil if snapshots are not available.
+// Concurrency requirements: (a) game state may be locked and (b) this player may be locked.
+func (g *Game) handleRollback(pe *playerEntity, params *ChatCommandRollbackParams) func() {
+	if g.snapshots == nil {
+		pe.Send(response.NewServerMessageResponse("Snapshots are not available"))
+		return nil
+	}
+
+	administrator := pe.player.Username
+
+	return func() {
+		snapshot, err := g.snapshots.Schedule(params.Username, params.SnapshotID)
+		if err != nil {
+			logger.Errorf("failed to schedule rollback of %s to snapshot %d: %s", params.Username,
+				params.SnapshotID, err)
+			pe.Send(response.NewServerMessageResponse(fmt.Sprintf("Failed to roll back %s", params.Username)))
+			return
+		}
+
+		logger.Infof("%s scheduled rollback of %s to snapshot %d", administrator, params.Username, snapshot.ID)
+		msg := fmt.Sprintf("%s will be rolled back to snapshot #%d on their next login", params.Username, snapshot.ID)
+		pe.Send(response.NewServerMessageResponse(msg))
+	}
+}
+
+// findPlayerByUsername returns the online player with a username, ignoring case.
+// Concurrency requirements: (a) game state should be locked and (b) the player should NOT be locked.
+func (g *Game) findPlayerByUsername(username string) *playerEntity {
+	for _, pe := range g.players {
+		if strings.EqualFold(pe.player.Username, username) {
+			return pe
+		}
+	}
+
+	return nil
 }
 
 // addToList adds another player to the player's friends or ignore list.
@@ -1520,9 +2262,12 @@ func (g *Game) handleGameUpdate() error {
 	for _, pe := range g.players {
 		pe.mu.Lock()
 
-		// add this player to the removal list if they have idled for too long
+		// add this player to the removal list if they have idled for too long, or if their client did not reconnect
+		// in time after losing its connection
 		if time.Now().Sub(pe.lastInteraction) >= g.playerMaxIdleInterval {
 			g.removePlayers[pe.player.ID] = pe
+		} else if !pe.detachedAt.IsZero() && time.Now().Sub(pe.detachedAt) >= g.playerReconnectWindow {
+			g.removePlayers[pe.player.ID] = pe
 		}
 	}
 
@@ -1972,6 +2717,12 @@ func (g *Game) handleDeferredActions(pe *playerEntity) ActionResult {
 				return result
 			}
 
+			// free players cannot pick up members items
+			if !g.canUseItem(pe, action.Item) {
+				pe.RemoveDeferredAction(deferred)
+				break
+			}
+
 			// check if the player has room in their inventory
 			if !pe.player.InventoryCanHoldItem(action.Item) {
 				pe.Send(response.NewServerMessageResponse("You cannot carry any more items"))
@@ -2007,7 +2758,11 @@ func (g *Game) handleDeferredActions(pe *playerEntity) ActionResult {
 		case ActionEquipItem:
 			action := deferred.EquipItemAction
 
-			g.equipPlayerInventoryItem(pe, action.Item)
+			// free players cannot equip members items
+			if g.canUseItem(pe, action.Item) {
+				g.equipPlayerInventoryItem(pe, action.Item)
+			}
+
 			pe.RemoveDeferredAction(deferred)
 
 		case ActionUnequipItem:
@@ -2094,6 +2849,12 @@ func (g *Game) handleDeferredActions(pe *playerEntity) ActionResult {
 				break
 			}
 
+			// free players cannot cast spells on members items
+			if !g.canUseItem(pe, slot.Item) {
+				pe.RemoveDeferredAction(deferred)
+				break
+			}
+
 			// execute a script to handle this spell
 			done, err := g.scripts.DoCastSpellOnItem(pe, slot.Item, action.SlotID, inventoryInterface, spellBookInterface, spellInterface)
 			if err != nil {
@@ -2249,6 +3010,13 @@ func (g *Game) handleServerMessage(pe *playerEntity, message string) {
 // handleTeleportPlayer teleports a player to another location.
 // Concurrency requirements: (a) game state may be locked and (b) this player should be locked.
 func (g *Game) handleTeleportPlayer(pe *playerEntity, globalPos model.Vector3D) {
+	// free players cannot be teleported into members areas, unless they are allowed to teleport anywhere
+	if !pe.player.IsMember(time.Now()) && g.inMembersArea(globalPos.To2D()) &&
+		!g.hasPermission(pe.player, model.PermissionTeleport) {
+		pe.Send(response.NewServerMessageResponse("You need to be a member to go there."))
+		return
+	}
+
 	// defer the teleport action for its tick delay
 	pe.DeferTeleportPlayer(globalPos)
 }
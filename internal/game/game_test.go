@@ -0,0 +1,333 @@
+package game
+
+import (
+	"errors"
+	"github.com/mbpolan/openmcs/internal/auth"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/moderation"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/response"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/store/driver"
+	"github.com/mbpolan/openmcs/internal/store/playerdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// brokenConn is a connection that fails every write.
+type brokenConn struct{}
+
+func (c *brokenConn) Write(_ []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func Test_Game_DetachPlayer_afterWriteError(t *testing.T) {
+	tests := map[string]struct {
+		reconnectWindow time.Duration
+		removed         bool
+	}{
+		"reconnect window": {reconnectWindow: time.Minute, removed: false},
+		"no reconnect":     {reconnectWindow: 0, removed: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			g, pe := newTestGame(test.reconnectWindow)
+			writer := network.NewWriter(&brokenConn{})
+			pe.Attach(writer, nil)
+
+			go g.playerLoop(pe)
+			defer pe.Drop()
+
+			// the player is detached as soon as a response fails to be written
+			pe.Send(&response.ClearScreenResponse{})
+			require.Eventually(t, func() bool {
+				pe.mu.Lock()
+				defer pe.mu.Unlock()
+				return pe.conn.Load() == nil && !pe.detachedAt.IsZero()
+			}, time.Second, 10*time.Millisecond)
+
+			// the socket closing afterward still detaches the player
+			g.DetachPlayer(pe.player, writer)
+			assert.Equal(t, test.removed, g.removePlayers[pe.player.ID] != nil)
+		})
+	}
+}
+
+func Test_Game_DetachPlayer_otherConnection(t *testing.T) {
+	g, pe := newTestGame(time.Minute)
+	pe.Attach(network.NewBufferedWriter(), nil)
+
+	// a stale connection closing does not detach the player from their current one
+	g.DetachPlayer(pe.player, network.NewBufferedWriter())
+	assert.NotNil(t, pe.conn.Load())
+	assert.True(t, pe.detachedAt.IsZero())
+}
+
+// lockCheckingDriver is a store driver that counts how many times it was accessed while the game state was locked.
+type lockCheckingDriver struct {
+	driver.Driver
+	g      *Game
+	locked int
+}
+
+func (d *lockCheckingDriver) SaveBan(ban *model.Ban) error {
+	d.check()
+	return d.Driver.SaveBan(ban)
+}
+
+func (d *lockCheckingDriver) DeleteBans(target model.BanTarget, value string) (int, error) {
+	d.check()
+	return d.Driver.DeleteBans(target, value)
+}
+
+func (d *lockCheckingDriver) SaveMute(username string, muted bool, expiresAt *time.Time) (bool, error) {
+	d.check()
+	return d.Driver.SaveMute(username, muted, expiresAt)
+}
+
+func (d *lockCheckingDriver) SaveAbuseReport(report *model.AbuseReport) error {
+	d.check()
+	return d.Driver.SaveAbuseReport(report)
+}
+
+func (d *lockCheckingDriver) LoadOpenAbuseReports() ([]*model.AbuseReport, error) {
+	d.check()
+	return d.Driver.LoadOpenAbuseReports()
+}
+
+func (d *lockCheckingDriver) ResolveAbuseReport(id int, resolvedBy string, resolvedAt time.Time) (bool, error) {
+	d.check()
+	return d.Driver.ResolveAbuseReport(id, resolvedBy, resolvedAt)
+}
+
+func (d *lockCheckingDriver) LoadPlayerSnapshots(playerID int) ([]*model.PlayerSnapshot, error) {
+	d.check()
+	return d.Driver.LoadPlayerSnapshots(playerID)
+}
+
+func (d *lockCheckingDriver) SavePendingRollback(playerID, snapshotID int) error {
+	d.check()
+	return d.Driver.SavePendingRollback(playerID, snapshotID)
+}
+
+// check records if the game state is locked.
+func (d *lockCheckingDriver) check() {
+	if !d.g.mu.TryLock() {
+		d.locked++
+		return
+	}
+
+	d.g.mu.Unlock()
+}
+
+// newTestModerationGame returns a game containing an administrator and another player, with moderation and snapshots
+// backed by a store that checks it is not accessed while the game state is locked.
+func newTestModerationGame(t *testing.T) (*Game, *playerEntity, *playerEntity, *lockCheckingDriver) {
+	g, pe := newTestGame(time.Minute)
+	pe.player.Type = model.PlayerAdmin
+
+	target := newPlayerEntity(model.NewPlayer("Bob"))
+	target.player.ID = 2
+	g.players = append(g.players, target)
+
+	permissions, err := auth.NewRolePermissions(config.PermissionsConfig{
+		Roles: map[string][]string{"admin": {string(model.PermissionAll)}},
+	})
+	require.NoError(t, err)
+	g.permissions = permissions
+
+	memory, err := driver.NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	// the other player also has an account so that they can be muted
+	require.NoError(t, memory.CreatePlayer(model.NewPlayer("Bob")))
+
+	d := &lockCheckingDriver{Driver: memory, g: g}
+	s := store.NewWithDriver(&config.Config{}, d)
+	g.bans = moderation.NewBanList(s)
+	g.mutes = moderation.NewMuteList(s)
+	g.reports = moderation.NewReportQueue(s)
+	g.snapshots = playerdata.NewHistory(d, 5)
+
+	return g, pe, target, d
+}
+
+// newTestGame returns a game containing a single player.
+func newTestGame(reconnectWindow time.Duration) (*Game, *playerEntity) {
+	p := model.NewPlayer("Mike")
+	p.ID = 1
+	pe := newPlayerEntity(p)
+
+	g := &Game{
+		playerMaxIdleInterval: time.Hour,
+		playerReconnectWindow: reconnectWindow,
+		players:               []*playerEntity{pe},
+		removePlayers:         map[int]*playerEntity{},
+	}
+
+	return g, pe
+}
+
+func Test_Game_DoUseInventoryItem_membersItem(t *testing.T) {
+	g, pe := newTestGame(time.Minute)
+	g.items = map[int]*model.Item{
+		1333: {ID: 1333},
+		1351: {ID: 1351, MembersOnly: true},
+	}
+
+	// free players cannot use an item on a members item, or the other way around
+	g.DoUseInventoryItem(pe.player, 1333, 3214, 0, 1351, 3214, 1)
+	g.DoUseInventoryItem(pe.player, 1351, 3214, 1, 1333, 3214, 0)
+	assert.Len(t, pe.outChan, 2)
+
+	g.DoUseInventoryItem(pe.player, 1333, 3214, 0, 1333, 3214, 1)
+	assert.Len(t, pe.outChan, 2)
+
+	// members can use both items
+	pe.player.Member = true
+	g.DoUseInventoryItem(pe.player, 1333, 3214, 0, 1351, 3214, 1)
+	g.DoUseItem(pe.player, 1351, 3214, 1)
+	assert.Len(t, pe.outChan, 2)
+}
+
+func Test_Game_SnapshotPlayer(t *testing.T) {
+	g, pe := newTestGame(time.Minute)
+
+	// the player keeps changing during game state updates while their snapshot is taken
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			g.mu.Lock()
+			pe.mu.Lock()
+			pe.player.RunEnergy = i
+			pe.player.SetInventoryItem(&model.Item{ID: 995}, i+1, 0)
+			pe.mu.Unlock()
+			g.mu.Unlock()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		snapshot := g.SnapshotPlayer(pe.player)
+		require.NotNil(t, snapshot)
+		assert.NotSame(t, pe.player, snapshot)
+	}
+
+	<-done
+
+	assert.Nil(t, g.SnapshotPlayer(model.NewPlayer("Hurz")))
+}
+
+func Test_Game_ResumePlayer(t *testing.T) {
+	g, pe := newTestGame(time.Minute)
+	g.mapManager = NewMapManager(model.NewMap())
+
+	prev := network.NewBufferedWriter()
+	pe.Attach(prev, nil)
+	pe.detachedAt = time.Now()
+
+	// responses were queued for the previous connection before it was lost
+	flush := &flushResponse{done: make(chan bool)}
+	pe.Send(&response.ClearScreenResponse{}, flush)
+
+	writer := network.NewBufferedWriter()
+	resumed := g.ResumePlayer(pe.player, true, writer, nil)
+	require.Same(t, pe.player, resumed)
+
+	assert.True(t, pe.Attached(writer))
+	assert.True(t, pe.isLowMemory)
+	assert.True(t, pe.detachedAt.IsZero())
+
+	// stale responses are discarded, and the client is sent its state from scratch
+	<-flush.done
+	require.NotEmpty(t, pe.outChan)
+	assert.IsType(t, &response.InitPlayerResponse{}, <-pe.outChan)
+}
+
+func Test_Game_DoPlayerChatCommand_ban(t *testing.T) {
+	g, pe, target, d := newTestModerationGame(t)
+
+	g.DoPlayerChatCommand(pe.player, "ban bob")
+	assert.Equal(t, response.NewServerMessageResponse("Banned bob"), <-pe.outChan)
+	assert.Same(t, target, g.removePlayers[target.player.ID])
+
+	g.DoPlayerChatCommand(pe.player, "unban bob")
+	assert.Equal(t, response.NewServerMessageResponse("Unbanned bob"), <-pe.outChan)
+
+	// bans are persisted without holding up the game state
+	assert.Zero(t, d.locked)
+}
+
+func Test_Game_ProcessAbuseReport(t *testing.T) {
+	g, pe, target, d := newTestModerationGame(t)
+	target.recentChat = []*model.ChatLogEntry{{Text: "hello"}}
+
+	g.ProcessAbuseReport(pe.player, "bob", 1, true)
+	assert.Equal(t, response.NewServerMessageResponse("Thank-you, your abuse report has been received."), <-pe.outChan)
+	assert.True(t, target.player.Muted)
+
+	reports, err := d.LoadOpenAbuseReports()
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "Bob", reports[0].Offender)
+	assert.True(t, reports[0].Muted)
+	assert.Len(t, reports[0].Chat, 1)
+
+	// the report and mute are persisted without holding up the game state
+	assert.Zero(t, d.locked)
+}
+
+func Test_Game_DoPlayerChatCommand_muteAndReports(t *testing.T) {
+	g, pe, target, d := newTestModerationGame(t)
+
+	g.DoPlayerChatCommand(pe.player, "mute bob 1h")
+	assert.Equal(t, response.NewServerMessageResponse("Muted bob"), <-pe.outChan)
+	assert.True(t, target.player.Muted)
+	assert.NotNil(t, target.player.MuteExpiry)
+
+	g.DoPlayerChatCommand(pe.player, "unmute bob")
+	assert.Equal(t, response.NewServerMessageResponse("Unmuted bob"), <-pe.outChan)
+	assert.False(t, target.player.Muted)
+
+	g.ProcessAbuseReport(pe.player, "bob", 1, false)
+	<-pe.outChan
+
+	g.DoPlayerChatCommand(pe.player, "reports")
+	assert.Equal(t, response.NewServerMessageResponse("1 open abuse report(s)"), <-pe.outChan)
+	<-pe.outChan
+
+	g.DoPlayerChatCommand(pe.player, "resolve 1")
+	assert.Equal(t, response.NewServerMessageResponse("Resolved report #1"), <-pe.outChan)
+
+	// mutes and reports are persisted without holding up the game state
+	assert.Zero(t, d.locked)
+}
+
+func Test_Game_DoPlayerChatCommand_rollback(t *testing.T) {
+	g, pe, _, d := newTestModerationGame(t)
+
+	bob, err := d.LoadPlayer("Bob")
+	require.NoError(t, err)
+	require.NoError(t, g.snapshots.Record(bob))
+
+	g.DoPlayerChatCommand(pe.player, "snapshots bob")
+	assert.Equal(t, response.NewServerMessageResponse("1 snapshot(s) of bob"), <-pe.outChan)
+	<-pe.outChan
+
+	// online players can be rolled back too, since the rollback is only applied when they next log in
+	g.DoPlayerChatCommand(pe.player, "rollback bob 1")
+	expected := response.NewServerMessageResponse("bob will be rolled back to snapshot #1 on their next login")
+	assert.Equal(t, expected, <-pe.outChan)
+
+	pending, err := d.LoadPendingRollback(bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	// snapshots are loaded and rollbacks are scheduled without holding up the game state
+	assert.Zero(t, d.locked)
+}
@@ -2,9 +2,11 @@ package game
 
 import (
 	"github.com/google/uuid"
+	"github.com/mbpolan/openmcs/internal/logger"
 	"github.com/mbpolan/openmcs/internal/model"
 	"github.com/mbpolan/openmcs/internal/network/response"
 	"github.com/mbpolan/openmcs/internal/util"
+	"math"
 	"sync"
 	"time"
 )
@@ -42,6 +44,8 @@ type MapManager struct {
 	regions map[model.Vector3D]*RegionManager
 	// pendingRegions is a map of region origins, in global coordinates, to flags if they need to be reconciled.
 	pendingRegions map[model.Vector3D]bool
+	// groundItemTiles is a set of positions, in global coordinates, of tiles that ground items have been placed on.
+	groundItemTiles map[model.Vector3D]bool
 	// scheduler is used to track events on the map.
 	scheduler *Scheduler
 	// worldMap is a pointer to the model.Map that this MapManager is responsible for managing.
@@ -64,12 +68,13 @@ func NewMapManager(m *model.Map) *MapManager {
 	changeChan := make(chan bool, 1)
 
 	mgr := &MapManager{
-		doneChan:       make(chan bool, 1),
-		changeChan:     changeChan,
-		pendingRegions: map[model.Vector3D]bool{},
-		regions:        regions,
-		scheduler:      NewScheduler(changeChan),
-		worldMap:       m,
+		doneChan:        make(chan bool, 1),
+		changeChan:      changeChan,
+		pendingRegions:  map[model.Vector3D]bool{},
+		groundItemTiles: map[model.Vector3D]bool{},
+		regions:         regions,
+		scheduler:       NewScheduler(changeChan),
+		worldMap:        m,
 	}
 
 	return mgr
@@ -174,23 +179,11 @@ func (m *MapManager) FindSpectators(pe *playerEntity) (map[int]*playerEntity, ma
 // automatically be removed. Stackable items will be added to an existing stackable with the same Item ID, if one
 // exists, or they will be placed as new items on the tile.
 func (m *MapManager) AddGroundItem(itemID, amount int, stackable bool, timeoutSeconds *int, globalPos model.Vector3D) {
-	tile := m.worldMap.Tile(globalPos)
-	if tile == nil {
+	newlyAdded, oldAmount, ok := m.placeGroundItem(itemID, amount, stackable, timeoutSeconds, globalPos)
+	if !ok {
 		return
 	}
 
-	var instanceUUID uuid.UUID
-	newlyAdded := true
-	oldAmount := 0
-
-	// add the Item to the tile. if the Item is stackable, attempt to find an update an newlyAdded stackable with the
-	// same Item id
-	if stackable {
-		instanceUUID, newlyAdded, oldAmount = tile.AddStackableItem(itemID, amount)
-	} else {
-		instanceUUID = tile.AddItem(itemID)
-	}
-
 	// find each region manager that is aware of this tile and inform them about the change
 	regions := m.findOverlappingRegions(globalPos)
 	for _, origin := range regions {
@@ -204,17 +197,6 @@ func (m *MapManager) AddGroundItem(itemID, amount int, stackable bool, timeoutSe
 
 		m.addPendingRegion(origin)
 	}
-
-	// if this Item has an expiration, schedule an event to remove it after the fact
-	if timeoutSeconds != nil {
-		timeout := *timeoutSeconds
-		m.scheduler.Plan(&Event{
-			Type:         EventRemoveExpiredGroundItem,
-			Schedule:     time.Now().Add(time.Second * time.Duration(timeout)),
-			InstanceUUID: instanceUUID,
-			GlobalPos:    globalPos,
-		})
-	}
 }
 
 // RemoveGroundItem attempts to remove a ground Item with the given ID at a position, in global coordinates. If the Item
@@ -268,11 +250,25 @@ func (m *MapManager) ClearGroundItems(globalPos model.Vector3D) {
 	}
 }
 
-// WarmUp computes the initial state of the world map. This should generally be called only once before the game state
-// begins changing.
-func (m *MapManager) WarmUp() {
+// WarmUp restores the mutable state of the world map from a previous run, if one is given, and computes the initial
+// state of the world map. Ground items are restored using their definitions in items, and items that are no longer
+// defined are discarded. This should generally be called only once before the game state begins changing.
+func (m *MapManager) WarmUp(state *model.WorldState, items map[int]*model.Item) {
 	var wg sync.WaitGroup
 
+	// place ground items back onto their tiles before the regions compute their state, so that they are included in it
+	if state != nil {
+		for _, item := range state.GroundItems {
+			def := items[item.ItemID]
+			if def == nil {
+				logger.Warnf("discarding unknown ground item %d at %s", item.ItemID, item.GlobalPos)
+				continue
+			}
+
+			m.placeGroundItem(item.ItemID, item.Amount, def.Stackable, item.RemainingSeconds, item.GlobalPos)
+		}
+	}
+
 	// recompute each region's state in isolation
 	for _, mgr := range m.regions {
 		wg.Add(1)
@@ -286,6 +282,51 @@ func (m *MapManager) WarmUp() {
 	wg.Wait()
 }
 
+// Snapshot returns the mutable state of the world map, including all ground items and the time left until they are
+// removed.
+func (m *MapManager) Snapshot() *model.WorldState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := &model.WorldState{}
+	now := time.Now()
+
+	for globalPos := range m.groundItemTiles {
+		items := m.worldMap.Tile(globalPos).CopyGroundItems()
+
+		// stop tracking tiles whose ground items have all been removed
+		if len(items) == 0 {
+			delete(m.groundItemTiles, globalPos)
+			continue
+		}
+
+		// tiles keep their newest items first, so add them in reverse to restore them in the same order
+		for i := len(items) - 1; i >= 0; i-- {
+			item := items[i]
+
+			var remainingSeconds *int
+			if item.ExpiresAt != nil {
+				// skip items that are about to be removed anyway
+				remaining := int(math.Ceil(item.ExpiresAt.Sub(now).Seconds()))
+				if remaining <= 0 {
+					continue
+				}
+
+				remainingSeconds = &remaining
+			}
+
+			state.GroundItems = append(state.GroundItems, &model.WorldGroundItem{
+				ItemID:           item.ItemID,
+				Amount:           item.Amount,
+				GlobalPos:        globalPos,
+				RemainingSeconds: remainingSeconds,
+			})
+		}
+	}
+
+	return state
+}
+
 // Reconcile validates the current state of the entire world map and recomputes its state if a change has occurred.
 func (m *MapManager) Reconcile() map[model.Vector3D][]response.Response {
 	updates := map[model.Vector3D][]response.Response{}
@@ -345,6 +386,51 @@ func (m *MapManager) addPendingRegion(origin model.Vector3D) {
 	m.pendingRegions[origin] = true
 }
 
+// placeGroundItem adds a ground Item to the top of a tile with an optional timeout (in seconds), without informing any
+// region managers about the change. If a new Item was placed on the tile, true will be returned in the first tuple
+// element, otherwise false if an existing stackable was updated, along with its previous stack amount. If there is no
+// tile at the position, the last tuple element will be false.
+func (m *MapManager) placeGroundItem(itemID, amount int, stackable bool, timeoutSeconds *int, globalPos model.Vector3D) (bool, int, bool) {
+	tile := m.worldMap.Tile(globalPos)
+	if tile == nil {
+		return false, 0, false
+	}
+
+	var expiresAt *time.Time
+	if timeoutSeconds != nil {
+		schedule := time.Now().Add(time.Second * time.Duration(*timeoutSeconds))
+		expiresAt = &schedule
+	}
+
+	var instanceUUID uuid.UUID
+	newlyAdded := true
+	oldAmount := 0
+
+	// add the Item to the tile. if the Item is stackable, attempt to find an update an newlyAdded stackable with the
+	// same Item id
+	if stackable {
+		instanceUUID, newlyAdded, oldAmount = tile.AddStackableItem(itemID, amount, expiresAt)
+	} else {
+		instanceUUID = tile.AddItem(itemID, expiresAt)
+	}
+
+	m.mu.Lock()
+	m.groundItemTiles[globalPos] = true
+	m.mu.Unlock()
+
+	// if this Item has an expiration, schedule an event to remove it after the fact
+	if expiresAt != nil {
+		m.scheduler.Plan(&Event{
+			Type:         EventRemoveExpiredGroundItem,
+			Schedule:     *expiresAt,
+			InstanceUUID: instanceUUID,
+			GlobalPos:    globalPos,
+		})
+	}
+
+	return newlyAdded, oldAmount, true
+}
+
 // loop processes state changes to the map that occur internally.
 func (m *MapManager) loop() {
 	run := true
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_MapManager_WarmUp_restoresGroundItems(t *testing.T) {
+	pos := model.Vector3D{X: 3222, Y: 3218, Z: 0}
+	other := model.Vector3D{X: 3223, Y: 3218, Z: 0}
+
+	m := model.NewMap()
+	m.SetTile(pos, &model.Tile{})
+	m.SetTile(other, &model.Tile{})
+
+	timeout := 60
+	mgr := NewMapManager(m)
+	mgr.WarmUp(&model.WorldState{
+		GroundItems: []*model.WorldGroundItem{
+			{ItemID: 1333, Amount: 1, GlobalPos: pos},
+			{ItemID: 995, Amount: 500, GlobalPos: pos, RemainingSeconds: &timeout},
+			{ItemID: 1351, Amount: 1, GlobalPos: other},
+			// items on tiles that no longer exist are skipped
+			{ItemID: 1351, Amount: 1, GlobalPos: model.Vector3D{X: 1, Y: 1, Z: 0}},
+			// items that are no longer defined are skipped
+			{ItemID: 4151, Amount: 1, GlobalPos: other},
+		},
+	}, testItems)
+
+	// the newest item is on top of the tile
+	items := m.Tile(pos).GroundItems()
+	require.Len(t, items, 2)
+	assert.Equal(t, 995, items[0].ItemID)
+	assert.Equal(t, 1333, items[1].ItemID)
+	assert.Nil(t, items[1].ExpiresAt)
+	require.NotNil(t, items[0].ExpiresAt)
+	assert.Len(t, m.Tile(other).GroundItems(), 1)
+
+	// expiring items are scheduled for removal
+	event := mgr.scheduler.Next()
+	require.NotNil(t, event)
+	assert.Equal(t, items[0].InstanceUUID, event.InstanceUUID)
+}
+
+func Test_MapManager_Snapshot(t *testing.T) {
+	pos := model.Vector3D{X: 3222, Y: 3218, Z: 0}
+
+	m := model.NewMap()
+	m.SetTile(pos, &model.Tile{})
+
+	timeout := 60
+	mgr := NewMapManager(m)
+	mgr.WarmUp(&model.WorldState{
+		GroundItems: []*model.WorldGroundItem{
+			{ItemID: 1333, Amount: 1, GlobalPos: pos},
+			{ItemID: 995, Amount: 500, GlobalPos: pos, RemainingSeconds: &timeout},
+		},
+	}, testItems)
+
+	state := mgr.Snapshot()
+	require.Len(t, state.GroundItems, 2)
+
+	// items are saved oldest first, so they can be restored in the same order
+	assert.Equal(t, 1333, state.GroundItems[0].ItemID)
+	assert.Nil(t, state.GroundItems[0].RemainingSeconds)
+	assert.Equal(t, 995, state.GroundItems[1].ItemID)
+	assert.Equal(t, 500, state.GroundItems[1].Amount)
+	assert.Equal(t, pos, state.GroundItems[1].GlobalPos)
+	assert.InDelta(t, 60, *state.GroundItems[1].RemainingSeconds, 1)
+
+	// tiles that have been cleared are no longer tracked
+	m.Tile(pos).Clear()
+	assert.Empty(t, mgr.Snapshot().GroundItems)
+	assert.Empty(t, mgr.groundItemTiles)
+}
+
+func Test_MapManager_WarmUp_stackableItems(t *testing.T) {
+	pos := model.Vector3D{X: 3222, Y: 3218, Z: 0}
+
+	m := model.NewMap()
+	m.SetTile(pos, &model.Tile{})
+
+	// a single coin is still stackable, so more coins dropped on the tile are added to it
+	mgr := NewMapManager(m)
+	mgr.WarmUp(&model.WorldState{
+		GroundItems: []*model.WorldGroundItem{
+			{ItemID: 995, Amount: 1, GlobalPos: pos},
+		},
+	}, testItems)
+
+	mgr.placeGroundItem(995, 10, true, nil, pos)
+
+	items := m.Tile(pos).GroundItems()
+	require.Len(t, items, 1)
+	assert.Equal(t, 11, items[0].Amount)
+}
+
+// testItems are item definitions for ground items used in tests.
+var testItems = map[int]*model.Item{
+	995:  {ID: 995, Stackable: true},
+	1333: {ID: 1333},
+	1351: {ID: 1351},
+}
@@ -5,7 +5,9 @@ import (
 	"github.com/mbpolan/openmcs/internal/model"
 	"github.com/mbpolan/openmcs/internal/network"
 	"github.com/mbpolan/openmcs/internal/network/response"
+	"github.com/mbpolan/openmcs/internal/recording"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,9 +26,11 @@ type playerEntity struct {
 	outChan             chan response.Response
 	path                []model.Vector2D
 	nextPathIdx         int
-	writer              *network.ProtocolWriter
+	conn                atomic.Pointer[playerConnection]
+	detachedAt          time.Time
 	lastChatMessage     *model.ChatMessage
 	lastChatTime        time.Time
+	recentChat          []*model.ChatLogEntry
 	chatHighWater       time.Time
 	tabInterfaces       map[model.ClientTab]int
 	privateMessageID    int
@@ -44,12 +48,29 @@ type playerEntity struct {
 	isLowMemory         bool
 }
 
+// playerConnection is the client connection a player entity sends responses to.
+type playerConnection struct {
+	writer   *network.ProtocolWriter
+	recorder *recording.Recorder
+}
+
 type playerStatusBroadcast struct {
 	targets []string
 }
 
+// flushResponse is queued for a player to determine when all responses queued before it have been sent.
+type flushResponse struct {
+	done chan bool
+}
+
+// Write signals that the flush is complete without writing anything to the stream.
+func (r *flushResponse) Write(w *network.ProtocolWriter) error {
+	close(r.done)
+	return nil
+}
+
 // newPlayerEntity creates a new player entity.
-func newPlayerEntity(p *model.Player, w *network.ProtocolWriter) *playerEntity {
+func newPlayerEntity(p *model.Player) *playerEntity {
 	changeChan := make(chan bool)
 
 	return &playerEntity{
@@ -64,7 +85,24 @@ func newPlayerEntity(p *model.Player, w *network.ProtocolWriter) *playerEntity {
 		privateMessageID: 1,
 		statRegenTicks:   map[model.SkillType]int{},
 		tabInterfaces:    map[model.ClientTab]int{},
-		writer:           w,
+	}
+}
+
+// RecordChat keeps a chat message sent by the player for moderation purposes, discarding the oldest messages once
+// more than limit messages are kept. The recipient is empty for public chat messages.
+func (pe *playerEntity) RecordChat(recipient, text string, limit int) {
+	if limit <= 0 {
+		return
+	}
+
+	pe.recentChat = append(pe.recentChat, &model.ChatLogEntry{
+		Recipient: recipient,
+		Text:      text,
+		SentAt:    time.Now(),
+	})
+
+	if len(pe.recentChat) > limit {
+		pe.recentChat = pe.recentChat[len(pe.recentChat)-limit:]
 	}
 }
 
@@ -191,6 +229,74 @@ func (pe *playerEntity) Send(responses ...response.Response) {
 	}
 }
 
+// Attach sets the client connection that responses are written to, returning the previous connection if there was
+// one. A nil writer detaches the player from their client, and responses are discarded until a new connection is
+// attached.
+func (pe *playerEntity) Attach(writer *network.ProtocolWriter, recorder *recording.Recorder) *playerConnection {
+	if writer == nil {
+		return pe.conn.Swap(nil)
+	}
+
+	return pe.conn.Swap(&playerConnection{
+		writer:   writer,
+		recorder: recorder,
+	})
+}
+
+// Attached returns true if the player's responses are written to a writer.
+func (pe *playerEntity) Attached(writer *network.ProtocolWriter) bool {
+	conn := pe.conn.Load()
+	return conn != nil && conn.writer == writer
+}
+
+// Write immediately writes a response to the player's client, recording it if the player's session is being recorded.
+// If the player is not attached to a client, the response is discarded.
+func (pe *playerEntity) Write(resp response.Response) error {
+	return pe.writeTo(pe.conn.Load(), resp)
+}
+
+// DiscardQueued removes all responses that are queued for the player but have not yet been sent. Flush responses are
+// processed as if they had been sent.
+func (pe *playerEntity) DiscardQueued() {
+	for {
+		select {
+		case resp := <-pe.outChan:
+			if flush, ok := resp.(*flushResponse); ok {
+				close(flush.done)
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+// writeTo writes a response to a client connection, which may be nil if the player is not attached to a client.
+func (pe *playerEntity) writeTo(conn *playerConnection, resp response.Response) error {
+	if conn == nil {
+		// flush responses still need to be processed, even if there is no client to write to
+		if flush, ok := resp.(*flushResponse); ok {
+			close(flush.done)
+		}
+
+		return nil
+	}
+
+	if conn.recorder == nil {
+		return conn.writer.WriteMessage(resp)
+	}
+
+	conn.writer.StartCapture()
+	err := conn.writer.WriteMessage(resp)
+	data := conn.writer.StopCapture()
+
+	if len(data) > 0 {
+		conn.recorder.Record(recording.Outbound, data[0], data[1:])
+	}
+
+	return err
+}
+
 // Drop flags that this player should be disconnected and no more responses should be sent to the client.
 func (pe *playerEntity) Drop() {
 	select {
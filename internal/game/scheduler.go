@@ -49,8 +49,12 @@ func (s *Scheduler) Next() *Event {
 
 // Plan schedules an event for later processing.
 func (s *Scheduler) Plan(e *Event) {
+	// wake up the consumer without blocking, since a single pending signal is enough for it to notice the new event
 	defer func() {
-		s.changeChan <- true
+		select {
+		case s.changeChan <- true:
+		default:
+		}
 	}()
 
 	s.mu.Lock()
@@ -12,6 +12,7 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"time"
 )
 
 const luaTypePlayerEntity = "playerEntity"
@@ -249,6 +250,11 @@ func (s *ScriptManager) registerItemModel(l *lua.LState) {
 			state.Push(lua.LString(item.Name))
 			return 1
 		},
+		"members_only": func(state *lua.LState) int {
+			item := state.CheckUserData(1).Value.(*model.Item)
+			state.Push(lua.LBool(item.MembersOnly))
+			return 1
+		},
 		"value": func(state *lua.LState) int {
 			item := state.CheckUserData(1).Value.(*model.Item)
 
@@ -648,7 +654,7 @@ func (s *ScriptManager) registerPlayerModel(l *lua.LState) {
 		"has_membership": func(state *lua.LState) int {
 			pe := state.CheckUserData(1).Value.(*playerEntity)
 
-			state.Push(lua.LBool(pe.player.Member))
+			state.Push(lua.LBool(pe.player.IsMember(time.Now())))
 			return 1
 		},
 		"has_prayer_active": func(state *lua.LState) int {
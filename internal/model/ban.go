@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BanTarget enumerates what a ban is applied against.
+type BanTarget int
+
+const (
+	// BanTargetAccount bans a player account by its username.
+	BanTargetAccount BanTarget = iota
+	// BanTargetAddress bans a network address.
+	BanTargetAddress
+	// BanTargetUID bans the unique machine identifier reported by a client.
+	BanTargetUID
+)
+
+// Ban prevents a player account, network address or client machine from logging in.
+type Ban struct {
+	// ID is the identifier of the ban.
+	ID int
+	// Target is what the ban is applied against.
+	Target BanTarget
+	// Value is the username, network address or client identifier that is banned.
+	Value string
+	// Reason is a description of why the ban was issued.
+	Reason string
+	// IssuedBy is the username of the moderator that issued the ban.
+	IssuedBy string
+	// IssuedAt is when the ban was issued.
+	IssuedAt time.Time
+	// ExpiresAt is when the ban ends, or nil if the ban is permanent.
+	ExpiresAt *time.Time
+}
+
+// Active returns true if the ban is in effect at a point in time.
+func (b *Ban) Active(now time.Time) bool {
+	return b.ExpiresAt == nil || now.Before(*b.ExpiresAt)
+}
+
+// Applies returns true if the ban is against a player's username, network address or client identifier.
+func (b *Ban) Applies(p *Player) bool {
+	switch b.Target {
+	case BanTargetAccount:
+		return strings.EqualFold(b.Value, p.Username)
+	case BanTargetAddress:
+		return b.Value == p.Address
+	case BanTargetUID:
+		return b.Value == strconv.FormatUint(uint64(p.UID), 10)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// LoginAttemptSource enumerates what failed login attempts are tracked against.
+type LoginAttemptSource int
+
+const (
+	// LoginAttemptSourceUsername tracks attempts to log into a particular account.
+	LoginAttemptSourceUsername LoginAttemptSource = iota
+	// LoginAttemptSourceAddress tracks attempts made from a particular network address.
+	LoginAttemptSourceAddress
+)
+
+// LoginAttempts tracks consecutive failed login attempts made for a username or from a network address.
+type LoginAttempts struct {
+	// Failures is the number of failed attempts since the last lockout or successful login.
+	Failures int
+	// Lockouts is the number of times logins have been locked out since the last successful login.
+	Lockouts int
+	// LastFailure is when the most recent failed attempt was made.
+	LastFailure time.Time
+	// LockedUntil is when the current lockout ends, if any.
+	LockedUntil time.Time
+}
+
+// Locked returns true if logins are locked out at a point in time.
+func (a *LoginAttempts) Locked(now time.Time) bool {
+	return now.Before(a.LockedUntil)
+}
+
+// LoginResult enumerates the outcomes of a login attempt.
+type LoginResult int
+
+const (
+	// LoginResultSuccess indicates the player was authenticated.
+	LoginResultSuccess LoginResult = iota
+	// LoginResultInvalidCredentials indicates the username did not exist or the password did not match.
+	LoginResultInvalidCredentials
+	// LoginResultLockedOut indicates the attempt was rejected due to too many prior failed attempts.
+	LoginResultLockedOut
+	// LoginResultBanned indicates the attempt was rejected because the account, network address or client is banned.
+	LoginResultBanned
+)
+
+// LoginAuditEntry is a record of a single login attempt.
+type LoginAuditEntry struct {
+	// Username is the username the client attempted to log in with.
+	Username string
+	// Address is the network address the attempt was made from.
+	Address string
+	// UID is the unique identifier reported by the client.
+	UID uint32
+	// Result is the outcome of the attempt.
+	Result LoginResult
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/google/uuid"
 	"sync"
+	"time"
 )
 
 // TileGroundItem is an instance of an item placed on a tile.
@@ -10,6 +11,8 @@ type TileGroundItem struct {
 	InstanceUUID uuid.UUID
 	ItemID       int
 	Amount       int
+	// ExpiresAt is when the item is removed from the tile, or nil if it stays on the tile indefinitely.
+	ExpiresAt *time.Time
 }
 
 // Tile is the smallest unit of space on the world map.
@@ -31,8 +34,8 @@ func (t *Tile) AddObject(object *WorldObject) {
 	t.objects = append(t.objects, object)
 }
 
-// AddItem adds a non-stackable ground item to the tile, returning its unique instance UUID.
-func (t *Tile) AddItem(id int) uuid.UUID {
+// AddItem adds a non-stackable ground item to the tile with an optional expiry, returning its unique instance UUID.
+func (t *Tile) AddItem(id int, expiresAt *time.Time) uuid.UUID {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -40,17 +43,18 @@ func (t *Tile) AddItem(id int) uuid.UUID {
 		InstanceUUID: uuid.New(),
 		Amount:       1,
 		ItemID:       id,
+		ExpiresAt:    expiresAt,
 	}
 
 	t.groundItems = append([]*TileGroundItem{item}, t.groundItems...)
 	return item.InstanceUUID
 }
 
-// AddStackableItem adds a stackable ground item with a stack amount to the tile, returning its unique instance UUID.
-// If a new item was added to the tile, true will be returned in the second tuple element, otherwise false if an
-// existing item's stack was updated. If an existing item was updated, the previous stack amount will be returned in
-// the third tuple element.
-func (t *Tile) AddStackableItem(id, amount int) (uuid.UUID, bool, int) {
+// AddStackableItem adds a stackable ground item with a stack amount and an optional expiry to the tile, returning its
+// unique instance UUID. If a new item was added to the tile, true will be returned in the second tuple element,
+// otherwise false if an existing item's stack was updated. If an existing item was updated, the previous stack amount
+// will be returned in the third tuple element, and the stack takes on the new expiry.
+func (t *Tile) AddStackableItem(id, amount int, expiresAt *time.Time) (uuid.UUID, bool, int) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -59,6 +63,7 @@ func (t *Tile) AddStackableItem(id, amount int) (uuid.UUID, bool, int) {
 		if item.ItemID == id && int64(item.Amount+amount) < MaxStackableSize {
 			// reset the item's instance uuid and add the amount to the stack
 			item.InstanceUUID = uuid.New()
+			item.ExpiresAt = expiresAt
 			oldAmount := item.Amount
 			item.Amount += amount
 			return item.InstanceUUID, false, oldAmount
@@ -70,6 +75,7 @@ func (t *Tile) AddStackableItem(id, amount int) (uuid.UUID, bool, int) {
 		InstanceUUID: uuid.New(),
 		Amount:       amount,
 		ItemID:       id,
+		ExpiresAt:    expiresAt,
 	}
 
 	t.groundItems = append([]*TileGroundItem{item}, t.groundItems...)
@@ -89,6 +95,20 @@ func (t *Tile) GroundItems() []*TileGroundItem {
 	return items
 }
 
+// CopyGroundItems returns copies of the ground items located on this tile, which are safe to read while the tile is
+// being modified.
+func (t *Tile) CopyGroundItems() []TileGroundItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items := make([]TileGroundItem, len(t.groundItems))
+	for i, item := range t.groundItems {
+		items[i] = *item
+	}
+
+	return items
+}
+
 // RemoveItemByID removes the first ground item that matches the item ID. If the item was found and removed, a pointer
 // to the TileGroundItem model will be returned. If there are multiple ground items with the same item ID, only the
 // first will be removed.
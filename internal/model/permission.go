@@ -0,0 +1,56 @@
+package model
+
+// Permission is a named privilege that a player's role must grant before they can perform a restricted action.
+type Permission string
+
+const (
+	// PermissionAll grants every permission.
+	PermissionAll Permission = "*"
+	// PermissionAnimate allows a player to play arbitrary animations on their character.
+	PermissionAnimate Permission = "animate"
+	// PermissionBan allows a player to issue and lift bans.
+	PermissionBan Permission = "ban"
+	// PermissionClearTile allows a player to remove items from the tile they are standing on.
+	PermissionClearTile Permission = "clearTile"
+	// PermissionInterfaces allows a player to open and close arbitrary interfaces.
+	PermissionInterfaces Permission = "interfaces"
+	// PermissionKick allows a player to disconnect other players.
+	PermissionKick Permission = "kick"
+	// PermissionMute allows a player to mute and unmute other players.
+	PermissionMute Permission = "mute"
+	// PermissionPosition allows a player to query their current position.
+	PermissionPosition Permission = "position"
+	// PermissionReloadScripts allows a player to reload the server's scripts.
+	PermissionReloadScripts Permission = "reloadScripts"
+	// PermissionReports allows a player to list and resolve abuse reports.
+	PermissionReports Permission = "reports"
+	// PermissionRollback allows a player to list the saved snapshots of other players and roll them back.
+	PermissionRollback Permission = "rollback"
+	// PermissionSpawnItem allows a player to spawn items.
+	PermissionSpawnItem Permission = "spawnItem"
+	// PermissionTeleport allows a player to teleport to any location.
+	PermissionTeleport Permission = "teleport"
+)
+
+// Permissions is a list of all named permissions that can be granted to a role.
+var Permissions = []Permission{
+	PermissionAnimate,
+	PermissionBan,
+	PermissionClearTile,
+	PermissionInterfaces,
+	PermissionKick,
+	PermissionMute,
+	PermissionPosition,
+	PermissionReloadScripts,
+	PermissionReports,
+	PermissionRollback,
+	PermissionSpawnItem,
+	PermissionTeleport,
+}
+
+// PlayerRoles maps the names of player roles to their player types.
+var PlayerRoles = map[string]PlayerType{
+	"normal":    PlayerNormal,
+	"moderator": PlayerModerator,
+	"admin":     PlayerAdmin,
+}
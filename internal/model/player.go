@@ -3,6 +3,7 @@ package model
 import (
 	"math"
 	"strings"
+	"time"
 )
 
 // MaxInventorySlots is the maximum number of inventory slots.
@@ -64,6 +65,8 @@ type Player struct {
 	Modes PlayerModes
 	// Muted is true when the player is not able to chat, false if not.
 	Muted bool
+	// MuteExpiry is when the player's mute ends, or nil if the mute is permanent.
+	MuteExpiry *time.Time
 	// Friends is a slice of usernames of players added as friends.
 	Friends []string
 	// Ignored is a slice of usernames of players that are ignored.
@@ -74,6 +77,8 @@ type Player struct {
 	Member bool
 	// MemberDays is the number of days of subscription remaining.
 	MemberDays int
+	// MemberExpiry is when the player's subscription ends, or nil if it does not expire.
+	MemberExpiry *time.Time
 	// Inventory is the player's current inventory of items.
 	Inventory [MaxInventorySlots]*InventorySlot
 	// CombatStats are the player's combat statistics.
@@ -102,6 +107,10 @@ type Player struct {
 	HitpointsRegenRate int
 	// StatRegenRate is the amount of stat levels for non-hitpoints skills recovered after each interval.
 	StatRegenRate int
+	// Address is the network address the player is connected from.
+	Address string
+	// UID is the unique machine identifier reported by the player's client.
+	UID uint32
 }
 
 // PlayerModes indicates what types of chat and interactions a player wishes to receive.
@@ -158,6 +167,119 @@ func NewPlayer(username string) *Player {
 	}
 }
 
+// NormalizeUsername returns the form of a username used to compare it against others. The client treats spaces and
+// underscores in usernames as the same character, and ignores their case, so they are compared alike.
+func NormalizeUsername(username string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(username)), "_", " ")
+}
+
+// Snapshot returns a deep copy of the player's persistent data, which can be saved without holding any locks on the
+// player. Item definitions are shared with the original player since they are not modified once loaded.
+func (p *Player) Snapshot() *Player {
+	s := *p
+	entity := *p.Entity
+	s.Entity = &entity
+
+	// copy the appearance, including equipped items
+	s.Appearance.Equipment = make(map[EquipmentSlotType]*EquipmentSlot, len(p.Appearance.Equipment))
+	for slotType, slot := range p.Appearance.Equipment {
+		equipped := *slot
+		s.Appearance.Equipment[slotType] = &equipped
+	}
+
+	s.Appearance.BodyColors = append([]int(nil), p.Appearance.BodyColors...)
+	s.Appearance.Animations = make(map[AnimationID]int, len(p.Appearance.Animations))
+	for k, v := range p.Appearance.Animations {
+		s.Appearance.Animations[k] = v
+	}
+
+	if p.MuteExpiry != nil {
+		expiry := *p.MuteExpiry
+		s.MuteExpiry = &expiry
+	}
+
+	if p.MemberExpiry != nil {
+		expiry := *p.MemberExpiry
+		s.MemberExpiry = &expiry
+	}
+
+	s.Friends = append([]string(nil), p.Friends...)
+	s.Ignored = append([]string(nil), p.Ignored...)
+
+	s.Skills = make(SkillMap, len(p.Skills))
+	for skillType, skill := range p.Skills {
+		copied := *skill
+		s.Skills[skillType] = &copied
+	}
+
+	for i, slot := range p.Inventory {
+		if slot != nil {
+			copied := *slot
+			s.Inventory[i] = &copied
+		}
+	}
+
+	s.AttackStyles = make(map[WeaponStyle]AttackStyle, len(p.AttackStyles))
+	for k, v := range p.AttackStyles {
+		s.AttackStyles[k] = v
+	}
+
+	s.GameOptions = make(map[int]string, len(p.GameOptions))
+	for k, v := range p.GameOptions {
+		s.GameOptions[k] = v
+	}
+
+	s.QuestStatuses = make(map[int]QuestStatus, len(p.QuestStatuses))
+	for k, v := range p.QuestStatuses {
+		s.QuestStatuses[k] = v
+	}
+
+	s.QuestFlags = make(map[int]map[int]int, len(p.QuestFlags))
+	for questID, flags := range p.QuestFlags {
+		s.QuestFlags[questID] = make(map[int]int, len(flags))
+		for k, v := range flags {
+			s.QuestFlags[questID][k] = v
+		}
+	}
+
+	s.MusicTracks = make(map[int]bool, len(p.MusicTracks))
+	for k, v := range p.MusicTracks {
+		s.MusicTracks[k] = v
+	}
+
+	s.ActivePrayers = make(map[int]int, len(p.ActivePrayers))
+	for k, v := range p.ActivePrayers {
+		s.ActivePrayers[k] = v
+	}
+
+	return &s
+}
+
+// IsMuted returns true if the player is not able to chat at a point in time.
+func (p *Player) IsMuted(now time.Time) bool {
+	return p.Muted && (p.MuteExpiry == nil || now.Before(*p.MuteExpiry))
+}
+
+// IsMember returns true if the player has an active subscription at a point in time.
+func (p *Player) IsMember(now time.Time) bool {
+	return p.Member && (p.MemberExpiry == nil || now.Before(*p.MemberExpiry))
+}
+
+// UpdateMembership determines if the player's subscription is active at a point in time, and computes the number of
+// days remaining on it. A subscription with an end date is active only until that date passes.
+func (p *Player) UpdateMembership(now time.Time) {
+	p.MemberDays = 0
+	if p.MemberExpiry == nil {
+		return
+	}
+
+	remaining := p.MemberExpiry.Sub(now)
+	p.Member = remaining > 0
+	if p.Member {
+		p.MemberDays = int(math.Ceil(remaining.Hours() / 24))
+	}
+}
+
 // AttackStyle returns the active attack style for a particular weapon style.
 func (p *Player) AttackStyle(weaponStyle WeaponStyle) AttackStyle {
 	return p.AttackStyles[weaponStyle]
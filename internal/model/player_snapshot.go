@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// PlayerSnapshot is a copy of a player's persistent data taken when they were saved, which the player can be rolled
+// back to.
+type PlayerSnapshot struct {
+	// ID is the unique identifier of the snapshot.
+	ID int
+	// PlayerID is the ID of the player the snapshot was taken of.
+	PlayerID int
+	// SchemaVersion is the version of the format the data is encoded with.
+	SchemaVersion int
+	// Data is the player's encoded data. It is only loaded along with a single snapshot.
+	Data []byte
+	// CreatedAt is when the snapshot was taken.
+	CreatedAt time.Time
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func Test_Player_SetSkillExperience_combatLevels_hitpoints(t *testing.T) {
@@ -61,3 +62,70 @@ func Test_Player_SetSkillExperience_level(t *testing.T) {
 	assert.Equal(t, 2, p.Skills[SkillTypeFletching].BaseLevel)
 	assert.Equal(t, 10, p.Skills[SkillTypePrayer].BaseLevel)
 }
+
+func Test_Player_IsMuted(t *testing.T) {
+	p := NewPlayer("mike")
+	now := time.Unix(1000, 0)
+
+	assert.False(t, p.IsMuted(now))
+
+	// permanent mute
+	p.Muted = true
+	assert.True(t, p.IsMuted(now))
+
+	// timed mute
+	expiry := now.Add(time.Hour)
+	p.MuteExpiry = &expiry
+	assert.True(t, p.IsMuted(now))
+	assert.False(t, p.IsMuted(expiry))
+}
+
+func Test_Player_UpdateMembership(t *testing.T) {
+	p := NewPlayer("mike")
+	now := time.Unix(1000, 0)
+
+	// subscriptions without an end date never expire
+	p.Member = true
+	p.UpdateMembership(now)
+	assert.True(t, p.IsMember(now))
+	assert.Equal(t, 0, p.MemberDays)
+
+	// partial days count as a full day remaining
+	expiry := now.Add(36 * time.Hour)
+	p.MemberExpiry = &expiry
+	p.UpdateMembership(now)
+	assert.True(t, p.IsMember(now))
+	assert.Equal(t, 2, p.MemberDays)
+	assert.False(t, p.IsMember(expiry))
+
+	// expired subscriptions are no longer active
+	p.UpdateMembership(expiry)
+	assert.False(t, p.Member)
+	assert.Equal(t, 0, p.MemberDays)
+}
+
+func Test_Player_Snapshot(t *testing.T) {
+	p := NewPlayer("mike")
+	p.ID = 42
+	p.SetSkillExperience(SkillTypeAttack, 277)
+	p.SetInventoryItem(&Item{ID: 995}, 100, 0)
+	p.SetQuestFlag(1, 2, 3)
+	p.Friends = []string{"joe"}
+
+	s := p.Snapshot()
+	assert.Equal(t, p.ID, s.ID)
+	assert.Equal(t, 100, s.Inventory[0].Amount)
+
+	// changes to the player after the snapshot is taken should not be reflected in it
+	p.GlobalPos = Vector3D{X: 1, Y: 2, Z: 3}
+	p.SetSkillExperience(SkillTypeAttack, 1160)
+	p.Inventory[0].Amount = 1
+	p.SetQuestFlag(1, 2, 4)
+	p.Friends[0] = "bob"
+
+	assert.Equal(t, Vector3D{}, s.GlobalPos)
+	assert.Equal(t, float64(277), s.SkillExperience(SkillTypeAttack))
+	assert.Equal(t, 100, s.Inventory[0].Amount)
+	assert.Equal(t, 3, s.QuestFlag(1, 2))
+	assert.Equal(t, []string{"joe"}, s.Friends)
+}
@@ -17,3 +17,8 @@ func MakeRectangle(x1, y1, x2, y2 int) Rectangle {
 		Y2: y2,
 	}
 }
+
+// Contains returns true if a point lies within the rectangle's boundaries, inclusive.
+func (r Rectangle) Contains(v Vector2D) bool {
+	return v.X >= r.X1 && v.X <= r.X2 && v.Y >= r.Y2 && v.Y <= r.Y1
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// AbuseReport is a report filed by a player against another player for breaking the rules.
+type AbuseReport struct {
+	// ID is the identifier of the report.
+	ID int
+	// Reporter is the username of the player that filed the report.
+	Reporter string
+	// Offender is the username of the player that was reported.
+	Offender string
+	// Reason is the identifier of the rule the offender was reported for breaking.
+	Reason int
+	// Muted is true if the reporter is a moderator that muted the offender as part of the report.
+	Muted bool
+	// Chat is the offender's recent chat messages at the time the report was filed.
+	Chat []*ChatLogEntry
+	// CreatedAt is when the report was filed.
+	CreatedAt time.Time
+	// ResolvedBy is the username of the moderator that resolved the report, if it has been resolved.
+	ResolvedBy string
+	// ResolvedAt is when the report was resolved, or nil if it is still open.
+	ResolvedAt *time.Time
+}
+
+// ChatLogEntry is a chat message sent by a player, which is kept for moderation purposes.
+type ChatLogEntry struct {
+	// Recipient is the username of the player a private message was sent to, or empty for public chat.
+	Recipient string
+	// Text is the content of the message.
+	Text string
+	// SentAt is when the message was sent.
+	SentAt time.Time
+}
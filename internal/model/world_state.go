@@ -0,0 +1,19 @@
+package model
+
+// WorldState is the mutable state of the world map, which is kept across server restarts.
+type WorldState struct {
+	// GroundItems are the items placed on tiles across the world map, with the oldest items on a tile first.
+	GroundItems []*WorldGroundItem
+}
+
+// WorldGroundItem is an item placed on a tile of the world map.
+type WorldGroundItem struct {
+	// ItemID is the ID of the item.
+	ItemID int
+	// Amount is the stack size of the item.
+	Amount int
+	// GlobalPos is the position of the tile, in global coordinates.
+	GlobalPos Vector3D
+	// RemainingSeconds is the time left until the item is removed, or nil if it stays on the tile indefinitely.
+	RemainingSeconds *int
+}
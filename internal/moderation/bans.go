@@ -0,0 +1,130 @@
+package moderation
+
+import (
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BanList issues, lifts and checks bans against player accounts, network addresses and client machines.
+type BanList struct {
+	now   func() time.Time
+	store *store.Store
+}
+
+// NewBanList creates a ban list that persists bans in a store.
+func NewBanList(store *store.Store) *BanList {
+	return &BanList{
+		now:   time.Now,
+		store: store,
+	}
+}
+
+// Issue bans a username, network address or client identifier. A ban with a zero duration is permanent.
+func (b *BanList) Issue(target model.BanTarget, value, reason, issuedBy string, duration time.Duration) (*model.Ban, error) {
+	now := b.now()
+
+	ban := &model.Ban{
+		Target:   target,
+		Value:    normalizeBanValue(target, value),
+		Reason:   reason,
+		IssuedBy: issuedBy,
+		IssuedAt: now,
+	}
+
+	if duration > 0 {
+		expiresAt := now.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	err := b.store.SaveBan(ban)
+	if err != nil {
+		return nil, err
+	}
+
+	return ban, nil
+}
+
+// Lift removes all bans against a username, network address or client identifier, returning the number of bans that
+// were lifted.
+func (b *BanList) Lift(target model.BanTarget, value string) (int, error) {
+	return b.store.DeleteBans(target, normalizeBanValue(target, value))
+}
+
+// Find returns an active ban that prevents a player from logging in with a username, from a network address or
+// client machine. If no ban applies, nil is returned.
+func (b *BanList) Find(username, address string, uid uint32) (*model.Ban, error) {
+	now := b.now()
+
+	targets := map[model.BanTarget]string{
+		model.BanTargetAccount: username,
+		model.BanTargetAddress: address,
+		model.BanTargetUID:     strconv.FormatUint(uint64(uid), 10),
+	}
+
+	for target, value := range targets {
+		bans, err := b.store.LoadBans(target, normalizeBanValue(target, value))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ban := range bans {
+			if ban.Active(now) {
+				return ban, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// ParseBanTarget returns the ban target with a name, one of account, address or uid.
+func ParseBanTarget(name string) (model.BanTarget, error) {
+	switch strings.ToLower(name) {
+	case "account":
+		return model.BanTargetAccount, nil
+	case "address", "ip":
+		return model.BanTargetAddress, nil
+	case "uid":
+		return model.BanTargetUID, nil
+	default:
+		return 0, fmt.Errorf("unknown ban target: %s", name)
+	}
+}
+
+// ParseDuration parses the duration of a ban or mute. In addition to the units supported by time.ParseDuration, a
+// number of days can be given with a "d" suffix. The value "perm" represents a permanent duration of zero.
+func ParseDuration(text string) (time.Duration, error) {
+	text = strings.ToLower(text)
+	if text == "perm" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration: %s", text)
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration: %s", text)
+	}
+
+	return duration, nil
+}
+
+// normalizeBanValue returns the form of a banned value that is stored. Usernames are compared ignoring case.
+func normalizeBanValue(target model.BanTarget, value string) string {
+	if target == model.BanTargetAccount {
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+
+	return strings.TrimSpace(value)
+}
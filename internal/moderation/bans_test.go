@@ -0,0 +1,27 @@
+package moderation
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_ParseDuration(t *testing.T) {
+	duration, err := ParseDuration("perm")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), duration)
+
+	duration, err = ParseDuration("7d")
+	assert.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, duration)
+
+	duration, err = ParseDuration("90m")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, duration)
+
+	_, err = ParseDuration("-1h")
+	assert.Error(t, err)
+
+	_, err = ParseDuration("spam")
+	assert.Error(t, err)
+}
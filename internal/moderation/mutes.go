@@ -0,0 +1,42 @@
+package moderation
+
+import (
+	"github.com/mbpolan/openmcs/internal/store"
+	"time"
+)
+
+// MuteList mutes and unmutes player accounts.
+type MuteList struct {
+	now   func() time.Time
+	store *store.Store
+}
+
+// NewMuteList creates a mute list that persists mutes in a store.
+func NewMuteList(store *store.Store) *MuteList {
+	return &MuteList{
+		now:   time.Now,
+		store: store,
+	}
+}
+
+// Mute prevents a player from chatting for a duration, or permanently if the duration is zero. The time when the mute
+// ends is returned, or nil if it is permanent. If no player has the username, false is returned.
+func (m *MuteList) Mute(username string, duration time.Duration) (*time.Time, bool, error) {
+	var expiresAt *time.Time
+	if duration > 0 {
+		t := m.now().Add(duration)
+		expiresAt = &t
+	}
+
+	found, err := m.store.SaveMute(username, true, expiresAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return expiresAt, found, nil
+}
+
+// Unmute allows a player to chat again. If no player has the username, false is returned.
+func (m *MuteList) Unmute(username string) (bool, error) {
+	return m.store.SaveMute(username, false, nil)
+}
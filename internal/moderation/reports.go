@@ -0,0 +1,37 @@
+package moderation
+
+import (
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store"
+	"time"
+)
+
+// ReportQueue tracks abuse reports filed by players until a moderator resolves them.
+type ReportQueue struct {
+	now   func() time.Time
+	store *store.Store
+}
+
+// NewReportQueue creates a report queue that persists reports in a store.
+func NewReportQueue(store *store.Store) *ReportQueue {
+	return &ReportQueue{
+		now:   time.Now,
+		store: store,
+	}
+}
+
+// Submit adds a new abuse report to the queue.
+func (q *ReportQueue) Submit(report *model.AbuseReport) error {
+	report.CreatedAt = q.now()
+	return q.store.SaveAbuseReport(report)
+}
+
+// Open returns all reports that have not been resolved, oldest first.
+func (q *ReportQueue) Open() ([]*model.AbuseReport, error) {
+	return q.store.LoadOpenAbuseReports()
+}
+
+// Resolve removes a report from the queue. If no open report has the ID, false is returned.
+func (q *ReportQueue) Resolve(id int, resolvedBy string) (bool, error) {
+	return q.store.ResolveAbuseReport(id, resolvedBy, q.now())
+}
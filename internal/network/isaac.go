@@ -0,0 +1,132 @@
+package network
+
+// isaacSize is the number of words in the ISAAC state and result tables.
+const isaacSize = 256
+
+// isaacGoldenRatio is the initial value used to scramble the ISAAC state during seeding.
+const isaacGoldenRatio uint32 = 0x9E3779B9
+
+// ISAACCipher is a stream cipher based on the ISAAC pseudo-random number generator. The game client uses a pair of
+// these ciphers, seeded at login, to obfuscate packet opcodes.
+type ISAACCipher struct {
+	count       int
+	results     [isaacSize]uint32
+	memory      [isaacSize]uint32
+	accumulator uint32
+	lastResult  uint32
+	counter     uint32
+}
+
+// NewISAACCipher creates a new cipher initialized with a set of seed values.
+func NewISAACCipher(seeds []uint32) *ISAACCipher {
+	c := &ISAACCipher{}
+	for i := 0; i < len(seeds) && i < isaacSize; i++ {
+		c.results[i] = seeds[i]
+	}
+
+	c.init()
+	return c
+}
+
+// Next returns the next key in the cipher's stream.
+func (c *ISAACCipher) Next() uint32 {
+	if c.count == 0 {
+		c.isaac()
+		c.count = isaacSize
+	}
+
+	c.count--
+	return c.results[c.count]
+}
+
+// isaac generates the next block of results.
+func (c *ISAACCipher) isaac() {
+	c.counter++
+	c.lastResult += c.counter
+
+	for i := 0; i < isaacSize; i++ {
+		x := c.memory[i]
+
+		switch i & 3 {
+		case 0:
+			c.accumulator ^= c.accumulator << 13
+		case 1:
+			c.accumulator ^= c.accumulator >> 6
+		case 2:
+			c.accumulator ^= c.accumulator << 2
+		case 3:
+			c.accumulator ^= c.accumulator >> 16
+		}
+
+		c.accumulator += c.memory[(i+128)&0xFF]
+
+		y := c.memory[(x>>2)&0xFF] + c.accumulator + c.lastResult
+		c.memory[i] = y
+
+		c.lastResult = c.memory[(y>>10)&0xFF] + x
+		c.results[i] = c.lastResult
+	}
+}
+
+// init scrambles the seed values into the cipher's internal state and generates the first block of results.
+func (c *ISAACCipher) init() {
+	var s [8]uint32
+	for i := range s {
+		s[i] = isaacGoldenRatio
+	}
+
+	for i := 0; i < 4; i++ {
+		isaacMix(&s)
+	}
+
+	// first pass incorporates the seed values
+	for i := 0; i < isaacSize; i += 8 {
+		for j := range s {
+			s[j] += c.results[i+j]
+		}
+
+		isaacMix(&s)
+		copy(c.memory[i:i+8], s[:])
+	}
+
+	// second pass further distributes the seed across the entire state
+	for i := 0; i < isaacSize; i += 8 {
+		for j := range s {
+			s[j] += c.memory[i+j]
+		}
+
+		isaacMix(&s)
+		copy(c.memory[i:i+8], s[:])
+	}
+
+	c.isaac()
+	c.count = isaacSize
+}
+
+// isaacMix scrambles a set of eight words.
+func isaacMix(s *[8]uint32) {
+	s[0] ^= s[1] << 11
+	s[3] += s[0]
+	s[1] += s[2]
+	s[1] ^= s[2] >> 2
+	s[4] += s[1]
+	s[2] += s[3]
+	s[2] ^= s[3] << 8
+	s[5] += s[2]
+	s[3] += s[4]
+	s[3] ^= s[4] >> 16
+	s[6] += s[3]
+	s[4] += s[5]
+	s[4] ^= s[5] << 10
+	s[7] += s[4]
+	s[5] += s[6]
+	s[5] ^= s[6] >> 4
+	s[0] += s[5]
+	s[6] += s[7]
+	s[6] ^= s[7] << 8
+	s[1] += s[6]
+	s[7] += s[0]
+	s[7] ^= s[0] >> 9
+	s[2] += s[7]
+	s[0] += s[1]
+}
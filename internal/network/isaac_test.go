@@ -0,0 +1,69 @@
+package network
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_ISAACCipher_ReferenceVector(t *testing.T) {
+	c := NewISAACCipher(nil)
+
+	// the reference implementation reports the second block of results for a zeroed seed
+	c.isaac()
+
+	assert.Equal(t, uint32(0xF650E4C8), c.results[0])
+	assert.Equal(t, uint32(0xE448E96D), c.results[1])
+	assert.Equal(t, uint32(0x98DB2FB4), c.results[2])
+	assert.Equal(t, uint32(0xF5FAD54F), c.results[3])
+}
+
+func Test_ISAACCipher_Deterministic(t *testing.T) {
+	seeds := []uint32{0x1234, 0x5678, 0x9ABC, 0xDEF0}
+	a := NewISAACCipher(seeds)
+	b := NewISAACCipher(seeds)
+
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, a.Next(), b.Next())
+	}
+}
+
+func Test_ProtocolReader_Cipher(t *testing.T) {
+	seeds := []uint32{1, 2, 3, 4}
+
+	w := NewBufferedWriter()
+	w.SetCipher(NewISAACCipher(seeds))
+
+	err := w.WriteHeader(0x04)
+	assert.NoError(t, err)
+	err = w.WriteUint8(0x7F)
+	assert.NoError(t, err)
+	err = w.WriteHeader(0xA4)
+	assert.NoError(t, err)
+
+	buf, err := w.Buffer()
+	assert.NoError(t, err)
+
+	r := NewReader(buf)
+	r.SetCipher(NewISAACCipher(seeds))
+
+	header, err := r.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x04), header)
+
+	// peeking again should not advance the cipher
+	header, err = r.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x04), header)
+
+	header, err = r.Uint8()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x04), header)
+
+	b, err := r.Uint8()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x7F), b)
+
+	header, err = r.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xA4), header)
+}
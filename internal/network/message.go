@@ -0,0 +1,13 @@
+package network
+
+// Message is a packet that can be written to a stream.
+type Message interface {
+	// Write writes the contents of the message to a stream.
+	Write(w *ProtocolWriter) error
+}
+
+// Encoder writes messages to a stream using a particular wire format.
+type Encoder interface {
+	// Encode writes a message to a stream.
+	Encode(w *ProtocolWriter, m Message) error
+}
@@ -2,31 +2,80 @@ package network
 
 import (
 	"bufio"
-	"net"
+	"bytes"
+	"io"
 )
 
 // ProtocolReader parses request sent by the client.
 type ProtocolReader struct {
 	*bufio.Reader
-	buffer []byte
+	buffer    []byte
+	capture   *bytes.Buffer
+	cipher    *ISAACCipher
+	header    uint8
+	hasHeader bool
 }
 
 // NewReader returns a new ProtocolReader for a network connection.
-func NewReader(conn net.Conn) *ProtocolReader {
+func NewReader(conn io.Reader) *ProtocolReader {
 	return &ProtocolReader{
 		Reader: bufio.NewReader(conn),
 		buffer: make([]byte, 1024),
 	}
 }
 
-// Peek returns the next available byte, without advancing the reader.
+// SetCipher enables decoding of packet headers using an ISAAC cipher. Once set, each call to Peek will consume the
+// next key from the cipher when a new packet header is encountered.
+func (r *ProtocolReader) SetCipher(cipher *ISAACCipher) {
+	r.cipher = cipher
+}
+
+// StartCapture begins keeping a copy of every byte read from the stream until StopCapture is called. Packet headers
+// are captured after they have been decoded.
+func (r *ProtocolReader) StartCapture() {
+	r.capture = &bytes.Buffer{}
+}
+
+// StopCapture ends a capture started by StartCapture and returns the bytes that were read in the meantime.
+func (r *ProtocolReader) StopCapture() []byte {
+	if r.capture == nil {
+		return nil
+	}
+
+	data := r.capture.Bytes()
+	r.capture = nil
+	return data
+}
+
+// Peek returns the next available byte, without advancing the reader. The byte is treated as a packet header, and is
+// decoded if a cipher is set.
 func (r *ProtocolReader) Peek() (uint8, error) {
+	// return the previously decoded header if it has not been read yet
+	if r.hasHeader {
+		return r.header, nil
+	}
+
 	b, err := r.Reader.Peek(1)
 	if err != nil {
 		return 0, err
 	}
 
-	return b[0], nil
+	// decode the header and hold on to it until it's read from the stream
+	r.header = b[0]
+	if r.cipher != nil {
+		r.header -= uint8(r.cipher.Next())
+	}
+
+	r.hasHeader = true
+	return r.header, nil
+}
+
+// ReplaceHeader substitutes the packet header returned by the last call to Peek, so that the header is read as the
+// replacement instead. This has no effect if Peek was not called before.
+func (r *ProtocolReader) ReplaceHeader(header uint8) {
+	if r.hasHeader {
+		r.header = header
+	}
 }
 
 // Skip reads exactly n bytes and discards them.
@@ -41,14 +90,29 @@ func (r *ProtocolReader) Skip(n int) error {
 	return nil
 }
 
-// Uint8 reads a single, unsigned byte from the connection.
+// Uint8 reads a single, unsigned byte from the connection. If a packet header was previously decoded by Peek, the
+// decoded header is returned instead of the raw byte.
 func (r *ProtocolReader) Uint8() (byte, error) {
-	return r.Reader.ReadByte()
+	b, err := r.Reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	if r.hasHeader {
+		r.hasHeader = false
+		b = r.header
+	}
+
+	if r.capture != nil {
+		r.capture.WriteByte(b)
+	}
+
+	return b, nil
 }
 
 // Int8 reads a single, signed byte from the connection.
 func (r *ProtocolReader) Int8() (int8, error) {
-	b, err := r.Reader.ReadByte()
+	b, err := r.Uint8()
 	if err != nil {
 		return 0, err
 	}
@@ -4,17 +4,20 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"net"
 )
 
 // ProtocolWriter serializes and writes response from the server to the client.
 type ProtocolWriter struct {
-	buffer *bytes.Buffer
-	writer io.Writer
+	buffer  *bytes.Buffer
+	capture *bytes.Buffer
+	cipher  *ISAACCipher
+	encoder Encoder
+	opcodes map[byte]byte
+	writer  io.Writer
 }
 
 // NewWriter returns a new ProtocolWriter for a network connection.
-func NewWriter(conn net.Conn) *ProtocolWriter {
+func NewWriter(conn io.Writer) *ProtocolWriter {
 	return &ProtocolWriter{
 		writer: conn,
 	}
@@ -30,6 +33,16 @@ func NewBufferedWriter() *ProtocolWriter {
 	}
 }
 
+// Derive returns a new buffered ProtocolWriter that substitutes and encodes messages the same way as this writer. The
+// cipher is not shared, since the derived writer is used for data embedded within another packet.
+func (w *ProtocolWriter) Derive() *ProtocolWriter {
+	bw := NewBufferedWriter()
+	bw.encoder = w.encoder
+	bw.opcodes = w.opcodes
+
+	return bw
+}
+
 // Buffer returns the buffer representing the backing storage for a buffered ProtocolWriter. If the writer is not
 // buffered, an error will be returned instead.
 func (w *ProtocolWriter) Buffer() (*bytes.Buffer, error) {
@@ -40,15 +53,70 @@ func (w *ProtocolWriter) Buffer() (*bytes.Buffer, error) {
 	return w.buffer, nil
 }
 
+// StartCapture begins keeping a copy of every byte written to the stream until StopCapture is called. Packet headers
+// are captured before they are encoded.
+func (w *ProtocolWriter) StartCapture() {
+	w.capture = &bytes.Buffer{}
+}
+
+// StopCapture ends a capture started by StartCapture and returns the bytes that were written in the meantime.
+func (w *ProtocolWriter) StopCapture() []byte {
+	if w.capture == nil {
+		return nil
+	}
+
+	data := w.capture.Bytes()
+	w.capture = nil
+	return data
+}
+
+// Close closes the underlying stream, if it supports being closed.
+func (w *ProtocolWriter) Close() error {
+	if c, ok := w.writer.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// SetEncoder sets an encoder that will be used to write messages passed to WriteMessage.
+func (w *ProtocolWriter) SetEncoder(encoder Encoder) {
+	w.encoder = encoder
+}
+
+// SetOpcodeMap sets a map of packet headers to substitutes that are written in their place. Headers not present in
+// the map are written as-is.
+func (w *ProtocolWriter) SetOpcodeMap(opcodes map[byte]byte) {
+	w.opcodes = opcodes
+}
+
+// WriteMessage writes a message to the stream, using the writer's encoder if one is set.
+func (w *ProtocolWriter) WriteMessage(m Message) error {
+	if w.encoder != nil {
+		return w.encoder.Encode(w, m)
+	}
+
+	return m.Write(w)
+}
+
+// SetCipher enables encoding of packet headers using an ISAAC cipher.
+func (w *ProtocolWriter) SetCipher(cipher *ISAACCipher) {
+	w.cipher = cipher
+}
+
 // Write attempts to write the slice of bytes, returning how many bytes were written and an error if the entire slice
 // could not be written.
 func (w *ProtocolWriter) Write(b []byte) (int, error) {
+	if w.capture != nil {
+		w.capture.Write(b)
+	}
+
 	return w.writer.Write(b)
 }
 
 // WriteUint8 writes a single, unsigned byte.
 func (w *ProtocolWriter) WriteUint8(n uint8) error {
-	_, err := w.writer.Write([]byte{n})
+	_, err := w.Write([]byte{n})
 	if err != nil {
 		return err
 	}
@@ -56,6 +124,25 @@ func (w *ProtocolWriter) WriteUint8(n uint8) error {
 	return nil
 }
 
+// WriteHeader writes a packet header, substituting it using the writer's opcode map and encoding it with the writer's
+// cipher if either are set.
+func (w *ProtocolWriter) WriteHeader(header uint8) error {
+	if w.capture != nil {
+		w.capture.WriteByte(header)
+	}
+
+	if opcode, ok := w.opcodes[header]; ok {
+		header = opcode
+	}
+
+	if w.cipher != nil {
+		header += uint8(w.cipher.Next())
+	}
+
+	_, err := w.writer.Write([]byte{header})
+	return err
+}
+
 // WriteVarByte writes either a single, unsigned byte or two unsigned bytes depending on the value.
 func (w *ProtocolWriter) WriteVarByte(n uint16) error {
 	if n < 0x80 {
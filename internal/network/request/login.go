@@ -1,6 +1,8 @@
 package request
 
 import (
+	"bytes"
+	"crypto/rsa"
 	"fmt"
 	"github.com/mbpolan/openmcs/internal/network"
 )
@@ -10,23 +12,45 @@ const NewLoginRequestHeader byte = 0x12
 
 // LoginRequest is sent by the client when a player attempts to log into the server.
 type LoginRequest struct {
+	// PrivateKey is an optional RSA key used to decrypt the credentials block. If nil, the block is expected to be
+	// sent in plaintext.
+	PrivateKey  *rsa.PrivateKey
 	Seeds       []uint32
 	Version     uint16
 	UID         uint32
 	Username    string
 	Password    string
 	IsLowMemory bool
+	IsReconnect bool
 	CRCs        []uint32
 }
 
-// Read parses the content of the request from a stream. If the data cannot be read, an error will be returned.
+// NumLoginCRCs is the number of archive checksums sent by the 317 client in its login request.
+const NumLoginCRCs = 9
+
+// Read parses the content of the request from a stream, expecting the layout used by the 317 client. If the data
+// cannot be read, an error will be returned.
 func (p *LoginRequest) Read(r *network.ProtocolReader) error {
+	err := p.ReadHeader(r)
+	if err != nil {
+		return err
+	}
+
+	return p.ReadBody(r, NumLoginCRCs)
+}
+
+// ReadHeader parses the start of the request up to and including the client version, which is the same for all
+// client revisions. The rest of the request should be read by the revision used by that client version.
+func (p *LoginRequest) ReadHeader(r *network.ProtocolReader) error {
 	// read 1 byte for the header
-	_, err := r.Uint8()
+	header, err := r.Uint8()
 	if err != nil {
 		return err
 	}
 
+	// the client reconnects using a different header if it lost its connection while logged in
+	p.IsReconnect = header == ReconnectLoginRequestHeader
+
 	// skip unknown bytes
 	err = r.Skip(2)
 	if err != nil {
@@ -39,6 +63,13 @@ func (p *LoginRequest) Read(r *network.ProtocolReader) error {
 		return err
 	}
 
+	p.Version = version
+	return nil
+}
+
+// ReadBody parses the rest of the request following its header, using the layout of the 317 client with a given
+// number of archive checksums.
+func (p *LoginRequest) ReadBody(r *network.ProtocolReader, numCRCs int) error {
 	// read low memory indicator
 	lowMemory, err := r.Uint8()
 	if err != nil {
@@ -46,7 +77,7 @@ func (p *LoginRequest) Read(r *network.ProtocolReader) error {
 	}
 
 	// read expected cache crcs
-	crcs := make([]uint32, 9)
+	crcs := make([]uint32, numCRCs)
 	for i := 0; i < len(crcs); i++ {
 		crc, err := r.Uint32()
 		if err != nil {
@@ -56,14 +87,28 @@ func (p *LoginRequest) Read(r *network.ProtocolReader) error {
 		crcs[i] = crc
 	}
 
-	// read length of remaining buffer
-	_, err = r.Uint8()
+	// read length of the credentials block
+	blockSize, err := r.Uint8()
 	if err != nil {
 		return err
 	}
 
+	// the credentials block is encrypted unless the client has had rsa disabled
+	br := r
+	if p.PrivateKey != nil {
+		block := make([]byte, blockSize)
+		for i := 0; i < len(block); i++ {
+			block[i], err = r.Uint8()
+			if err != nil {
+				return err
+			}
+		}
+
+		br = network.NewReader(bytes.NewReader(network.DecryptRSABlock(p.PrivateKey, block)))
+	}
+
 	// read next segment byte
-	b, err := r.Uint8()
+	b, err := br.Uint8()
 	if err != nil {
 		return err
 	}
@@ -75,7 +120,7 @@ func (p *LoginRequest) Read(r *network.ProtocolReader) error {
 	// read four integers containing client seed
 	seeds := make([]uint32, 4)
 	for i := 0; i < len(seeds); i++ {
-		seed, err := r.Uint32()
+		seed, err := br.Uint32()
 		if err != nil {
 			return err
 		}
@@ -84,24 +129,23 @@ func (p *LoginRequest) Read(r *network.ProtocolReader) error {
 	}
 
 	// client unique identifier
-	uid, err := r.Uint32()
+	uid, err := br.Uint32()
 	if err != nil {
 		return err
 	}
 
 	// read the username and password
-	username, err := r.String()
+	username, err := br.String()
 	if err != nil {
 		return err
 	}
 
-	password, err := r.String()
+	password, err := br.String()
 	if err != nil {
 		return err
 	}
 
 	p.Seeds = seeds
-	p.Version = version
 	p.UID = uid
 	p.Username = username
 	p.Password = password
@@ -109,3 +153,12 @@ func (p *LoginRequest) Read(r *network.ProtocolReader) error {
 	p.CRCs = crcs
 	return nil
 }
+
+// SessionKey returns the server session key that the client embedded in its cipher seeds.
+func (p *LoginRequest) SessionKey() uint64 {
+	if len(p.Seeds) < 4 {
+		return 0
+	}
+
+	return (uint64(p.Seeds[2]) << 32) | uint64(p.Seeds[3])
+}
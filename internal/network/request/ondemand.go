@@ -0,0 +1,69 @@
+package request
+
+import "github.com/mbpolan/openmcs/internal/network"
+
+const OnDemandInitRequestHeader byte = 0x0F
+
+// OnDemandPriority indicates how urgently the client needs a file from the update server.
+type OnDemandPriority int
+
+const (
+	// OnDemandPriorityHigh is used for files the client needs immediately while the player is logged in.
+	OnDemandPriorityHigh OnDemandPriority = iota
+	// OnDemandPriorityMedium is used for files the client needs before the player has logged in.
+	OnDemandPriorityMedium
+	// OnDemandPriorityLow is used for files the client is prefetching in the background.
+	OnDemandPriorityLow
+)
+
+// OnDemandInitRequest is sent by the client when it opens a connection to the update server.
+type OnDemandInitRequest struct {
+}
+
+// Read parses the content of the request from a stream. If the data cannot be read, an error will be returned.
+func (p *OnDemandInitRequest) Read(r *network.ProtocolReader) error {
+	// read 1 byte for the header
+	_, err := r.Uint8()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// OnDemandFileRequest is sent by the client to request a file from the update server.
+type OnDemandFileRequest struct {
+	// CacheID is the index of the cache where the file is located.
+	CacheID int
+	// FileID is the identifier of the file within the cache.
+	FileID int
+	// Priority is how urgently the client needs the file.
+	Priority OnDemandPriority
+}
+
+// Read parses the content of the request from a stream. If the data cannot be read, an error will be returned.
+func (p *OnDemandFileRequest) Read(r *network.ProtocolReader) error {
+	// read 1 byte for the file type
+	fileType, err := r.Uint8()
+	if err != nil {
+		return err
+	}
+
+	// read 2 bytes for the file id
+	fileID, err := r.Uint16()
+	if err != nil {
+		return err
+	}
+
+	// read 1 byte for the priority
+	priority, err := r.Uint8()
+	if err != nil {
+		return err
+	}
+
+	// file types are offset by one from their cache index, since the main cache is never requested on-demand
+	p.CacheID = int(fileType) + 1
+	p.FileID = int(fileID)
+	p.Priority = OnDemandPriority(priority)
+	return nil
+}
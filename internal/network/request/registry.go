@@ -0,0 +1,190 @@
+package request
+
+import (
+	"github.com/mbpolan/openmcs/internal/network"
+)
+
+// PacketSize is the length of a packet's payload, excluding its header. Fixed-length packets have a non-negative size,
+// while variable-length packets use one of the PacketSizeVar* sentinel values.
+type PacketSize int
+
+const (
+	// PacketSizeVarByte indicates the payload length is sent as a single byte following the header.
+	PacketSizeVarByte PacketSize = -1
+	// PacketSizeVarShort indicates the payload length is sent as two bytes following the header.
+	PacketSizeVarShort PacketSize = -2
+)
+
+// Decoder creates an empty Request that can read a packet's contents from a stream.
+type Decoder func() Request
+
+// registryEntry is a decoder for packets with a particular opcode.
+type registryEntry struct {
+	opcode  byte
+	decoder Decoder
+}
+
+// Registry maps packet opcodes sent by a client to their sizes and, for packets understood by the server, their
+// decoders. Opcodes sent by the client can be mapped to a different opcode used by the server, which allows clients
+// of other revisions to reuse the same decoders and handlers.
+type Registry struct {
+	sizes   [256]PacketSize
+	entries map[byte]registryEntry
+}
+
+// packetSizes317 contains the payload size for each packet the 317 client may send.
+var packetSizes317 = [256]PacketSize{
+	0, 0, 0, 1, -1, 0, 0, 0, 0, 0, // 0
+	0, 0, 0, 0, 8, 0, 6, 2, 2, 0, // 10
+	0, 2, 0, 6, 0, 12, 0, 0, 0, 0, // 20
+	0, 0, 0, 0, 0, 8, 4, 0, 0, 2, // 30
+	2, 6, 0, 6, 0, -1, 0, 0, 0, 0, // 40
+	0, 0, 0, 12, 0, 0, 0, 8, 8, 12, // 50
+	8, 8, 0, 0, 0, 0, 0, 0, 0, 0, // 60
+	6, 0, 2, 2, 8, 6, 0, -1, 0, 6, // 70
+	0, 0, 0, 0, 0, 1, 4, 6, 0, 0, // 80
+	0, 0, 0, 0, 0, 3, 0, 0, -1, 0, // 90
+	0, 13, 0, -1, 0, 0, 0, 0, 0, 0, // 100
+	0, 0, 0, 0, 0, 0, 0, 6, 0, 0, // 110
+	1, 0, 6, 0, 0, 0, -1, 0, 2, 6, // 120
+	0, 4, 6, 8, 0, 6, 0, 0, 0, 2, // 130
+	0, 0, 0, 0, 0, 6, 0, 0, 0, 0, // 140
+	0, 0, 1, 2, 0, 2, 6, 0, 0, 0, // 150
+	0, 0, 0, 0, -1, -1, 0, 0, 0, 0, // 160
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // 170
+	0, 8, 0, 3, 0, 2, 0, 0, 8, 1, // 180
+	0, 0, 12, 0, 0, 0, 0, 0, 0, 0, // 190
+	2, 0, 0, 0, 0, 0, 0, 0, 4, 0, // 200
+	4, 0, 0, 0, 7, 8, 0, 0, 10, 0, // 210
+	0, 0, 0, 0, 0, 0, -1, 0, 6, 0, // 220
+	1, 0, 0, 0, 6, 0, 6, 8, 1, 0, // 230
+	0, 4, 0, 0, 0, 0, -1, 0, -1, 4, // 240
+	0, 0, 6, 6, 0, 0, // 250
+}
+
+// NewRegistry returns a Registry with packet sizes for the 317 client, and decoders for all requests supported by
+// the server.
+func NewRegistry() *Registry {
+	r := NewRegistryWithSizes(packetSizes317)
+
+	r.Register(KeepAliveRequestHeader, func() Request { return &KeepAliveRequest{} })
+	r.Register(FocusRequestHeader, func() Request { return &FocusChangeRequest{} })
+	r.Register(ClientClickRequestHeader, func() Request { return &ClientClickRequest{} })
+	r.Register(RegionChangeRequestHeader, func() Request { return &RegionChangeRequest{} })
+	r.Register(CameraModeRequestHeader, func() Request { return &CameraModeRequest{} })
+	r.Register(RegionLoadedRequestHeader, func() Request { return &RegionLoadedRequest{} })
+	r.Register(RegionResetRequestHeader, func() Request { return &RegionResetRequest{} })
+	r.Register(ReportRequestHeader, func() Request { return &ReportRequest{} })
+	r.Register(CloseInterfaceRequestHeader, func() Request { return &CloseInterfaceRequest{} })
+	r.Register(PlayerIdleRequestHeader, func() Request { return &PlayerIdleRequest{} })
+	r.Register(PlayerChatRequestHeader, func() Request { return &PlayerChatRequest{} })
+	r.Register(ChatCommandRequestHeader, func() Request { return &ChatCommandRequest{} })
+	r.Register(PrivateChatRequestHeader, func() Request { return &PrivateChatRequest{} })
+	r.Register(ChangeModesRequestHeader, func() Request { return &ChangeModesRequest{} })
+	r.Register(WalkRequestHeader, func() Request { return &WalkRequest{} })
+	r.Register(WalkOnCommandRequestHeader, func() Request { return &WalkRequest{} })
+	r.Register(WalkMinimap, func() Request { return &WalkRequest{} })
+	r.Register(TakeGroundItemRequestHeader, func() Request { return &TakeGroundItemRequest{} })
+	r.Register(DropInventoryItemRequestHeader, func() Request { return &DropInventoryItemRequest{} })
+	r.Register(SwapInventoryItemRequestHeader, func() Request { return &SwapInventoryItemRequest{} })
+	r.Register(EquipItemRequestHeader, func() Request { return &EquipItemRequest{} })
+	r.Register(UnequipItemRequestHeader, func() Request { return &UnequipItemRequest{} })
+	r.Register(UseItemRequestHeader, func() Request { return &UseItemRequest{} })
+	r.Register(UseInventoryItemsRequestHeader, func() Request { return &UseInventoryItemsRequest{} })
+	r.Register(AddFriendRequestHeader, func() Request { return &ModifyFriendRequest{} })
+	r.Register(RemoveFriendRequestHeader, func() Request { return &ModifyFriendRequest{} })
+	r.Register(AddIgnoreRequestHeader, func() Request { return &ModifyIgnoreRequest{} })
+	r.Register(RemoveIgnoreRequestHeader, func() Request { return &ModifyIgnoreRequest{} })
+	r.Register(InterfaceActionRequestHeader, func() Request { return &InterfaceActionRequest{} })
+	r.Register(InteractObjectRequestHeader, func() Request { return &InteractObjectRequest{} })
+	r.Register(CastSpellOnItemRequestHeader, func() Request { return &CastSpellOnItemRequest{} })
+	r.Register(CharacterDesignRequestHeader, func() Request { return &CharacterDesignRequest{} })
+	r.Register(AttackNPCRequestHeader, func() Request { return &AttackNPCRequest{} })
+	r.Register(InteractWithNPCAction1RequestHeader, func() Request { return &InteractWithNPCRequest{} })
+	r.Register(InteractWithNPCAction2RequestHeader, func() Request { return &InteractWithNPCRequest{} })
+	r.Register(InteractWithNPCAction3RequestHeader, func() Request { return &InteractWithNPCRequest{} })
+	r.Register(InteractWithNPCAction4RequestHeader, func() Request { return &InteractWithNPCRequest{} })
+
+	return r
+}
+
+// NewRegistryWithSizes returns an empty Registry with payload sizes for each packet opcode.
+func NewRegistryWithSizes(sizes [256]PacketSize) *Registry {
+	return &Registry{
+		sizes:   sizes,
+		entries: map[byte]registryEntry{},
+	}
+}
+
+// Register sets the decoder used for packets with an opcode.
+func (r *Registry) Register(opcode byte, decoder Decoder) {
+	r.RegisterAs(opcode, opcode, decoder)
+}
+
+// RegisterAs sets the decoder used for packets sent with the wire opcode. The packet is decoded and reported as if it
+// was sent with the server opcode instead.
+func (r *Registry) RegisterAs(wire byte, opcode byte, decoder Decoder) {
+	r.entries[wire] = registryEntry{
+		opcode:  opcode,
+		decoder: decoder,
+	}
+}
+
+// SetSize overrides the payload size expected for packets with an opcode.
+func (r *Registry) SetSize(opcode byte, size PacketSize) {
+	r.sizes[opcode] = size
+}
+
+// Size returns the payload size expected for packets with an opcode.
+func (r *Registry) Size(opcode byte) PacketSize {
+	return r.sizes[opcode]
+}
+
+// Decode reads the next packet from a stream, returning its server opcode and the decoded request. If no decoder is
+// registered for the opcode, the packet's payload is skipped based on its size, and a nil Request is returned instead.
+func (r *Registry) Decode(pr *network.ProtocolReader) (byte, Request, error) {
+	opcode, err := pr.Peek()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if entry, ok := r.entries[opcode]; ok {
+		// present the packet to the decoder using the server opcode
+		pr.ReplaceHeader(entry.opcode)
+
+		req := entry.decoder()
+		err = req.Read(pr)
+		if err != nil {
+			return entry.opcode, nil, err
+		}
+
+		return entry.opcode, req, nil
+	}
+
+	// consume the header and skip over the payload
+	_, err = pr.Uint8()
+	if err != nil {
+		return opcode, nil, err
+	}
+
+	size := int(r.sizes[opcode])
+	switch r.sizes[opcode] {
+	case PacketSizeVarByte:
+		n, err := pr.Uint8()
+		if err != nil {
+			return opcode, nil, err
+		}
+
+		size = int(n)
+
+	case PacketSizeVarShort:
+		n, err := pr.Uint16()
+		if err != nil {
+			return opcode, nil, err
+		}
+
+		size = int(n)
+	}
+
+	return opcode, nil, pr.Skip(size)
+}
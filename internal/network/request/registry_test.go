@@ -0,0 +1,94 @@
+package request
+
+import (
+	"bytes"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Registry_Decode_known(t *testing.T) {
+	r := network.NewReader(bytes.NewReader([]byte{FocusRequestHeader, 0x01}))
+
+	opcode, req, err := NewRegistry().Decode(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, FocusRequestHeader, opcode)
+	assert.IsType(t, &FocusChangeRequest{}, req)
+}
+
+func Test_Registry_Decode_skipsFixed(t *testing.T) {
+	// opcode 0x10 has a fixed payload of 6 bytes, followed by a keep-alive packet
+	r := network.NewReader(bytes.NewReader([]byte{0x10, 1, 2, 3, 4, 5, 6, KeepAliveRequestHeader}))
+	registry := NewRegistry()
+
+	opcode, req, err := registry.Decode(r)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x10), opcode)
+	assert.Nil(t, req)
+
+	opcode, req, err = registry.Decode(r)
+	assert.NoError(t, err)
+	assert.Equal(t, KeepAliveRequestHeader, opcode)
+	assert.IsType(t, &KeepAliveRequest{}, req)
+}
+
+func Test_Registry_Decode_skipsVarByte(t *testing.T) {
+	// opcode 0x2D has a variable payload with a one byte length, followed by a keep-alive packet
+	r := network.NewReader(bytes.NewReader([]byte{0x2D, 3, 1, 2, 3, KeepAliveRequestHeader}))
+	registry := NewRegistry()
+
+	_, req, err := registry.Decode(r)
+	assert.NoError(t, err)
+	assert.Nil(t, req)
+
+	opcode, _, err := registry.Decode(r)
+	assert.NoError(t, err)
+	assert.Equal(t, KeepAliveRequestHeader, opcode)
+}
+
+func Test_Registry_Decode_skipsVarShort(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetSize(0x10, PacketSizeVarShort)
+
+	r := network.NewReader(bytes.NewReader([]byte{0x10, 0x00, 0x02, 1, 2, KeepAliveRequestHeader}))
+
+	_, req, err := registry.Decode(r)
+	assert.NoError(t, err)
+	assert.Nil(t, req)
+
+	opcode, _, err := registry.Decode(r)
+	assert.NoError(t, err)
+	assert.Equal(t, KeepAliveRequestHeader, opcode)
+}
+
+func Test_Registry_Decode_registerAs(t *testing.T) {
+	registry := NewRegistryWithSizes([256]PacketSize{0x20: 1})
+	registry.RegisterAs(0x20, FocusRequestHeader, func() Request { return &FocusChangeRequest{} })
+
+	r := network.NewReader(bytes.NewReader([]byte{0x20, 0x01}))
+
+	opcode, req, err := registry.Decode(r)
+	assert.NoError(t, err)
+	assert.Equal(t, FocusRequestHeader, opcode)
+	assert.IsType(t, &FocusChangeRequest{}, req)
+}
+
+func Test_packetSizes317(t *testing.T) {
+	// item on npc, item on object and a few other packets sent with fixed sizes
+	sizes := map[int]PacketSize{
+		14:  8,
+		57:  8,
+		58:  8,
+		59:  12,
+		60:  8,
+		61:  8,
+		101: 13,
+		164: PacketSizeVarByte,
+		241: 4,
+	}
+
+	for opcode, size := range sizes {
+		assert.Equal(t, size, packetSizes317[opcode], "opcode %d", opcode)
+	}
+}
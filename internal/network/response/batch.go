@@ -24,8 +24,9 @@ func NewBatchResponse(playerRegionLocal model.Vector2D, responses []Response) *B
 
 // Write writes the contents of the message to a stream.
 func (p *BatchResponse) Write(w *network.ProtocolWriter) error {
-	// use a buffered writer since we need to compute the packet size
-	bw := network.NewBufferedWriter()
+	// use a buffered writer since we need to compute the packet size. batched responses are translated for the
+	// client's protocol revision the same way as standalone ones.
+	bw := w.Derive()
 
 	// write 1 byte for the player y-coordinate
 	err := bw.WriteUint8(byte(p.playerRegionLocal.Y))
@@ -41,7 +42,7 @@ func (p *BatchResponse) Write(w *network.ProtocolWriter) error {
 
 	// write each response to the buffered writer
 	for _, resp := range p.responses {
-		err := resp.Write(bw)
+		err := bw.WriteMessage(resp)
 		if err != nil {
 			return err
 		}
@@ -50,7 +51,7 @@ func (p *BatchResponse) Write(w *network.ProtocolWriter) error {
 	// write the packet to the stream now that its contents are complete
 
 	// write packet header
-	err = w.WriteUint8(BatchResponseHeader)
+	err = w.WriteHeader(BatchResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -19,7 +19,7 @@ func NewClearInventoryResponse(interfaceID int) *ClearInventoryResponse {
 // Write writes the contents of the message to a stream.
 func (p *ClearInventoryResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(ClearInventoryResponseHeader)
+	err := w.WriteHeader(ClearInventoryResponseHeader)
 	if err != nil {
 		return err
 	}
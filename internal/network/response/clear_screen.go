@@ -11,7 +11,7 @@ type ClearScreenResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *ClearScreenResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(ClearScreenResponseHeader)
+	err := w.WriteHeader(ClearScreenResponseHeader)
 	if err != nil {
 		return err
 	}
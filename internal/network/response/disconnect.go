@@ -0,0 +1,13 @@
+package response
+
+import "github.com/mbpolan/openmcs/internal/network"
+
+// DisconnectResponse is sent by the server to tell the client that a player has been forcefully logged out.
+type DisconnectResponse struct {
+}
+
+// Write writes the contents of the message to a stream.
+func (p *DisconnectResponse) Write(w *network.ProtocolWriter) error {
+	// write packet header
+	return w.WriteHeader(DisconnectResponseHeader)
+}
@@ -35,7 +35,7 @@ func NewOfflineFriendStatusResponse(username string) *FriendStatusResponse {
 // Write writes the contents of the message to a stream.
 func (p *FriendStatusResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(FriendStatusResponseHeader)
+	err := w.WriteHeader(FriendStatusResponseHeader)
 	if err != nil {
 		return err
 	}
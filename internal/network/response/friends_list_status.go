@@ -29,7 +29,7 @@ func NewFriendsListStatusResponse(status model.FriendsListStatus) *FriendsListSt
 // Write writes the contents of the message to a stream.
 func (p *FriendsListStatusResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(FriendsListStatusResponseHeader)
+	err := w.WriteHeader(FriendsListStatusResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -22,7 +22,7 @@ func NewIgnoredListResponse(usernames []string) *IgnoredListResponse {
 // Write writes the contents of the message to a stream.
 func (p *IgnoredListResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(IgnoredListResponseHeader)
+	err := w.WriteHeader(IgnoredListResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -65,6 +65,14 @@ func NewLoggedInInitResponse(playerType model.PlayerType, playerFlagged bool) *I
 	}
 }
 
+// NewReconnectedInitResponse creates a response confirming that a reconnecting player has been authenticated, and that
+// their client can continue where it left off.
+func NewReconnectedInitResponse() *InitResponse {
+	return &InitResponse{
+		code: initSuccessReset,
+	}
+}
+
 // NewFailedInitResponse creates a response indicating the player's login was rejected.
 func NewFailedInitResponse(code InitFailureCode) *InitResponse {
 	return &InitResponse{
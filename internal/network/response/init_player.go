@@ -13,7 +13,7 @@ type InitPlayerResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *InitPlayerResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(InitPlayerResponseHeader)
+	err := w.WriteHeader(InitPlayerResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -83,7 +83,7 @@ func (p *NPCUpdateResponse) Write(w *network.ProtocolWriter) error {
 	}
 
 	// write packet header
-	err = w.WriteUint8(NPCUpdateResponseHeader)
+	err = w.WriteHeader(NPCUpdateResponseHeader)
 	if err != nil {
 		return err
 	}
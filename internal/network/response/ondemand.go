@@ -0,0 +1,70 @@
+package response
+
+import "github.com/mbpolan/openmcs/internal/network"
+
+// OnDemandChunkSize is the maximum amount of file data sent in a single on-demand response.
+const OnDemandChunkSize = 500
+
+// OnDemandFileChunkResponse is sent by the update server with a portion of a requested file.
+type OnDemandFileChunkResponse struct {
+	cacheID   int
+	fileID    int
+	totalSize int
+	chunk     int
+	data      []byte
+}
+
+// NewOnDemandFileChunkResponse creates a response containing a chunk of a file. The totalSize is the size of the
+// entire file, and chunk is the zero-based index of the chunk contained in data.
+func NewOnDemandFileChunkResponse(cacheID, fileID, totalSize, chunk int, data []byte) *OnDemandFileChunkResponse {
+	return &OnDemandFileChunkResponse{
+		cacheID:   cacheID,
+		fileID:    fileID,
+		totalSize: totalSize,
+		chunk:     chunk,
+		data:      data,
+	}
+}
+
+// NewMissingOnDemandFileResponse creates a response indicating a requested file does not exist.
+func NewMissingOnDemandFileResponse(cacheID, fileID int) *OnDemandFileChunkResponse {
+	return &OnDemandFileChunkResponse{
+		cacheID: cacheID,
+		fileID:  fileID,
+	}
+}
+
+// Write writes the contents of the message to a stream.
+func (p *OnDemandFileChunkResponse) Write(w *network.ProtocolWriter) error {
+	// write 1 byte for the file type, which is offset by one from the cache index
+	err := w.WriteUint8(byte(p.cacheID - 1))
+	if err != nil {
+		return err
+	}
+
+	// write 2 bytes for the file id
+	err = w.WriteUint16(uint16(p.fileID))
+	if err != nil {
+		return err
+	}
+
+	// write 2 bytes for the total size of the file
+	err = w.WriteUint16(uint16(p.totalSize))
+	if err != nil {
+		return err
+	}
+
+	// write 1 byte for the chunk number
+	err = w.WriteUint8(byte(p.chunk))
+	if err != nil {
+		return err
+	}
+
+	// finally write the chunk data itself
+	_, err = w.Write(p.data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -15,7 +15,7 @@ type PlayMusicResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *PlayMusicResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(PlayMusicResponseHeader)
+	err := w.WriteHeader(PlayMusicResponseHeader)
 	if err != nil {
 		return err
 	}
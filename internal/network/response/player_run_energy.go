@@ -12,7 +12,7 @@ type PlayerRunEnergyResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *PlayerRunEnergyResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(PlayerRunEnergyResponseHeader)
+	err := w.WriteHeader(PlayerRunEnergyResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -310,7 +310,7 @@ func (p *PlayerUpdateResponse) Write(w *network.ProtocolWriter) error {
 	}
 
 	// write packet header
-	err = w.WriteUint8(PlayerUpdateResponseHeader)
+	err = w.WriteHeader(PlayerUpdateResponseHeader)
 	if err != nil {
 		return err
 	}
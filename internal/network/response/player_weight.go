@@ -12,7 +12,7 @@ type PlayerWeightResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *PlayerWeightResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(PlayerWeightResponseHeader)
+	err := w.WriteHeader(PlayerWeightResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -30,7 +30,7 @@ func NewPrivateChatResponse(id int, username string, senderType model.PlayerType
 // Write writes the contents of the message to a stream.
 func (p *PrivateChatResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(PrivateChatResponseHeader)
+	err := w.WriteHeader(PrivateChatResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -24,7 +24,7 @@ func NewRemoveGroundItemResponse(itemID int, positionRelative model.Vector2D) *R
 // Write writes the contents of the message to a stream.
 func (p *RemoveGroundItemResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(RemoveGroundItemResponseHeader)
+	err := w.WriteHeader(RemoveGroundItemResponseHeader)
 	if err != nil {
 		return err
 	}
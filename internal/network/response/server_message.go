@@ -19,7 +19,7 @@ func NewServerMessageResponse(message string) *ServerMessageResponse {
 // Write writes the contents of the message to a stream.
 func (p *ServerMessageResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(ServerMessageResponseHeader)
+	err := w.WriteHeader(ServerMessageResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -16,7 +16,7 @@ type SetInterfaceColorResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *SetInterfaceColorResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(SetInterfaceColorResponseHeader)
+	err := w.WriteHeader(SetInterfaceColorResponseHeader)
 	if err != nil {
 		return err
 	}
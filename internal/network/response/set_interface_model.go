@@ -14,7 +14,7 @@ type SetInterfaceModelResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *SetInterfaceModelResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(SetInterfaceModelResponseHeader)
+	err := w.WriteHeader(SetInterfaceModelResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -12,7 +12,7 @@ type SetInterfaceSettingResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *SetInterfaceSettingResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(SetInterfaceSettingResponseHeader)
+	err := w.WriteHeader(SetInterfaceSettingResponseHeader)
 	if err != nil {
 		return err
 	}
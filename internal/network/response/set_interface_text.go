@@ -36,7 +36,7 @@ func (p *SetInterfaceTextResponse) Write(w *network.ProtocolWriter) error {
 	}
 
 	// write packet header
-	err = w.WriteUint8(SetInterfaceTextResponseHeader)
+	err = w.WriteHeader(SetInterfaceTextResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -83,7 +83,7 @@ func (p *SetInventoryItemsResponse) Write(w *network.ProtocolWriter) error {
 	}
 
 	// write packet header
-	err = w.WriteUint8(SetInventoryItemsResponseHeader)
+	err = w.WriteHeader(SetInventoryItemsResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -27,7 +27,7 @@ func NewSetModesResponse(publicChat, privateChat model.ChatMode, interaction mod
 // Write writes the contents of the message to a stream.
 func (p *SetModesResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(SetModesResponseHeader)
+	err := w.WriteHeader(SetModesResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -26,7 +26,7 @@ func NewShowGroundItemResponse(itemID, stackSize int, positionRelative model.Vec
 // Write writes the contents of the message to a stream.
 func (p *ShowGroundItemResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(ShowGroundItemResponseHeader)
+	err := w.WriteHeader(ShowGroundItemResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -19,7 +19,7 @@ func NewShowInterfaceResponse(interfaceID int) *ShowInterfaceResponse {
 // Write writes the contents of the message to a stream.
 func (p *ShowInterfaceResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(ShowInterfaceResponseHeader)
+	err := w.WriteHeader(ShowInterfaceResponseHeader)
 	if err != nil {
 		return err
 	}
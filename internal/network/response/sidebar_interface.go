@@ -36,7 +36,7 @@ func NewRemoveSidebarInterfaceResponse(tab model.ClientTab) *SidebarInterfaceRes
 // Write writes the contents of the message to a stream.
 func (p *SidebarInterfaceResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(SidebarInterfaceResponseHeader)
+	err := w.WriteHeader(SidebarInterfaceResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -16,7 +16,7 @@ type SidebarTabResponse struct {
 // Write writes the contents of the message to a stream.
 func (p *SidebarTabResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(SidebarTabResponseHeader)
+	err := w.WriteHeader(SidebarTabResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -26,7 +26,7 @@ func NewSkillDataResponse(skill *model.Skill) *SkillDataResponse {
 // Write writes the contents of the message to a stream.
 func (p *SkillDataResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(SkillDataResponseHeader)
+	err := w.WriteHeader(SkillDataResponseHeader)
 	if err != nil {
 		return err
 	}
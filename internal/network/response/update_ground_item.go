@@ -28,7 +28,7 @@ func NewUpdateGroundItemResponse(itemID, oldStackSize, newStackSize int, positio
 // Write writes the contents of the message to a stream.
 func (p *UpdateGroundItemResponse) Write(w *network.ProtocolWriter) error {
 	// write packet header
-	err := w.WriteUint8(UpdateGroundItemResponseHeader)
+	err := w.WriteHeader(UpdateGroundItemResponseHeader)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+package network
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// rsaPEMType is the PEM block type used when persisting private keys.
+const rsaPEMType = "RSA PRIVATE KEY"
+
+// LoadRSAPrivateKey reads a PEM-encoded RSA private key from a file. Both PKCS#1 and PKCS#8 encodings are accepted.
+func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	// prefer pkcs#1 keys, but fall back to pkcs#8 keys in case the key was generated by another tool
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key in %s is not an RSA private key", path)
+	}
+
+	return key, nil
+}
+
+// GenerateRSAPrivateKey creates a new RSA private key with a given size in bits, and writes it to a file in PEM format.
+func GenerateRSAPrivateKey(path string, bits int) (*rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:  rsaPEMType,
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// DecryptRSABlock decrypts a block of data that was encrypted by the client using raw (unpadded) RSA.
+func DecryptRSABlock(key *rsa.PrivateKey, data []byte) []byte {
+	c := new(big.Int).SetBytes(data)
+	m := new(big.Int).Exp(c, key.D, key.N)
+
+	return m.Bytes()
+}
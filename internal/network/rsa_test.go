@@ -0,0 +1,24 @@
+package network
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func Test_DecryptRSABlock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+
+	plaintext := []byte{0x0A, 0x01, 0x02, 0x03, 0x04}
+
+	// encrypt the block the same way the client does, without any padding
+	m := new(big.Int).SetBytes(plaintext)
+	c := new(big.Int).Exp(m, big.NewInt(int64(key.E)), key.N)
+
+	decrypted := DecryptRSABlock(key, c.Bytes())
+
+	assert.Equal(t, plaintext, decrypted)
+}
@@ -0,0 +1,172 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// webSocketFlushDelay is the amount of time written data is buffered before it's sent to the client as a single frame.
+const webSocketFlushDelay = 5 * time.Millisecond
+
+// webSocketMaxBuffer is the amount of buffered data that causes a frame to be sent immediately.
+const webSocketMaxBuffer = 16 * 1024
+
+// WebSocketConn adapts a WebSocket connection to a net.Conn, so that clients connecting over WebSocket can be served
+// the same way as those connecting over TCP. Binary messages sent by the client are read as one continuous stream of
+// bytes. Data written to the connection is buffered for a short time and sent to the client in binary messages, since
+// packets are written in small increments.
+type WebSocketConn struct {
+	buffer     bytes.Buffer
+	closed     bool
+	conn       *websocket.Conn
+	flushTimer *time.Timer
+	mu         sync.Mutex
+	reader     io.Reader
+	writeErr   error
+}
+
+// NewWebSocketConn returns a connection that reads and writes binary messages over a WebSocket connection.
+func NewWebSocketConn(conn *websocket.Conn) *WebSocketConn {
+	return &WebSocketConn{
+		conn: conn,
+	}
+}
+
+// Read reads data sent by the client, continuing onto the next message once the current one is exhausted.
+func (c *WebSocketConn) Read(b []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			messageType, r, err := c.conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+
+			if messageType != websocket.BinaryMessage {
+				return 0, fmt.Errorf("unexpected websocket message type: %d", messageType)
+			}
+
+			c.reader = r
+		}
+
+		n, err := c.reader.Read(b)
+		if err == io.EOF {
+			c.reader = nil
+
+			// an empty read is not allowed to return without an error, so move on to the next message
+			if n == 0 {
+				continue
+			}
+
+			return n, nil
+		}
+
+		return n, err
+	}
+}
+
+// Write buffers data to be sent to the client.
+func (c *WebSocketConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	// report errors that occurred while sending previously buffered data
+	if c.writeErr != nil {
+		return 0, c.writeErr
+	}
+
+	c.buffer.Write(b)
+
+	if c.buffer.Len() >= webSocketMaxBuffer {
+		return len(b), c.flush()
+	}
+
+	// schedule the buffer to be sent if this is the first write since the last flush
+	if c.flushTimer == nil {
+		c.flushTimer = time.AfterFunc(webSocketFlushDelay, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			_ = c.flush()
+		})
+	}
+
+	return len(b), nil
+}
+
+// Close sends any buffered data and closes the connection.
+func (c *WebSocketConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	_ = c.flush()
+	c.closed = true
+
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+
+	return c.conn.Close()
+}
+
+// LocalAddr returns the local network address.
+func (c *WebSocketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address.
+func (c *WebSocketConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines for the connection.
+func (c *WebSocketConn) SetDeadline(t time.Time) error {
+	err := c.conn.SetReadDeadline(t)
+	if err != nil {
+		return err
+	}
+
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future Read calls.
+func (c *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for sending data to the client.
+func (c *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// flush sends all buffered data to the client as a single binary message. The caller must hold the lock.
+func (c *WebSocketConn) flush() error {
+	if c.flushTimer != nil {
+		c.flushTimer.Stop()
+		c.flushTimer = nil
+	}
+
+	if c.closed || c.writeErr != nil || c.buffer.Len() == 0 {
+		return c.writeErr
+	}
+
+	err := c.conn.WriteMessage(websocket.BinaryMessage, c.buffer.Bytes())
+	c.buffer.Reset()
+
+	if err != nil {
+		c.writeErr = err
+	}
+
+	return err
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_WebSocketConn(t *testing.T) {
+	connChan := make(chan *WebSocketConn, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		connChan <- NewWebSocketConn(conn)
+	}))
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer client.Close()
+	conn := <-connChan
+
+	// messages sent by the client are read as a single stream
+	assert.NoError(t, client.WriteMessage(websocket.BinaryMessage, []byte{1, 2}))
+	assert.NoError(t, client.WriteMessage(websocket.BinaryMessage, []byte{3}))
+
+	r := NewReader(conn)
+	data := make([]byte, 3)
+	_, err = io.ReadFull(r, data)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+
+	// individual writes are sent together in a single message
+	w := NewWriter(conn)
+	assert.NoError(t, w.WriteUint8(4))
+	assert.NoError(t, w.WriteUint16(0x0506))
+
+	messageType, msg, err := client.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+	assert.Equal(t, []byte{4, 5, 6}, msg)
+
+	assert.NoError(t, conn.Close())
+}
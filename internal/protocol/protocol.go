@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/request"
+)
+
+// Revision describes the wire protocol spoken by a particular version of the game client. Requests and responses
+// exchanged with the game engine always use the server's opcodes and structures, and a revision translates them to
+// and from the opcodes and field encodings its client expects.
+type Revision interface {
+	network.Encoder
+
+	// Version returns the client version that uses this revision of the protocol.
+	Version() int
+	// ReadLogin parses the rest of a login request sent by the client, following the header that was read by
+	// request.LoginRequest.ReadHeader.
+	ReadLogin(r *network.ProtocolReader, req *request.LoginRequest) error
+	// Requests returns a registry that decodes packets sent by the client into server requests.
+	Requests() *request.Registry
+	// ResponseOpcodes returns a mapping of server response opcodes to the opcodes expected by the client. Responses
+	// whose opcodes are not present in the map are sent as-is. A nil map can be returned if no opcodes differ.
+	ResponseOpcodes() map[byte]byte
+}
+
+// Set is a collection of revisions supported by the server, keyed by their client version.
+type Set map[int]Revision
+
+// NewSet returns a set containing the given revisions.
+func NewSet(revisions ...Revision) Set {
+	s := Set{}
+	for _, r := range revisions {
+		s[r.Version()] = r
+	}
+
+	return s
+}
+
+// Lookup returns the revision used by a client version, or nil if the version is not supported.
+func (s Set) Lookup(version int) Revision {
+	return s[version]
+}
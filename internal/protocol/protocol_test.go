@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/request"
+	"github.com/mbpolan/openmcs/internal/network/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// mockRevision is a revision whose client expects ground items to be shown with a different opcode.
+type mockRevision struct {
+	encoded []network.Message
+}
+
+func (r *mockRevision) Version() int {
+	return 1
+}
+
+func (r *mockRevision) ReadLogin(pr *network.ProtocolReader, req *request.LoginRequest) error {
+	return req.ReadBody(pr, request.NumLoginCRCs)
+}
+
+func (r *mockRevision) Requests() *request.Registry {
+	return request.NewRegistry()
+}
+
+func (r *mockRevision) ResponseOpcodes() map[byte]byte {
+	return map[byte]byte{response.ShowGroundItemResponseHeader: 0x55}
+}
+
+func (r *mockRevision) Encode(w *network.ProtocolWriter, m network.Message) error {
+	r.encoded = append(r.encoded, m)
+	return m.Write(w)
+}
+
+func Test_Revision_batchedResponses(t *testing.T) {
+	var revision Revision = &mockRevision{}
+
+	w := network.NewBufferedWriter()
+	w.SetCipher(network.NewISAACCipher([]uint32{1, 2, 3, 4}))
+	w.SetOpcodeMap(revision.ResponseOpcodes())
+	w.SetEncoder(revision)
+
+	item := response.NewShowGroundItemResponse(995, 10, model.Vector2D{X: 1, Y: 2})
+	batch := response.NewBatchResponse(model.Vector2D{X: 8, Y: 8}, []response.Response{item})
+	require.NoError(t, w.WriteMessage(batch))
+
+	// batched responses are passed through the revision's encoder
+	assert.Equal(t, []network.Message{batch, item}, revision.(*mockRevision).encoded)
+
+	// the batched response uses the revision's opcode, and only the batch header itself is encoded with the cipher
+	buf, err := w.Buffer()
+	require.NoError(t, err)
+	payload := buf.Bytes()[3:]
+	require.Len(t, payload, 8)
+	assert.Equal(t, byte(0x55), payload[2])
+}
@@ -0,0 +1,47 @@
+package r317
+
+import (
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/request"
+)
+
+// Version is the client version that uses this revision.
+const Version = 317
+
+// Revision is the 317 revision of the protocol. The server's requests and responses are modelled after this revision,
+// so no translation is necessary.
+type Revision struct {
+	requests *request.Registry
+}
+
+// New returns the 317 protocol revision.
+func New() *Revision {
+	return &Revision{
+		requests: request.NewRegistry(),
+	}
+}
+
+// Version returns the client version that uses this revision of the protocol.
+func (r *Revision) Version() int {
+	return Version
+}
+
+// ReadLogin parses the rest of a login request sent by the client.
+func (r *Revision) ReadLogin(pr *network.ProtocolReader, req *request.LoginRequest) error {
+	return req.ReadBody(pr, request.NumLoginCRCs)
+}
+
+// Requests returns a registry that decodes packets sent by the client into server requests.
+func (r *Revision) Requests() *request.Registry {
+	return r.requests
+}
+
+// ResponseOpcodes returns a mapping of server response opcodes to the opcodes expected by the client.
+func (r *Revision) ResponseOpcodes() map[byte]byte {
+	return nil
+}
+
+// Encode writes a message to a stream.
+func (r *Revision) Encode(w *network.ProtocolWriter, m network.Message) error {
+	return m.Write(w)
+}
@@ -0,0 +1,73 @@
+package recording
+
+import "bytes"
+
+// Difference is a mismatch between an expected and an actual packet at the same position in a sequence of packets.
+type Difference struct {
+	// Index is the position of the packets in their respective sequences.
+	Index int
+	// Expected is the expected packet, or nil if the actual sequence contained more packets.
+	Expected *Packet
+	// Actual is the actual packet, or nil if the actual sequence contained fewer packets.
+	Actual *Packet
+	// Offset is the first byte in the payload that differs, or -1 if the opcodes differ or either packet is missing.
+	Offset int
+}
+
+// Filter returns all packets in the session that were sent in a direction.
+func (s *Session) Filter(direction Direction) []Packet {
+	var packets []Packet
+	for _, p := range s.Packets {
+		if p.Direction == direction {
+			packets = append(packets, p)
+		}
+	}
+
+	return packets
+}
+
+// Diff compares two sequences of packets by their opcodes and payloads, ignoring the ticks they were recorded on.
+func Diff(expected, actual []Packet) []Difference {
+	var diffs []Difference
+
+	for i := 0; i < max(len(expected), len(actual)); i++ {
+		diff := Difference{
+			Index:  i,
+			Offset: -1,
+		}
+
+		if i < len(expected) {
+			diff.Expected = &expected[i]
+		}
+
+		if i < len(actual) {
+			diff.Actual = &actual[i]
+		}
+
+		// both packets are present, so compare their contents
+		if diff.Expected != nil && diff.Actual != nil {
+			if diff.Expected.Opcode == diff.Actual.Opcode && bytes.Equal(diff.Expected.Payload, diff.Actual.Payload) {
+				continue
+			}
+
+			if diff.Expected.Opcode == diff.Actual.Opcode {
+				diff.Offset = firstMismatch(diff.Expected.Payload, diff.Actual.Payload)
+			}
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
+
+// firstMismatch returns the index of the first byte that differs between two slices.
+func firstMismatch(a, b []byte) int {
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+
+	return min(len(a), len(b))
+}
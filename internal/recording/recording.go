@@ -0,0 +1,226 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/pkg/errors"
+	"io"
+	"sync"
+)
+
+// magic identifies a file as a session recording.
+var magic = []byte("OMCSREC")
+
+// version is the current version of the recording file format.
+const version uint8 = 1
+
+// flagLowMemory is set in the header flags when the player's client is running in low-memory mode.
+const flagLowMemory byte = 0x01
+
+// Direction indicates if a packet was sent by the client or by the server.
+type Direction uint8
+
+const (
+	// Inbound packets are sent by the client to the server.
+	Inbound Direction = iota
+	// Outbound packets are sent by the server to the client.
+	Outbound
+)
+
+// Packet is a single packet exchanged between a client and the server.
+type Packet struct {
+	// Direction indicates which side sent the packet.
+	Direction Direction
+	// Tick is the game tick, relative to the start of the session, when the packet was recorded.
+	Tick uint64
+	// Opcode is the decoded packet header.
+	Opcode byte
+	// Payload is the packet content following the header.
+	Payload []byte
+}
+
+// Session is a recorded session for a single player.
+type Session struct {
+	// Player is the state of the player when the session started.
+	Player *model.Player
+	// LowMemory is true if the player's client was running in low-memory mode.
+	LowMemory bool
+	// Packets are all packets exchanged during the session, in the order they were recorded.
+	Packets []Packet
+}
+
+// Recorder writes packets exchanged during a player's session to a stream.
+type Recorder struct {
+	closed bool
+	err    error
+	mu     sync.Mutex
+	start  uint64
+	tick   func() uint64
+	w      *bufio.Writer
+	closer io.Closer
+}
+
+// NewRecorder creates a recorder that writes a session for a player to w. The lowMemory flag indicates if the player's
+// client is running in low-memory mode. The tick function should return the current game tick, and packets are
+// recorded with ticks relative to when the recorder was created. If w is also an io.Closer, it will be closed along
+// with the recorder. The player's password hash and network address are not included in the recording.
+func NewRecorder(w io.Writer, player *model.Player, lowMemory bool, tick func() uint64) (*Recorder, error) {
+	// recordings are shared for debugging, so leave out anything that identifies the player's account or connection
+	p := *player
+	p.PasswordHash = ""
+	p.Address = ""
+
+	snapshot, err := json.Marshal(&p)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode player")
+	}
+
+	r := &Recorder{
+		start: tick(),
+		tick:  tick,
+		w:     bufio.NewWriter(w),
+	}
+
+	if closer, ok := w.(io.Closer); ok {
+		r.closer = closer
+	}
+
+	var flags byte
+	if lowMemory {
+		flags |= flagLowMemory
+	}
+
+	// write the file header followed by a snapshot of the player
+	r.write(magic)
+	r.write([]byte{version, flags})
+	r.write(binary.BigEndian.AppendUint32(nil, uint32(len(snapshot))))
+	r.write(snapshot)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return r, nil
+}
+
+// Record writes a packet to the recording. Packets recorded after the recorder is closed are ignored. If a packet
+// cannot be written, the error is retained and returned when the recorder is closed.
+func (r *Recorder) Record(direction Direction, opcode byte, payload []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	header := make([]byte, 0, 14)
+	header = append(header, byte(direction))
+	header = binary.BigEndian.AppendUint64(header, r.tick()-r.start)
+	header = append(header, opcode)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(payload)))
+
+	r.write(header)
+	r.write(payload)
+}
+
+// Close flushes all recorded packets and releases the underlying stream.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	r.closed = true
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+
+	if r.closer != nil {
+		err := r.closer.Close()
+		if r.err == nil {
+			r.err = err
+		}
+	}
+
+	return r.err
+}
+
+// write writes data to the stream, unless a previous write has already failed.
+func (r *Recorder) write(data []byte) {
+	if r.err != nil {
+		return
+	}
+
+	_, r.err = r.w.Write(data)
+}
+
+// Read parses a recorded session from a stream.
+func Read(r io.Reader) (*Session, error) {
+	br := bufio.NewReader(r)
+
+	// validate the file header
+	header := make([]byte, len(magic)+2)
+	_, err := io.ReadFull(br, header)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read header")
+	}
+
+	if string(header[:len(magic)]) != string(magic) {
+		return nil, fmt.Errorf("not a session recording")
+	}
+
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("unsupported recording version: %d", header[len(magic)])
+	}
+
+	// read the player snapshot
+	var size uint32
+	err = binary.Read(br, binary.BigEndian, &size)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read player snapshot size")
+	}
+
+	snapshot := make([]byte, size)
+	_, err = io.ReadFull(br, snapshot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read player snapshot")
+	}
+
+	session := &Session{
+		LowMemory: header[len(magic)+1]&flagLowMemory != 0,
+	}
+	err = json.Unmarshal(snapshot, &session.Player)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode player snapshot")
+	}
+
+	// read packets until the end of the stream
+	for {
+		packetHeader := make([]byte, 14)
+		_, err := io.ReadFull(br, packetHeader)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to read packet header")
+		}
+
+		payload := make([]byte, binary.BigEndian.Uint32(packetHeader[10:]))
+		_, err = io.ReadFull(br, payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read packet payload")
+		}
+
+		session.Packets = append(session.Packets, Packet{
+			Direction: Direction(packetHeader[0]),
+			Tick:      binary.BigEndian.Uint64(packetHeader[1:]),
+			Opcode:    packetHeader[9],
+			Payload:   payload,
+		})
+	}
+
+	return session, nil
+}
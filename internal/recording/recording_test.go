@@ -0,0 +1,58 @@
+package recording
+
+import (
+	"bytes"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Recorder_Read(t *testing.T) {
+	tick := uint64(10)
+	buf := &bytes.Buffer{}
+	player := model.NewPlayer("mike")
+	player.PasswordHash = "hash"
+	player.Address = "127.0.0.1"
+
+	r, err := NewRecorder(buf, player, true, func() uint64 { return tick })
+	assert.NoError(t, err)
+
+	r.Record(Inbound, 0x04, []byte{1, 2, 3})
+	tick = 12
+	r.Record(Outbound, 0x51, nil)
+	assert.NoError(t, r.Close())
+
+	session, err := Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "mike", session.Player.Username)
+	assert.Empty(t, session.Player.PasswordHash)
+	assert.Empty(t, session.Player.Address)
+	assert.Equal(t, "hash", player.PasswordHash)
+	assert.True(t, session.LowMemory)
+	assert.Equal(t, []Packet{
+		{Direction: Inbound, Tick: 0, Opcode: 0x04, Payload: []byte{1, 2, 3}},
+		{Direction: Outbound, Tick: 2, Opcode: 0x51, Payload: []byte{}},
+	}, session.Packets)
+}
+
+func Test_Diff(t *testing.T) {
+	expected := []Packet{
+		{Opcode: 0x01, Payload: []byte{1, 2}},
+		{Opcode: 0x02, Payload: []byte{1, 2, 3}},
+		{Opcode: 0x03},
+	}
+
+	actual := []Packet{
+		{Opcode: 0x01, Payload: []byte{1, 2}, Tick: 5},
+		{Opcode: 0x02, Payload: []byte{1, 9, 3}},
+	}
+
+	diffs := Diff(expected, actual)
+
+	assert.Len(t, diffs, 2)
+	assert.Equal(t, 1, diffs[0].Index)
+	assert.Equal(t, 1, diffs[0].Offset)
+	assert.Equal(t, 2, diffs[1].Index)
+	assert.Nil(t, diffs[1].Actual)
+	assert.Equal(t, -1, diffs[1].Offset)
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/game"
+	"github.com/mbpolan/openmcs/internal/logger"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/store/playerdata"
+	"github.com/mbpolan/openmcs/internal/telemetry"
+	"sync"
+	"time"
+)
+
+// SaveReason describes why a player's persistent data was saved.
+type SaveReason string
+
+const (
+	// SaveReasonLogout indicates a player was saved when their client disconnected.
+	SaveReasonLogout SaveReason = "logout"
+	// SaveReasonAutosave indicates a player was saved by a periodic autosave.
+	SaveReasonAutosave SaveReason = "autosave"
+	// SaveReasonShutdown indicates a player was saved when the server stopped.
+	SaveReasonShutdown SaveReason = "shutdown"
+)
+
+// AutosaverOptions contains parameters to configure an Autosaver instance.
+type AutosaverOptions struct {
+	// Game is the game engine whose players and world state are saved.
+	Game *game.Game
+	// History takes a snapshot of each player after they are saved, and applies rollbacks scheduled for players when
+	// they log in. If nil, no snapshots are taken.
+	History *playerdata.History
+	// Interval is how often players are saved. If zero, players are only saved when they disconnect and when the
+	// server stops.
+	Interval time.Duration
+	// PersistWorldState enables saving the world state, such as ground items, along with players.
+	PersistWorldState bool
+	// Store is the persistent store that data is saved to.
+	Store *store.Store
+	// Telemetry records how long saves take.
+	Telemetry telemetry.Telemetry
+	// WorldID is the identifier of the game world whose state is saved.
+	WorldID int
+}
+
+// Autosaver periodically saves all players in the game world to the persistent store, and saves them one last time
+// when the server stops. All player saves go through an Autosaver so that an older snapshot of a player is never
+// written over a newer one. If enabled, the state of the world map is saved alongside players.
+type Autosaver struct {
+	doneChan          chan bool
+	game              *game.Game
+	history           *playerdata.History
+	interval          time.Duration
+	mu                sync.Mutex
+	persistWorldState bool
+	store             *store.Store
+	telemetry         telemetry.Telemetry
+	wg                sync.WaitGroup
+	worldID           int
+}
+
+// NewAutosaver creates an autosaver that saves players at an interval.
+func NewAutosaver(opts AutosaverOptions) *Autosaver {
+	return &Autosaver{
+		doneChan:          make(chan bool, 1),
+		game:              opts.Game,
+		history:           opts.History,
+		interval:          opts.Interval,
+		persistWorldState: opts.PersistWorldState,
+		store:             opts.Store,
+		telemetry:         opts.Telemetry,
+		worldID:           opts.WorldID,
+	}
+}
+
+// Start begins periodically saving players. When cleaning up, Stop() should be called.
+func (a *Autosaver) Start() {
+	if a.interval <= 0 {
+		logger.Warnf("autosave is disabled; players are only saved when they log out")
+		return
+	}
+
+	a.wg.Add(1)
+	go a.loop()
+}
+
+// Stop terminates periodic saves, and blocks until all players in the game world have been saved.
+func (a *Autosaver) Stop() {
+	if a.interval > 0 {
+		a.doneChan <- true
+		a.wg.Wait()
+	}
+
+	start := time.Now()
+	saved, failed := a.SaveAll(SaveReasonShutdown)
+	logger.Infof("saved %d players (%d failed) on shutdown in %s", saved, failed, time.Now().Sub(start))
+
+	a.SaveWorldState()
+}
+
+// SaveAll saves a snapshot of each player in the game world, returning the number of players that were saved and the
+// number of players that could not be saved.
+func (a *Autosaver) SaveAll(reason SaveReason) (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	saved, failed := 0, 0
+	for _, p := range a.game.SnapshotPlayers() {
+		if a.save(p, reason) {
+			saved++
+		} else {
+			failed++
+		}
+	}
+
+	return saved, failed
+}
+
+// SavePlayer saves a snapshot of a single player. If the player is no longer in the game world, nothing else modifies
+// their data and it is saved as-is.
+func (a *Autosaver) SavePlayer(p *model.Player, reason SaveReason) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	snapshot := a.game.SnapshotPlayer(p)
+	if snapshot == nil {
+		snapshot = p.Snapshot()
+	}
+
+	return a.save(snapshot, reason)
+}
+
+// ApplyPendingRollback rolls back a player who is about to be added to the game world, if a rollback was scheduled
+// for them, and returns the player as they should be added. If the rollback could not be applied, the player's data
+// is left as it is.
+func (a *Autosaver) ApplyPendingRollback(p *model.Player) (*model.Player, error) {
+	if a.history == nil {
+		return p, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	snapshot, err := a.history.ApplyPending(p)
+	if err != nil {
+		logger.Errorf("failed to roll back player %d: %s", p.ID, err)
+		return p, nil
+	} else if snapshot == nil {
+		return p, nil
+	}
+
+	logger.Infof("rolled back player %d to snapshot %d", p.ID, snapshot.ID)
+
+	// the player's data was replaced, so it needs to be loaded again
+	restored, err := a.store.LoadPlayer(p.Username)
+	if err != nil {
+		return nil, err
+	} else if restored == nil {
+		return nil, fmt.Errorf("player %s no longer exists", p.Username)
+	}
+
+	return restored, nil
+}
+
+// SaveWorldState saves the state of the world map, such as ground items, if world state persistence is enabled.
+// Returns false if the save failed.
+func (a *Autosaver) SaveWorldState() bool {
+	if !a.persistWorldState {
+		return true
+	}
+
+	start := time.Now()
+	state := a.game.SnapshotWorldState()
+
+	err := a.store.SaveWorldState(a.worldID, state)
+	if err != nil {
+		logger.Errorf("failed to save world state: %s", err)
+		return false
+	}
+
+	logger.Debugf("saved %d ground items in %s", len(state.GroundItems), time.Now().Sub(start))
+	return true
+}
+
+// loop saves all players each time the autosave interval elapses.
+func (a *Autosaver) loop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.doneChan:
+			return
+		case <-ticker.C:
+			start := time.Now()
+			saved, failed := a.SaveAll(SaveReasonAutosave)
+			logger.Debugf("autosaved %d players (%d failed) in %s", saved, failed, time.Now().Sub(start))
+
+			a.SaveWorldState()
+		}
+	}
+}
+
+// save writes a player to the persistent store and records how long it took, returning false if the save failed. A
+// snapshot of the player is taken after each successful save.
+// Concurrency requirements: (a) the autosaver should be locked.
+func (a *Autosaver) save(p *model.Player, reason SaveReason) bool {
+	start := time.Now()
+
+	err := a.store.SavePlayer(p)
+	if err != nil {
+		logger.Errorf("failed to save player %d (%s): %s", p.ID, reason, err)
+		a.telemetry.RecordPlayerSaveFailed(string(reason))
+		return false
+	}
+
+	a.telemetry.RecordPlayerSaved(string(reason), float64(time.Now().Sub(start).Nanoseconds()))
+
+	// the player's data is already saved, so a missing snapshot does not fail the save
+	if a.history != nil {
+		err = a.history.Record(p)
+		if err != nil {
+			logger.Errorf("failed to take snapshot of player %d (%s): %s", p.ID, reason, err)
+		}
+	}
+
+	return true
+}
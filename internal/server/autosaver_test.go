@@ -0,0 +1,46 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/store/driver"
+	"github.com/mbpolan/openmcs/internal/store/playerdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Autosaver_ApplyPendingRollback(t *testing.T) {
+	d, err := driver.NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+	require.NoError(t, d.CreatePlayer(model.NewPlayer("Mike")))
+
+	history := playerdata.NewHistory(d, 5)
+	a := NewAutosaver(AutosaverOptions{
+		History: history,
+		Store:   store.NewWithDriver(&config.Config{}, d),
+	})
+
+	p, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	p.RunEnergy = 50
+	require.NoError(t, d.SavePlayer(p))
+	require.NoError(t, history.Record(p))
+
+	// players are added to the game world as they are if they have no pending rollback
+	p.RunEnergy = 10
+	require.NoError(t, d.SavePlayer(p))
+
+	loaded, err := a.ApplyPendingRollback(p)
+	require.NoError(t, err)
+	assert.Same(t, p, loaded)
+
+	_, err = history.Schedule("Mike", 1)
+	require.NoError(t, err)
+
+	loaded, err = a.ApplyPendingRollback(p)
+	require.NoError(t, err)
+	assert.Equal(t, p.ID, loaded.ID)
+	assert.Equal(t, 50, loaded.RunEnergy)
+}
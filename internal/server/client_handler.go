@@ -1,26 +1,30 @@
 package server
 
 import (
-	"crypto/sha512"
-	"encoding/hex"
+	"crypto/rsa"
 	"fmt"
+	"github.com/mbpolan/openmcs/internal/asset"
+	"github.com/mbpolan/openmcs/internal/auth"
+	"github.com/mbpolan/openmcs/internal/config"
 	"github.com/mbpolan/openmcs/internal/game"
 	"github.com/mbpolan/openmcs/internal/logger"
 	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/moderation"
 	"github.com/mbpolan/openmcs/internal/network"
 	"github.com/mbpolan/openmcs/internal/network/request"
 	"github.com/mbpolan/openmcs/internal/network/response"
+	"github.com/mbpolan/openmcs/internal/protocol"
+	"github.com/mbpolan/openmcs/internal/recording"
 	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/telemetry"
 	"github.com/pkg/errors"
 	"io"
 	"net"
-	"strings"
+	"os"
+	"path/filepath"
 	"time"
 )
 
-// clientVersion is the client version that is supported by the server.
-const clientVersion = 317
-
 // clientState is an enumeration of the various states a player's connection may be in.
 type clientState int
 
@@ -28,35 +32,107 @@ const (
 	initializing clientState = iota
 	loggingIn
 	active
+	onDemand
 	failed
 )
 
+// ClientHandlerOptions contains parameters to configure a ClientHandler instance.
+type ClientHandlerOptions struct {
+	// Accounts creates accounts for players that log in with an unknown username, if AutoRegister is enabled.
+	Accounts *auth.AccountManager
+	// Assets is the asset manager used to serve game cache files.
+	Assets *asset.Manager
+	// Autosaver saves the player's persistent data when the client disconnects.
+	Autosaver *Autosaver
+	// AutoRegister enables creating a new account when a player logs in with an unknown username.
+	AutoRegister bool
+	// Bans determines if a player is banned from logging in.
+	Bans *moderation.BanList
+	// CloseChan receives the handler once it terminates, to indicate its work is complete.
+	CloseChan chan *ClientHandler
+	// Game is the game engine players are added to once they log in.
+	Game *game.Game
+	// LoginThrottle tracks failed login attempts and locks out clients that make too many of them.
+	LoginThrottle *LoginThrottle
+	// PasswordHasher verifies player passwords, and rehashes passwords whose hashes are outdated.
+	PasswordHasher auth.PasswordHasher
+	// PlayerLocks serializes loading a player when they log in with saving them when they log out.
+	PlayerLocks *PlayerLocks
+	// PrivateKey is an optional RSA key used to decrypt login requests. If nil, login requests are expected to be
+	// sent without rsa encryption.
+	PrivateKey *rsa.PrivateKey
+	// RateLimit contains the packet rate limits enforced on the client once it has logged in.
+	RateLimit config.RateLimitConfig
+	// RecordingDir is an optional directory where the player's session is recorded.
+	RecordingDir string
+	// Revisions are the protocol revisions of clients that are allowed to connect.
+	Revisions protocol.Set
+	// SessionKey is the unique key issued to the client connection.
+	SessionKey uint64
+	// Store is the persistent store for player data.
+	Store *store.Store
+	// Telemetry is the metrics provider used to instrument the client connection.
+	Telemetry telemetry.Telemetry
+}
+
 // ClientHandler is responsible for managing the state and communications for a single client.
 type ClientHandler struct {
+	accounts      *auth.AccountManager
+	assets        *asset.Manager
+	autosaver     *Autosaver
+	autoRegister  bool
+	bans          *moderation.BanList
 	conn          net.Conn
 	game          *game.Game
 	reader        *network.ProtocolReader
 	writer        *network.ProtocolWriter
 	closeChan     chan *ClientHandler
 	lastHeartbeat time.Time
+	loginThrottle *LoginThrottle
+	hasher        auth.PasswordHasher
 	player        *model.Player
+	playerLocks   *PlayerLocks
+	privateKey    *rsa.PrivateKey
+	rateLimiter   *RateLimiter
+	recorder      *recording.Recorder
+	recordingDir  string
+	revision      protocol.Revision
+	revisions     protocol.Set
 	store         *store.Store
 	sessionKey    uint64
 	state         clientState
+	telemetry     telemetry.Telemetry
 }
 
-// NewClientHandler returns a new handler for a client connection. When the handler terminates, it will write to the
-// provided closeChan to indicate its work is complete.
-func NewClientHandler(conn net.Conn, closeChan chan *ClientHandler, store *store.Store, game *game.Game, sessionKey uint64) *ClientHandler {
+// NewClientHandler returns a new handler for a client connection.
+func NewClientHandler(conn net.Conn, opts ClientHandlerOptions) *ClientHandler {
+	var rateLimiter *RateLimiter
+	if opts.RateLimit.Enabled {
+		rateLimiter = NewRateLimiter(opts.RateLimit)
+	}
+
 	return &ClientHandler{
-		conn:       conn,
-		game:       game,
-		store:      store,
-		reader:     network.NewReader(conn),
-		writer:     network.NewWriter(conn),
-		closeChan:  closeChan,
-		state:      initializing,
-		sessionKey: sessionKey,
+		accounts:      opts.Accounts,
+		assets:        opts.Assets,
+		autosaver:     opts.Autosaver,
+		autoRegister:  opts.AutoRegister,
+		bans:          opts.Bans,
+		conn:          conn,
+		game:          opts.Game,
+		loginThrottle: opts.LoginThrottle,
+		hasher:        opts.PasswordHasher,
+		playerLocks:   opts.PlayerLocks,
+		privateKey:    opts.PrivateKey,
+		rateLimiter:   rateLimiter,
+		recordingDir:  opts.RecordingDir,
+		revisions:     opts.Revisions,
+		store:         opts.Store,
+		reader:        network.NewReader(conn),
+		writer:        network.NewWriter(conn),
+		closeChan:     opts.CloseChan,
+		state:         initializing,
+		sessionKey:    opts.SessionKey,
+		telemetry:     opts.Telemetry,
 	}
 }
 
@@ -77,6 +153,8 @@ func (c *ClientHandler) Handle() {
 			nextState, err = c.handleLogin()
 		case active:
 			nextState, err = c.handleLoop()
+		case onDemand:
+			nextState, err = c.handleOnDemand()
 		case failed:
 			run = false
 		}
@@ -92,14 +170,22 @@ func (c *ClientHandler) Handle() {
 	// indicate this client handler can be cleaned up
 	c.closeChan <- c
 
-	// if the player was added to the game world, remove them and save their persistent data
+	// if the player was added to the game world, detach them from this connection and save their persistent data
 	if c.player != nil {
-		// remove the player from the game world
-		c.game.RemovePlayer(c.player)
+		unlock := c.playerLocks.Lock(c.player.Username)
+
+		// the player remains in the game world for a short time in case their client reconnects
+		c.game.DetachPlayer(c.player, c.writer)
+
+		c.autosaver.SavePlayer(c.player, SaveReasonLogout)
+		unlock()
+	}
 
-		err := c.store.SavePlayer(c.player)
+	// finish recording the player's session, if one was started
+	if c.recorder != nil {
+		err := c.recorder.Close()
 		if err != nil {
-			logger.Errorf("failed to save player %d: %s", c.player.ID, err)
+			logger.Errorf("failed to write session recording: %s", err)
 		}
 	}
 }
@@ -128,6 +214,24 @@ func (c *ClientHandler) handleInitialization() (clientState, error) {
 		return failed, errors.Wrap(err, "failed to read init packet header")
 	}
 
+	// the client opens a separate connection to the update server to request files on-demand
+	if header == request.OnDemandInitRequestHeader {
+		var req request.OnDemandInitRequest
+		err = req.Read(c.reader)
+		if err != nil {
+			return failed, errors.Wrap(err, "unexpected on-demand init packet contents")
+		}
+
+		// write some padding bytes (ignored by client)
+		padding := response.NewBlankResponse(8)
+		err = padding.Write(c.writer)
+		if err != nil {
+			return failed, errors.Wrap(err, "failed to send padding")
+		}
+
+		return onDemand, nil
+	}
+
 	// expect an init request first
 	if header != request.InitRequestHeader {
 		return failed, fmt.Errorf("unexpected init packet header: %2x", header)
@@ -168,406 +272,380 @@ func (c *ClientHandler) handleLogin() (clientState, error) {
 		return failed, fmt.Errorf("unexpected login packet header: %2x", header)
 	}
 
-	// read the contents of the login request
-	var req request.LoginRequest
-	err = req.Read(c.reader)
+	// read the start of the login request, which contains the client version
+	req := request.LoginRequest{PrivateKey: c.privateKey}
+	err = req.ReadHeader(c.reader)
 	if err != nil {
 		return failed, errors.Wrap(err, "unexpected login request contents")
 	}
 
 	// validate if the client is supported by the server
-	if req.Version != clientVersion {
+	revision := c.revisions.Lookup(int(req.Version))
+	if revision == nil {
 		resp := response.NewFailedInitResponse(response.InitGameUpdated)
 		err := resp.Write(c.writer)
 		return failed, err
 	}
 
-	// load the player's data, if it exists
-	player, err := c.store.LoadPlayer(req.Username)
+	// the rest of the login request differs between revisions
+	err = revision.ReadLogin(c.reader, &req)
 	if err != nil {
-		// fall through
-		logger.Errorf("failed to load player %s: %s", req.Username, err)
-		player = nil
+		return failed, errors.Wrap(err, "unexpected login request contents")
 	}
 
-	// does a player with that username even exist?
-	if player == nil {
-		resp := response.NewFailedInitResponse(response.InitInvalidUsername)
+	// validate the client's cache matches the server's, otherwise the player needs to update their client
+	match, err := c.matchesArchiveCRCs(req.CRCs)
+	if err != nil {
+		return failed, errors.Wrap(err, "failed to validate archive checksums")
+	} else if !match {
+		resp := response.NewFailedInitResponse(response.InitGameUpdated)
 		err := resp.Write(c.writer)
 		return failed, err
 	}
 
-	// hash their password for comparison
-	passwordHash := c.hashPassword(req.Password)
-	if player.PasswordHash != passwordHash {
-		// TODO: track this as a failed login attempt
-		resp := response.NewFailedInitResponse(response.InitInvalidUsername)
+	// the client includes the session key it received as part of its cipher seeds, so it needs to match the one
+	// that was issued for this connection
+	if req.SessionKey() != c.sessionKey {
+		resp := response.NewFailedInitResponse(response.InitInvalidSid)
 		err := resp.Write(c.writer)
 		return failed, err
 	}
 
-	// check if the player can be added to the game
-	result := c.game.ValidatePlayer(player)
-	if result != game.ValidationResultSuccess {
-		var resp response.Response
-
-		switch result {
-		case game.ValidationResultAlreadyLoggedIn:
-			resp = response.NewFailedInitResponse(response.InitAccountLoggedIn)
-		case game.ValidationResultNoCapacity:
-			resp = response.NewFailedInitResponse(response.InitServerFull)
-		default:
-			break
-		}
-
-		if resp != nil {
-			err = resp.Write(c.writer)
-		}
+	// reject the attempt outright if the username or network address is locked out after too many failed attempts
+	address := c.remoteAddress()
+	locked, err := c.loginThrottle.Locked(req.Username, address)
+	if err != nil {
+		return failed, errors.Wrap(err, "failed to check failed login attempts")
+	} else if locked {
+		c.auditLogin(&req, address, model.LoginResultLockedOut)
 
+		resp := response.NewFailedInitResponse(response.InitTooManyAttempts)
+		err := resp.Write(c.writer)
 		return failed, err
 	}
 
-	// the player has now authenticated and can be added to the game
-	c.player = player
-
-	// send a confirmation to the client
-	resp := response.NewLoggedInInitResponse(c.player.Type, c.player.Flagged)
-	err = resp.Write(c.writer)
+	// reject the attempt if the account, network address or client machine is banned
+	ban, err := c.bans.Find(req.Username, address, req.UID)
 	if err != nil {
-		return failed, errors.Wrap(err, "failed to send logged in response")
-	}
+		return failed, errors.Wrap(err, "failed to check bans")
+	} else if ban != nil {
+		c.auditLogin(&req, address, model.LoginResultBanned)
 
-	// add the player to the game world
-	c.game.AddPlayer(player, req.IsLowMemory, c.writer)
+		var code response.InitFailureCode = response.InitLoginRejected
+		if ban.Target == model.BanTargetAccount {
+			code = response.InitAccountDisabled
+		}
 
-	logger.Infof("connected new player: %s (%s)", c.player.Username, c.conn.RemoteAddr().String())
-	return active, nil
-}
+		resp := response.NewFailedInitResponse(code)
+		err := resp.Write(c.writer)
+		return failed, err
+	}
 
-// hashPassword computes a hash of the player's password.
-func (c *ClientHandler) hashPassword(password string) string {
-	// use a sha512/256 hash algorithm for passwords
-	hash := sha512.Sum512_256([]byte(password))
-	return strings.ToLower(hex.EncodeToString(hash[:]))
-}
+	// prevent the player from being loaded while their previous session is still being saved
+	unlock := c.playerLocks.Lock(req.Username)
+	defer unlock()
 
-func (c *ClientHandler) handleLoop() (clientState, error) {
-	header, err := c.reader.Peek()
+	// load the player's data, if it exists
+	player, err := c.store.LoadPlayer(req.Username)
 	if err != nil {
-		return failed, errors.Wrap(err, "unexpected error while waiting for packet header")
+		// fall through
+		logger.Errorf("failed to load player %s: %s", req.Username, err)
+		player = nil
 	}
 
-	// maintain current state
-	var nextState = c.state
-
-	switch header {
-	case request.KeepAliveRequestHeader:
-		// idle/keep-alive
-		var req request.KeepAliveRequest
-		err = req.Read(c.reader)
+	// create a new account for an unknown username if registration is enabled. if the player could not be loaded due
+	// to an error, do not assume the username is available.
+	if player == nil && err == nil && c.autoRegister {
+		player, err = c.accounts.Create(req.Username, req.Password)
 		if err != nil {
-			break
+			logger.Warnf("failed to register player %s: %s", req.Username, err)
+			player = nil
+		} else {
+			logger.Infof("registered new player %s", req.Username)
 		}
+	}
 
-		c.lastHeartbeat = time.Now()
-
-	case request.FocusRequestHeader:
-		// client window focus has changed
-		var req request.FocusChangeRequest
-		err = req.Read(c.reader)
+	// does a player with that username even exist?
+	if player == nil {
+		return c.rejectCredentials(&req, address)
+	}
 
-	case request.ClientClickRequestHeader:
-		// the player clicked somewhere on the client window
-		var req request.ClientClickRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	// verify their password against the stored hash
+	valid, err := c.hasher.Verify(req.Password, player.PasswordHash)
+	if err != nil {
+		logger.Errorf("failed to verify password for player %s: %s", req.Username, err)
+		valid = false
+	}
 
-		c.game.MarkPlayerActive(c.player)
+	if !valid {
+		return c.rejectCredentials(&req, address)
+	}
 
-	case request.RegionChangeRequestHeader:
-		// the player entered a new map region
-		var req request.RegionChangeRequest
-		err = req.Read(c.reader)
+	// rehash the password if it was hashed with an outdated algorithm or cost parameters
+	if c.hasher.NeedsUpgrade(player.PasswordHash) {
+		c.upgradePasswordHash(player, req.Password)
+	}
 
-	case request.CameraModeRequestHeader:
-		// the player moved their client's camera
-		var req request.CameraModeRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	// the player has authenticated, so forget about any previous failed attempts
+	err = c.loginThrottle.RecordSuccess(req.Username)
+	if err != nil {
+		logger.Errorf("failed to clear failed login attempts for player %s: %s", req.Username, err)
+	}
 
-		c.game.MarkPlayerActive(c.player)
+	c.auditLogin(&req, address, model.LoginResultSuccess)
 
-	case request.RegionLoadedRequestHeader:
-		// the player's client finished loading a new map region
-		var req request.RegionLoadedRequest
-		err = req.Read(c.reader)
+	// a reconnecting client can resume the player's session if they are still in the game world. otherwise, check
+	// if the player can be added to the game.
+	result := c.game.ValidatePlayer(player)
+	resume := req.IsReconnect && result == game.ValidationResultAlreadyLoggedIn
 
-	case request.RegionResetRequestHeader:
-		// the player's client loaded a certain number of map regions
-		var req request.RegionResetRequest
-		err = req.Read(c.reader)
+	if !resume && result != game.ValidationResultSuccess {
+		var resp response.Response
 
-	case request.ReportRequestHeader:
-		// the player sent an abuse report
-		var req request.ReportRequest
-		err = req.Read(c.reader)
-		if err != nil {
+		switch result {
+		case game.ValidationResultAlreadyLoggedIn:
+			resp = response.NewFailedInitResponse(response.InitAccountLoggedIn)
+		case game.ValidationResultNoCapacity:
+			resp = response.NewFailedInitResponse(response.InitServerFull)
+		default:
 			break
 		}
 
-		c.game.ProcessAbuseReport(c.player, req.Username, req.Reason, req.EnableMute)
-
-	case request.CloseInterfaceRequestHeader:
-		// the player's client dismissed the current interface, if any
-		var req request.CloseInterfaceRequest
-		err = req.Read(c.reader)
-
-	case request.PlayerIdleRequestHeader:
-		// the player has become idle
-		var req request.PlayerIdleRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
+		if resp != nil {
+			err = resp.Write(c.writer)
 		}
 
-		c.game.MarkPlayerInactive(c.player)
+		return failed, err
+	}
 
-	case request.PlayerChatRequestHeader:
-		// the player sent a chat message
-		var req request.PlayerChatRequest
-		err = req.Read(c.reader)
+	// roll back a player joining the game world if a rollback was scheduled for them while they were offline
+	if !resume {
+		player, err = c.autosaver.ApplyPendingRollback(player)
 		if err != nil {
-			break
+			return failed, errors.Wrap(err, "failed to load rolled back player")
 		}
+	}
 
-		c.game.DoPlayerChat(c.player, req.Effect, req.Color, req.Text)
-
-	case request.ChatCommandRequestHeader:
-		// the player sent a chat command
-		var req request.ChatCommandRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	// the player has now authenticated and can be added to the game
+	c.player = player
+	c.player.Address = address
+	c.player.UID = req.UID
 
-		c.game.DoPlayerChatCommand(c.player, req.Text)
+	// memberships are only active until their end date
+	c.player.UpdateMembership(time.Now())
 
-	case request.PrivateChatRequestHeader:
-		// the player sent a private chat message
-		var req request.PrivateChatRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	// send a confirmation to the client
+	resp := response.NewLoggedInInitResponse(c.player.Type, c.player.Flagged)
+	if resume {
+		resp = response.NewReconnectedInitResponse()
+	}
 
-		c.game.DoPlayerPrivateChat(c.player, req.Recipient, req.Text)
+	err = resp.Write(c.writer)
+	if err != nil {
+		return failed, errors.Wrap(err, "failed to send logged in response")
+	}
 
-	case request.ChangeModesRequestHeader:
-		// the player changed one or more chat or interaction modes
-		var req request.ChangeModesRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	// all subsequent packets exchanged with the client have their headers encoded
+	c.enableCiphers(req.Seeds)
 
-		c.game.SetPlayerModes(c.player, req.PublicChat, req.PrivateChat, req.Interaction)
+	// translate packets to and from the protocol revision used by the client
+	c.revision = revision
+	c.writer.SetOpcodeMap(revision.ResponseOpcodes())
+	c.writer.SetEncoder(revision)
 
-	case request.WalkRequestHeader, request.WalkOnCommandRequestHeader, request.WalkMinimap:
-		// the player started walking to a destination on the map
-		var req request.WalkRequest
-		err = req.Read(c.reader)
+	// start recording the player's session if enabled
+	if c.recordingDir != "" {
+		c.recorder, err = c.startRecording(req.IsLowMemory)
 		if err != nil {
-			break
+			logger.Errorf("failed to start recording session for player %s: %s", c.player.Username, err)
 		}
+	}
 
-		c.game.WalkPlayer(c.player, req.Start, req.Waypoints)
+	if resume {
+		// continue with the player as they are known to the game world
+		if p := c.game.ResumePlayer(player, req.IsLowMemory, c.writer, c.recorder); p != nil {
+			c.player = p
+			c.player.Address = address
+			c.player.UID = req.UID
 
-	case request.TakeGroundItemRequestHeader:
-		// the player tried to pick up a ground item
-		var req request.TakeGroundItemRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
+			logger.Infof("reconnected player: %s (%s)", c.player.Username, c.conn.RemoteAddr().String())
+			return active, nil
 		}
 
-		c.game.DoTakeGroundItem(c.player, req.ItemID, req.GlobalPos)
-
-	case request.DropInventoryItemRequestHeader:
-		// the player dropped an inventory item
-		var req request.DropInventoryItemRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
+		// the player's session ended in the meantime, so they need to be added to the game again
+		if c.game.ValidatePlayer(player) != game.ValidationResultSuccess {
+			return failed, fmt.Errorf("player %s could not rejoin after their session ended", player.Username)
 		}
+	}
 
-		c.game.DoDropInventoryItem(c.player, req.ItemID, req.InterfaceID, req.SecondaryActionID)
+	// add the player to the game world
+	c.game.AddPlayer(player, req.IsLowMemory, c.writer, c.recorder)
 
-	case request.SwapInventoryItemRequestHeader:
-		// the player rearranged an item in their inventory
-		var req request.SwapInventoryItemRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	logger.Infof("connected new player: %s (%s)", c.player.Username, c.conn.RemoteAddr().String())
+	return active, nil
+}
 
-		c.game.DoSwapInventoryItem(c.player, req.FromSlot, req.ToSlot, req.InterfaceID)
+// rejectCredentials tracks a failed login attempt due to an unknown username or incorrect password, and informs the
+// client accordingly.
+func (c *ClientHandler) rejectCredentials(req *request.LoginRequest, address string) (clientState, error) {
+	c.auditLogin(req, address, model.LoginResultInvalidCredentials)
 
-	case request.EquipItemRequestHeader:
-		// the player equipped an item from their inventory
-		var req request.EquipItemRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	code := response.InitInvalidUsername
 
-		c.game.DoEquipItem(c.player, req.ItemID, req.InterfaceID, req.SecondaryActionID)
+	// let the client know if this attempt caused the username or address to become locked out
+	locked, err := c.loginThrottle.RecordFailure(req.Username, address)
+	if err != nil {
+		logger.Errorf("failed to track failed login attempt for player %s: %s", req.Username, err)
+	} else if locked {
+		code = response.InitTooManyAttempts
+	}
 
-	case request.UnequipItemRequestHeader:
-		// the player unequipped an item from their equipment
-		var req request.UnequipItemRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	resp := response.NewFailedInitResponse(code)
+	err = resp.Write(c.writer)
+	return failed, err
+}
 
-		c.game.DoUnequipItem(c.player, req.ItemID, req.InterfaceID, req.SlotType)
+// auditLogin records the outcome of a login attempt.
+func (c *ClientHandler) auditLogin(req *request.LoginRequest, address string, result model.LoginResult) {
+	err := c.store.SaveLoginAudit(&model.LoginAuditEntry{
+		Username: req.Username,
+		Address:  address,
+		UID:      req.UID,
+		Result:   result,
+	})
+	if err != nil {
+		logger.Errorf("failed to audit login attempt for player %s: %s", req.Username, err)
+	}
+}
 
-	case request.UseItemRequestHeader:
-		// the player initiated the default action on an item
-		var req request.UseItemRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+// remoteAddress returns the network address of the client, without its port.
+func (c *ClientHandler) remoteAddress() string {
+	addr := c.conn.RemoteAddr().String()
 
-		c.game.DoUseItem(c.player, req.ItemID, req.InterfaceID, req.ActionID)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
 
-	case request.UseInventoryItemsRequestHeader:
-		// the player used an inventory item on another
-		var req request.UseInventoryItemsRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	return host
+}
 
-		c.game.DoUseInventoryItem(c.player, req.SourceItemID, req.SourceInterfaceID, req.SourceSlotID,
-			req.TargetItemID, req.TargetInterfaceID, req.TargetSlotID)
+// startRecording creates a recorder for the player's session in the recording directory.
+func (c *ClientHandler) startRecording(lowMemory bool) (*recording.Recorder, error) {
+	name := fmt.Sprintf("%s-%s.rec", c.player.Username, time.Now().Format("20060102-150405"))
 
-	case request.AddFriendRequestHeader:
-		// the player requested another player be added to their friends list
-		var req request.ModifyFriendRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	f, err := os.Create(filepath.Join(c.recordingDir, name))
+	if err != nil {
+		return nil, err
+	}
 
-		c.game.AddFriend(c.player, req.Username)
+	recorder, err := recording.NewRecorder(f, c.player, lowMemory, c.game.Tick)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
 
-	case request.RemoveFriendRequestHeader:
-		// the player requested another player be removed from their friends list
-		var req request.ModifyFriendRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	return recorder, nil
+}
+
+// matchesArchiveCRCs determines if the archive checksums sent by the client are the same as the server's.
+func (c *ClientHandler) matchesArchiveCRCs(crcs []uint32) (bool, error) {
+	expected, err := c.assets.ArchiveCRCs()
+	if err != nil {
+		return false, err
+	}
 
-		c.game.RemoveFriend(c.player, req.Username)
+	if len(crcs) != len(expected) {
+		return false, nil
+	}
 
-	case request.AddIgnoreRequestHeader:
-		// the player requested another player be added to their ignore list
-		var req request.ModifyIgnoreRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
+	for i, crc := range expected {
+		if crcs[i] != crc {
+			return false, nil
 		}
+	}
 
-		c.game.AddIgnored(c.player, req.Username)
+	return true, nil
+}
 
-	case request.RemoveIgnoreRequestHeader:
-		// the player requested another player be removed from their ignore list
-		var req request.ModifyIgnoreRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+// handleOnDemand serves files from the game cache until the client closes the update server connection.
+func (c *ClientHandler) handleOnDemand() (clientState, error) {
+	handler := NewOnDemandHandler(c.reader, c.writer, c.assets)
 
-		c.game.RemoveIgnored(c.player, req.Username)
+	err := handler.Handle()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return failed, errors.Wrap(err, "failed to serve on-demand request")
+	}
 
-	case request.InterfaceActionRequestHeader:
-		// the player has performed an action on an interface
-		var req request.InterfaceActionRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	// the client is done with the connection, so there is nothing more to do
+	return failed, nil
+}
 
-		c.game.DoInterfaceAction(c.player, req.Action)
+// enableCiphers initializes the ISAAC cipher pair used to encode packet headers. The client encodes its packets with
+// a cipher initialized with the seeds as-is, and expects the server to use the same seeds offset by 50.
+func (c *ClientHandler) enableCiphers(seeds []uint32) {
+	c.reader.SetCipher(network.NewISAACCipher(seeds))
 
-	case request.InteractObjectRequestHeader:
-		// the player interacted with an object
-		var req request.InteractObjectRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	outSeeds := make([]uint32, len(seeds))
+	for i, seed := range seeds {
+		outSeeds[i] = seed + 50
+	}
 
-		c.game.DoInteractWithObject(c.player, req.Action, req.GlobalPos)
+	c.writer.SetCipher(network.NewISAACCipher(outSeeds))
+}
 
-	case request.CastSpellOnItemRequestHeader:
-		// the player cast a spell on an inventory item
-		var req request.CastSpellOnItemRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+// upgradePasswordHash rehashes a player's password using the preferred algorithm, and persists the new hash. Failures
+// are logged but do not prevent the player from logging in, since the existing hash remains valid.
+func (c *ClientHandler) upgradePasswordHash(player *model.Player, password string) {
+	hash, err := c.hasher.Hash(password)
+	if err != nil {
+		logger.Errorf("failed to rehash password for player %s: %s", player.Username, err)
+		return
+	}
 
-		c.game.DoCastSpellOnItem(c.player, req.SlotID, req.ItemID, req.InventoryInterfaceID, req.SpellInterfaceID)
+	err = c.store.SavePasswordHash(player.ID, hash)
+	if err != nil {
+		logger.Errorf("failed to save rehashed password for player %s: %s", player.Username, err)
+		return
+	}
 
-	case request.CharacterDesignRequestHeader:
-		// the player submitted a new character design
-		var req request.CharacterDesignRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	player.PasswordHash = hash
+}
 
-		c.game.DoSetPlayerDesign(c.player, req.Gender, req.Base, req.BodyColors)
+// handleLoop decodes the next packet sent by the client and dispatches it to its handler, if one is registered.
+func (c *ClientHandler) handleLoop() (clientState, error) {
+	if c.recorder != nil {
+		c.reader.StartCapture()
+	}
 
-	case request.AttackNPCRequestHeader:
-		// the player attacked an npc
-		var req request.AttackNPCRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
-		}
+	opcode, req, err := c.revision.Requests().Decode(c.reader)
+	if err != nil {
+		return failed, errors.Wrapf(err, "failed to read packet %2x", opcode)
+	}
 
-		c.game.DoAttackNPC(c.player, req.TargetID)
+	// record the packet exactly as it was read, including any payload that was skipped
+	if c.recorder != nil {
+		data := c.reader.StopCapture()
+		c.recorder.Record(recording.Inbound, opcode, data[1:])
+	}
 
-	case request.InteractWithNPCAction1RequestHeader,
-		request.InteractWithNPCAction2RequestHeader,
-		request.InteractWithNPCAction3RequestHeader,
-		request.InteractWithNPCAction4RequestHeader:
-		// the player interacted with an npc
-		var req request.InteractWithNPCRequest
-		err = req.Read(c.reader)
-		if err != nil {
-			break
+	// disconnect the client if it's sending more packets than it's allowed to
+	if c.rateLimiter != nil {
+		if limit, ok := c.rateLimiter.Allow(opcode, c.game.Tick()); !ok {
+			c.telemetry.RecordRateLimitExceeded(string(limit), opcode)
+			return failed, fmt.Errorf("player %s exceeded %s rate limit with packet %2x", c.player.Username, limit, opcode)
 		}
+	}
 
-		c.game.DoInteractWithNPC(c.player, req.ActionIndex, req.TargetID)
-
-	default:
-		// unknown packet
-		err = fmt.Errorf("unexpected packet header: %2x", header)
+	// unknown packets are skipped by the registry without a decoded request
+	if req == nil {
+		logger.Debugf("skipped unknown packet: %2x", opcode)
+		return c.state, nil
 	}
 
-	if err != nil {
-		return failed, err
+	if handler, ok := packetHandlers[opcode]; ok {
+		handler(c, req)
 	}
 
-	return nextState, nil
+	return c.state, nil
 }
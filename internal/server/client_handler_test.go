@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/request"
+	"github.com/mbpolan/openmcs/internal/network/response"
+	"github.com/mbpolan/openmcs/internal/protocol"
+	"github.com/mbpolan/openmcs/internal/protocol/r317"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_ClientHandler_handleLogin_archiveCRCs(t *testing.T) {
+	assets := newTestAssets(t, map[int]map[int][]byte{0: newTestArchives()})
+	crcs, err := assets.ArchiveCRCs()
+	require.NoError(t, err)
+
+	outdated := append([]uint32{}, crcs...)
+	outdated[2]++
+
+	tests := map[string]struct {
+		crcs []uint32
+		code byte
+	}{
+		"outdated": {crcs: outdated, code: response.InitGameUpdated},
+		// a client with an up-to-date cache moves on to the next check, which fails on purpose here
+		"matching": {crcs: crcs, code: response.InitInvalidSid},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			writer := network.NewBufferedWriter()
+			c := &ClientHandler{
+				assets:     assets,
+				reader:     network.NewReader(bytes.NewReader(newTestLoginPacket(test.crcs))),
+				revisions:  protocol.NewSet(r317.New()),
+				sessionKey: 1,
+				writer:     writer,
+			}
+
+			state, err := c.handleLogin()
+			require.NoError(t, err)
+			assert.Equal(t, failed, state)
+
+			buf, err := writer.Buffer()
+			require.NoError(t, err)
+			assert.Equal(t, []byte{test.code}, buf.Bytes())
+		})
+	}
+}
+
+// newTestLoginPacket returns a login request from a 317 client with archive checksums, and an unencrypted block
+// containing credentials and a session key of zero.
+func newTestLoginPacket(crcs []uint32) []byte {
+	block := []byte{0x0A}
+	for i := 0; i < 5; i++ {
+		block = binary.BigEndian.AppendUint32(block, 0)
+	}
+
+	block = append(block, "mike\nsecret\n"...)
+
+	packet := []byte{request.NewLoginRequestHeader, byte(len(block) + 41), 0xFF}
+	packet = binary.BigEndian.AppendUint16(packet, r317.Version)
+	packet = append(packet, 0x00)
+	for _, crc := range crcs {
+		packet = binary.BigEndian.AppendUint32(packet, crc)
+	}
+
+	packet = append(packet, byte(len(block)))
+	return append(packet, block...)
+}
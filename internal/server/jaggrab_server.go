@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/asset"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/logger"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jaggrabRequestPrefix is the prefix of a request line sent by a client to the JAGGRAB server.
+const jaggrabRequestPrefix = "JAGGRAB /"
+
+// jaggrabReadTimeout is the maximum amount of time to wait for a client to send its request.
+const jaggrabReadTimeout = 5 * time.Second
+
+// jaggrabCRCTable is the name of the file containing the expected checksums of each archive.
+const jaggrabCRCTable = "crc"
+
+// jaggrabArchives maps archive names requested by the client to their index in the main cache.
+var jaggrabArchives = map[string]int{
+	"title":       1,
+	"config":      2,
+	"interface":   3,
+	"media":       4,
+	"versionlist": 5,
+	"textures":    6,
+	"wordenc":     7,
+	"sounds":      8,
+}
+
+// JAGGRABServer serves cache archives to clients over the JAGGRAB protocol, with an optional plain HTTP fallback for
+// clients that are unable to connect to the JAGGRAB port.
+type JAGGRABServer struct {
+	assets          *asset.Manager
+	bindAddress     string
+	httpBindAddress string
+	httpServer      *http.Server
+	listener        net.Listener
+}
+
+// NewJAGGRABServer creates a new JAGGRAB server that serves archives from an asset manager.
+func NewJAGGRABServer(host string, cfg config.JAGGRABConfig, assets *asset.Manager) *JAGGRABServer {
+	s := &JAGGRABServer{
+		assets:      assets,
+		bindAddress: fmt.Sprintf("%s:%d", host, cfg.Port),
+	}
+
+	// the http fallback is only enabled if a port is configured for it
+	if cfg.HTTPPort > 0 {
+		s.httpBindAddress = fmt.Sprintf("%s:%d", host, cfg.HTTPPort)
+	}
+
+	return s
+}
+
+// Start begins listening for connections on the JAGGRAB and, if enabled, the HTTP ports.
+func (s *JAGGRABServer) Start() error {
+	var err error
+	s.listener, err = net.Listen("tcp", s.bindAddress)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen on jaggrab socket")
+	}
+
+	go s.acceptLoop()
+	logger.Infof("jaggrab server listening on %s", s.bindAddress)
+
+	if s.httpBindAddress == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleHTTP)
+
+	s.httpServer = &http.Server{
+		Addr:    s.httpBindAddress,
+		Handler: mux,
+	}
+
+	go func() {
+		logger.Infof("jaggrab http server listening on %s", s.httpBindAddress)
+
+		err := s.httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("failed to start jaggrab http server: %s", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop terminates the JAGGRAB and HTTP servers.
+func (s *JAGGRABServer) Stop() {
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+
+	if s.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = s.httpServer.Shutdown(ctx)
+	}
+}
+
+// acceptLoop accepts incoming JAGGRAB connections until the listener is closed.
+func (s *JAGGRABServer) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Errorf("failed to accept jaggrab connection: %s", err)
+			}
+
+			return
+		}
+
+		go s.handleConnection(conn)
+	}
+}
+
+// handleConnection processes a single JAGGRAB request. The client sends one request line followed by a blank line,
+// and expects the raw archive data in response after which the connection is closed.
+func (s *JAGGRABServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(jaggrabReadTimeout))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		logger.Debugf("failed to read jaggrab request: %s", err)
+		return
+	}
+
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, jaggrabRequestPrefix) {
+		logger.Debugf("unexpected jaggrab request: %s", line)
+		return
+	}
+
+	data, err := s.resolve(strings.TrimPrefix(line, jaggrabRequestPrefix))
+	if err != nil {
+		logger.Debugf("failed to serve jaggrab request: %s", err)
+		return
+	}
+
+	_, err = conn.Write(data)
+	if err != nil {
+		logger.Debugf("failed to write jaggrab response: %s", err)
+	}
+}
+
+// handleHTTP processes an archive request sent over HTTP.
+func (s *JAGGRABServer) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := s.resolve(strings.TrimPrefix(r.URL.Path, "/"))
+	if err != nil {
+		logger.Debugf("failed to serve jaggrab http request: %s", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	_, _ = w.Write(data)
+}
+
+// resolve returns the archive data for a requested path. Paths consist of an archive name followed by the client's
+// expected crc value, which may be negative.
+func (s *JAGGRABServer) resolve(path string) ([]byte, error) {
+	name := strings.TrimRight(path, "-0123456789")
+	if name == jaggrabCRCTable {
+		return s.crcTable()
+	}
+
+	id, ok := jaggrabArchives[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown archive: %s", path)
+	}
+
+	data, err := s.assets.ArchiveData(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load archive %s", name)
+	}
+
+	return data, nil
+}
+
+// crcTable returns the table of archive checksums the client validates its cache against. The table contains each
+// archive checksum followed by a hash of the table itself.
+func (s *JAGGRABServer) crcTable() ([]byte, error) {
+	crcs, err := s.assets.ArchiveCRCs()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute archive checksums")
+	}
+
+	data := make([]byte, 0, (len(crcs)+1)*4)
+	hash := uint32(1234)
+	for _, crc := range crcs {
+		data = binary.BigEndian.AppendUint32(data, crc)
+		hash = (hash << 1) + crc
+	}
+
+	return binary.BigEndian.AppendUint32(data, hash), nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/binary"
+	"github.com/mbpolan/openmcs/internal/asset"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hash/crc32"
+	"testing"
+)
+
+func Test_JAGGRABServer_resolve(t *testing.T) {
+	archives := newTestArchives()
+	s := NewJAGGRABServer("localhost", config.JAGGRABConfig{}, newTestAssets(t, map[int]map[int][]byte{0: archives}))
+
+	tests := map[string]int{
+		"title":              1,
+		"config1234567":      2,
+		"interface-98765432": 3,
+		"sounds0":            8,
+	}
+
+	// the client's expected crc is stripped from the archive name, including its sign
+	for path, id := range tests {
+		t.Run(path, func(t *testing.T) {
+			data, err := s.resolve(path)
+			require.NoError(t, err)
+			assert.Equal(t, archives[id], data)
+		})
+	}
+
+	_, err := s.resolve("models1234")
+	assert.Error(t, err)
+
+	_, err = s.resolve("title/../crc")
+	assert.Error(t, err)
+}
+
+func Test_JAGGRABServer_crcTable(t *testing.T) {
+	archives := newTestArchives()
+	s := NewJAGGRABServer("localhost", config.JAGGRABConfig{}, newTestAssets(t, map[int]map[int][]byte{0: archives}))
+
+	data, err := s.resolve("crc-1527451934")
+	require.NoError(t, err)
+	require.Len(t, data, (asset.NumArchives+1)*4)
+
+	// the first archive is unused, and its checksum is always zero
+	expected := uint32(1234)
+	for i := 0; i < asset.NumArchives; i++ {
+		crc := binary.BigEndian.Uint32(data[i*4:])
+		if i == 0 {
+			assert.Zero(t, crc)
+		} else {
+			assert.Equal(t, crc32.ChecksumIEEE(archives[i]), crc)
+		}
+
+		expected = (expected << 1) + crc
+	}
+
+	// the table ends with a hash of all checksums, including the unused one
+	assert.Equal(t, expected, binary.BigEndian.Uint32(data[asset.NumArchives*4:]))
+}
+
+// newTestArchives returns the data for distinct, empty archives in the main cache that are requested by the client.
+func newTestArchives() map[int][]byte {
+	archives := map[int][]byte{}
+	for i := 1; i < asset.NumArchives; i++ {
+		// an uncompressed archive with no files, followed by padding that gives each archive its own checksum
+		archives[i] = []byte{0, 0, 3, 0, 0, 3, 0, 0, byte(i)}
+	}
+
+	return archives
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginThrottle tracks failed login attempts for each username and network address, and locks them out once too many
+// consecutive attempts have failed. Each subsequent lockout lasts twice as long as the previous one.
+type LoginThrottle struct {
+	attemptWindow      time.Duration
+	lockout            time.Duration
+	maxAttempts        int
+	maxAddressAttempts int
+	maxLockout         time.Duration
+	mu                 sync.Mutex
+	now                func() time.Time
+	store              *store.Store
+}
+
+// NewLoginThrottle creates a throttle that tracks login attempts in a persistent store.
+func NewLoginThrottle(cfg config.LoginConfig, store *store.Store) *LoginThrottle {
+	return &LoginThrottle{
+		attemptWindow:      time.Duration(cfg.AttemptWindowSeconds) * time.Second,
+		lockout:            time.Duration(cfg.LockoutSeconds) * time.Second,
+		maxAttempts:        cfg.MaxAttempts,
+		maxAddressAttempts: cfg.MaxAddressAttempts,
+		maxLockout:         time.Duration(cfg.MaxLockoutSeconds) * time.Second,
+		now:                time.Now,
+		store:              store,
+	}
+}
+
+// Locked returns true if logins for a username or from a network address are currently locked out.
+func (t *LoginThrottle) Locked(username, address string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+
+	for source, key := range t.keys(username, address) {
+		attempts, err := t.store.LoadLoginAttempts(source, key)
+		if err != nil {
+			return false, err
+		}
+
+		if attempts != nil && attempts.Locked(now) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RecordFailure tracks a failed login attempt for a username from a network address. If the attempt caused either to
+// become locked out, true is returned.
+func (t *LoginThrottle) RecordFailure(username, address string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	locked := false
+
+	for source, key := range t.keys(username, address) {
+		maxAttempts := t.maxAttempts
+		if source == model.LoginAttemptSourceAddress {
+			maxAttempts = t.maxAddressAttempts
+		}
+
+		// tracking is disabled for this source
+		if maxAttempts <= 0 {
+			continue
+		}
+
+		attempts, err := t.store.LoadLoginAttempts(source, key)
+		if err != nil {
+			return false, err
+		}
+
+		attempts = t.nextAttempts(attempts, maxAttempts, now)
+		locked = locked || attempts.Locked(now)
+
+		err = t.store.SaveLoginAttempts(source, key, attempts)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return locked, nil
+}
+
+// RecordSuccess clears all failed login attempts for a username. Failed attempts from the player's network address are
+// kept until they expire, since other accounts may be guessed at from the same address.
+func (t *LoginThrottle) RecordSuccess(username string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.store.DeleteLoginAttempts(model.LoginAttemptSourceUsername, strings.ToLower(username))
+}
+
+// nextAttempts returns the state of failed login attempts after another attempt has failed.
+func (t *LoginThrottle) nextAttempts(prev *model.LoginAttempts, maxAttempts int, now time.Time) *model.LoginAttempts {
+	attempts := &model.LoginAttempts{}
+
+	// forget about previous attempts if they were made a long time ago, and any lockout has since ended
+	if prev != nil && (prev.Locked(now) || now.Sub(prev.LastFailure) < t.attemptWindow) {
+		*attempts = *prev
+	}
+
+	attempts.Failures++
+	attempts.LastFailure = now
+
+	if attempts.Failures >= maxAttempts {
+		// each lockout doubles in duration, up to a limit
+		duration := t.lockout << min(attempts.Lockouts, 30)
+		if duration > t.maxLockout || duration <= 0 {
+			duration = t.maxLockout
+		}
+
+		attempts.Failures = 0
+		attempts.Lockouts++
+		attempts.LockedUntil = now.Add(duration)
+	}
+
+	return attempts
+}
+
+// keys returns the keys that login attempts are tracked against for each source.
+func (t *LoginThrottle) keys(username, address string) map[model.LoginAttemptSource]string {
+	return map[model.LoginAttemptSource]string{
+		model.LoginAttemptSourceUsername: strings.ToLower(username),
+		model.LoginAttemptSourceAddress:  address,
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/store/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_LoginThrottle_nextAttempts(t *testing.T) {
+	throttle := NewLoginThrottle(config.LoginConfig{
+		AttemptWindowSeconds: 60,
+		LockoutSeconds:       10,
+		MaxLockoutSeconds:    30,
+	}, nil)
+
+	now := time.Unix(1000, 0)
+
+	attempts := throttle.nextAttempts(nil, 2, now)
+	assert.Equal(t, 1, attempts.Failures)
+	assert.False(t, attempts.Locked(now))
+
+	// the second failure causes a lockout
+	attempts = throttle.nextAttempts(attempts, 2, now)
+	assert.Equal(t, 0, attempts.Failures)
+	assert.Equal(t, 1, attempts.Lockouts)
+	assert.Equal(t, now.Add(10*time.Second), attempts.LockedUntil)
+
+	// subsequent lockouts double in duration up to the limit
+	now = now.Add(10 * time.Second)
+	attempts = throttle.nextAttempts(throttle.nextAttempts(attempts, 2, now), 2, now)
+	assert.Equal(t, now.Add(20*time.Second), attempts.LockedUntil)
+
+	now = now.Add(20 * time.Second)
+	attempts = throttle.nextAttempts(throttle.nextAttempts(attempts, 2, now), 2, now)
+	assert.Equal(t, now.Add(30*time.Second), attempts.LockedUntil)
+
+	// attempts are forgotten once the window has passed
+	now = now.Add(2 * time.Minute)
+	attempts = throttle.nextAttempts(attempts, 2, now)
+	assert.Equal(t, 1, attempts.Failures)
+	assert.Equal(t, 0, attempts.Lockouts)
+}
+
+func Test_LoginThrottle_RecordSuccess(t *testing.T) {
+	d, err := driver.NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	throttle := NewLoginThrottle(config.LoginConfig{
+		AttemptWindowSeconds: 60,
+		LockoutSeconds:       10,
+		MaxAttempts:          5,
+		MaxAddressAttempts:   2,
+		MaxLockoutSeconds:    30,
+	}, store.NewWithDriver(&config.Config{}, d))
+
+	_, err = throttle.RecordFailure("Mike", "127.0.0.1")
+	require.NoError(t, err)
+
+	// a successful login only clears attempts against the username, so guesses at other accounts from the same
+	// address still count toward its lockout
+	require.NoError(t, throttle.RecordSuccess("mike"))
+
+	attempts, err := d.LoadLoginAttempts(model.LoginAttemptSourceUsername, "mike")
+	require.NoError(t, err)
+	assert.Nil(t, attempts)
+
+	locked, err := throttle.RecordFailure("Hurz", "127.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, locked)
+
+	locked, err = throttle.Locked("Mike", "127.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, locked)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/asset"
+	"github.com/mbpolan/openmcs/internal/logger"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/request"
+	"github.com/mbpolan/openmcs/internal/network/response"
+	"sync"
+)
+
+// maxOnDemandFileSize is the largest file size that can be described in an on-demand response.
+const maxOnDemandFileSize = 0xFFFF
+
+// numOnDemandPriorities is the number of distinct priorities a file request can have.
+const numOnDemandPriorities = int(request.OnDemandPriorityLow) + 1
+
+// OnDemandHandler serves files from the game cache to a client connected to the update server. Requests are queued
+// as they arrive and served in order of their priority.
+type OnDemandHandler struct {
+	assets     *asset.Manager
+	reader     *network.ProtocolReader
+	writer     *network.ProtocolWriter
+	queues     [numOnDemandPriorities][]request.OnDemandFileRequest
+	mu         sync.Mutex
+	notifyChan chan bool
+}
+
+// NewOnDemandHandler returns a new handler for an update server connection.
+func NewOnDemandHandler(reader *network.ProtocolReader, writer *network.ProtocolWriter, assets *asset.Manager) *OnDemandHandler {
+	return &OnDemandHandler{
+		assets:     assets,
+		reader:     reader,
+		writer:     writer,
+		notifyChan: make(chan bool, 1),
+	}
+}
+
+// Handle processes file requests from the client until the connection is closed or an error occurs.
+func (h *OnDemandHandler) Handle() error {
+	readErrChan := make(chan error, 1)
+	go h.readLoop(readErrChan)
+
+	for {
+		// serve the next request with the highest priority, if there is one
+		req, ok := h.next()
+		if ok {
+			err := h.serve(req)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// otherwise wait until more requests arrive, or the client stops sending them
+		select {
+		case <-h.notifyChan:
+		case err := <-readErrChan:
+			return err
+		}
+	}
+}
+
+// readLoop continuously reads file requests from the client and queues them. When the client can no longer be read
+// from, the error is written to errChan.
+func (h *OnDemandHandler) readLoop(errChan chan error) {
+	for {
+		var req request.OnDemandFileRequest
+		err := req.Read(h.reader)
+		if err != nil {
+			errChan <- err
+			return
+		}
+
+		// the client periodically sends requests with an out-of-range priority to keep the connection alive
+		if req.Priority < request.OnDemandPriorityHigh || int(req.Priority) >= numOnDemandPriorities {
+			continue
+		}
+
+		h.mu.Lock()
+		h.queues[req.Priority] = append(h.queues[req.Priority], req)
+		h.mu.Unlock()
+
+		// wake up the serving loop if it's waiting for work
+		select {
+		case h.notifyChan <- true:
+		default:
+		}
+	}
+}
+
+// next removes and returns the oldest request with the highest priority. If there are no pending requests, false is
+// returned instead.
+func (h *OnDemandHandler) next() (request.OnDemandFileRequest, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, queue := range h.queues {
+		if len(queue) == 0 {
+			continue
+		}
+
+		req := queue[0]
+		h.queues[i] = queue[1:]
+		return req, true
+	}
+
+	return request.OnDemandFileRequest{}, false
+}
+
+// serve sends a requested file to the client, split into chunks.
+func (h *OnDemandHandler) serve(req request.OnDemandFileRequest) error {
+	// files that don't exist, or are too large to describe, are reported back as missing
+	data, err := h.assets.CacheData(req.CacheID, req.FileID)
+	if err != nil || len(data) == 0 || len(data) > maxOnDemandFileSize {
+		logger.Debugf("unable to serve on-demand file %d from cache %d: %v", req.FileID, req.CacheID, err)
+
+		resp := response.NewMissingOnDemandFileResponse(req.CacheID, req.FileID)
+		return resp.Write(h.writer)
+	}
+
+	for chunk, offset := 0, 0; offset < len(data); chunk++ {
+		end := min(offset+response.OnDemandChunkSize, len(data))
+
+		resp := response.NewOnDemandFileChunkResponse(req.CacheID, req.FileID, len(data), chunk, data[offset:end])
+		err := resp.Write(h.writer)
+		if err != nil {
+			return err
+		}
+
+		offset = end
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/asset"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/network/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path"
+	"testing"
+)
+
+func Test_OnDemandHandler_serve_chunks(t *testing.T) {
+	data := make([]byte, 1234)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	w := network.NewBufferedWriter()
+	h := NewOnDemandHandler(nil, w, newTestAssets(t, map[int]map[int][]byte{1: {7: data}}))
+
+	require.NoError(t, h.serve(request.OnDemandFileRequest{CacheID: 1, FileID: 7}))
+
+	// the file is split into chunks of at most 500 bytes, each with a header describing the whole file
+	buf, err := w.Buffer()
+	require.NoError(t, err)
+
+	var received []byte
+	for chunk, size := range []int{500, 500, 234} {
+		header := buf.Next(6)
+		require.Len(t, header, 6)
+		assert.Equal(t, byte(0), header[0])
+		assert.Equal(t, uint16(7), binary.BigEndian.Uint16(header[1:3]))
+		assert.Equal(t, uint16(len(data)), binary.BigEndian.Uint16(header[3:5]))
+		assert.Equal(t, byte(chunk), header[5])
+
+		received = append(received, buf.Next(size)...)
+	}
+
+	assert.Equal(t, data, received)
+	assert.Zero(t, buf.Len())
+}
+
+func Test_OnDemandHandler_serve_missing(t *testing.T) {
+	assets := newTestAssets(t, map[int]map[int][]byte{1: {0: {1, 2, 3}}})
+
+	tests := map[string]request.OnDemandFileRequest{
+		"unknown cache":     {CacheID: asset.NumCaches, FileID: 0},
+		"file out of range": {CacheID: 1, FileID: 500},
+		"empty cache":       {CacheID: 2, FileID: 0},
+	}
+
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := network.NewBufferedWriter()
+			h := NewOnDemandHandler(nil, w, assets)
+
+			// files that cannot be served are described as having no data
+			require.NoError(t, h.serve(req))
+
+			buf, err := w.Buffer()
+			require.NoError(t, err)
+			expected := []byte{byte(req.CacheID - 1), byte(req.FileID >> 8), byte(req.FileID), 0, 0, 0}
+			assert.Equal(t, expected, buf.Bytes())
+		})
+	}
+}
+
+func Test_OnDemandHandler_next_priority(t *testing.T) {
+	requests := []byte{
+		0, 0, 1, byte(request.OnDemandPriorityLow),
+		0, 0, 2, byte(request.OnDemandPriorityMedium),
+		// keep-alive requests with an out-of-range priority are ignored
+		0, 0, 3, 10,
+		0, 0, 4, byte(request.OnDemandPriorityHigh),
+		0, 0, 5, byte(request.OnDemandPriorityMedium),
+	}
+
+	h := NewOnDemandHandler(network.NewReader(bytes.NewReader(requests)), nil, nil)
+
+	errChan := make(chan error, 1)
+	h.readLoop(errChan)
+	require.Error(t, <-errChan)
+
+	// requests are served by priority, and in the order they arrived for the same priority
+	var fileIDs []int
+	for {
+		req, ok := h.next()
+		if !ok {
+			break
+		}
+
+		fileIDs = append(fileIDs, req.FileID)
+	}
+
+	assert.Equal(t, []int{4, 2, 5, 1}, fileIDs)
+}
+
+// newTestAssets writes a game cache containing files, keyed by cache index and file ID, and returns an asset manager
+// that reads from it. Every cache index has an index file, even if it contains no files.
+func newTestAssets(t *testing.T, caches map[int]map[int][]byte) *asset.Manager {
+	dir := t.TempDir()
+
+	// the first sector of the data file is never used
+	dat := make([]byte, cacheSectorSize)
+
+	for cacheID := 0; cacheID < asset.NumCaches; cacheID++ {
+		files := caches[cacheID]
+
+		numFiles := 0
+		for fileID := range files {
+			numFiles = max(numFiles, fileID+1)
+		}
+
+		idx := make([]byte, numFiles*6)
+		for fileID, data := range files {
+			sector := len(dat) / cacheSectorSize
+
+			// each file is stored in a chain of sectors, each of which points to the next one
+			for part, offset := 0, 0; offset < len(data) || part == 0; part++ {
+				end := min(offset+cacheSectorSize-8, len(data))
+				next := 0
+				if end < len(data) {
+					next = len(dat)/cacheSectorSize + 1
+				}
+
+				block := make([]byte, cacheSectorSize)
+				binary.BigEndian.PutUint16(block[0:], uint16(fileID))
+				binary.BigEndian.PutUint16(block[2:], uint16(part))
+				block[4], block[5], block[6] = byte(next>>16), byte(next>>8), byte(next)
+				block[7] = byte(cacheID + 1)
+				copy(block[8:], data[offset:end])
+
+				dat = append(dat, block...)
+				offset = end
+			}
+
+			entry := idx[fileID*6:]
+			entry[0], entry[1], entry[2] = byte(len(data)>>16), byte(len(data)>>8), byte(len(data))
+			entry[3], entry[4], entry[5] = byte(sector>>16), byte(sector>>8), byte(sector)
+		}
+
+		err := os.WriteFile(path.Join(dir, fmt.Sprintf("main_file_cache.idx%d", cacheID)), idx, 0644)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, os.WriteFile(path.Join(dir, "main_file_cache.dat"), dat, 0644))
+
+	return asset.NewManager(dir)
+}
+
+// cacheSectorSize is the size of each sector in a game cache data file, including its header.
+const cacheSectorSize = 520
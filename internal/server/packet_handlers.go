@@ -0,0 +1,167 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/network/request"
+	"time"
+)
+
+// packetHandler processes a decoded request sent by a client that is logged into the game.
+type packetHandler func(c *ClientHandler, req request.Request)
+
+// packetHandlers maps packet opcodes to the handlers that act on them. Packets without a handler are decoded and then
+// discarded.
+var packetHandlers = map[byte]packetHandler{}
+
+// registerHandler sets the handler for requests with an opcode.
+func registerHandler[T request.Request](opcode byte, handler func(c *ClientHandler, req T)) {
+	packetHandlers[opcode] = func(c *ClientHandler, req request.Request) {
+		handler(c, req.(T))
+	}
+}
+
+func init() {
+	registerHandler(request.KeepAliveRequestHeader, func(c *ClientHandler, req *request.KeepAliveRequest) {
+		// idle/keep-alive
+		c.lastHeartbeat = time.Now()
+	})
+
+	registerHandler(request.ClientClickRequestHeader, func(c *ClientHandler, req *request.ClientClickRequest) {
+		// the player clicked somewhere on the client window
+		c.game.MarkPlayerActive(c.player)
+	})
+
+	registerHandler(request.CameraModeRequestHeader, func(c *ClientHandler, req *request.CameraModeRequest) {
+		// the player moved their client's camera
+		c.game.MarkPlayerActive(c.player)
+	})
+
+	registerHandler(request.ReportRequestHeader, func(c *ClientHandler, req *request.ReportRequest) {
+		// the player sent an abuse report
+		c.game.ProcessAbuseReport(c.player, req.Username, req.Reason, req.EnableMute)
+	})
+
+	registerHandler(request.PlayerIdleRequestHeader, func(c *ClientHandler, req *request.PlayerIdleRequest) {
+		// the player has become idle
+		c.game.MarkPlayerInactive(c.player)
+	})
+
+	registerHandler(request.PlayerChatRequestHeader, func(c *ClientHandler, req *request.PlayerChatRequest) {
+		// the player sent a chat message
+		c.game.DoPlayerChat(c.player, req.Effect, req.Color, req.Text)
+	})
+
+	registerHandler(request.ChatCommandRequestHeader, func(c *ClientHandler, req *request.ChatCommandRequest) {
+		// the player sent a chat command
+		c.game.DoPlayerChatCommand(c.player, req.Text)
+	})
+
+	registerHandler(request.PrivateChatRequestHeader, func(c *ClientHandler, req *request.PrivateChatRequest) {
+		// the player sent a private chat message
+		c.game.DoPlayerPrivateChat(c.player, req.Recipient, req.Text)
+	})
+
+	registerHandler(request.ChangeModesRequestHeader, func(c *ClientHandler, req *request.ChangeModesRequest) {
+		// the player changed one or more chat or interaction modes
+		c.game.SetPlayerModes(c.player, req.PublicChat, req.PrivateChat, req.Interaction)
+	})
+
+	// the player started walking to a destination on the map
+	walk := func(c *ClientHandler, req *request.WalkRequest) {
+		c.game.WalkPlayer(c.player, req.Start, req.Waypoints)
+	}
+
+	registerHandler(request.WalkRequestHeader, walk)
+	registerHandler(request.WalkOnCommandRequestHeader, walk)
+	registerHandler(request.WalkMinimap, walk)
+
+	registerHandler(request.TakeGroundItemRequestHeader, func(c *ClientHandler, req *request.TakeGroundItemRequest) {
+		// the player tried to pick up a ground item
+		c.game.DoTakeGroundItem(c.player, req.ItemID, req.GlobalPos)
+	})
+
+	registerHandler(request.DropInventoryItemRequestHeader, func(c *ClientHandler, req *request.DropInventoryItemRequest) {
+		// the player dropped an inventory item
+		c.game.DoDropInventoryItem(c.player, req.ItemID, req.InterfaceID, req.SecondaryActionID)
+	})
+
+	registerHandler(request.SwapInventoryItemRequestHeader, func(c *ClientHandler, req *request.SwapInventoryItemRequest) {
+		// the player rearranged an item in their inventory
+		c.game.DoSwapInventoryItem(c.player, req.FromSlot, req.ToSlot, req.InterfaceID)
+	})
+
+	registerHandler(request.EquipItemRequestHeader, func(c *ClientHandler, req *request.EquipItemRequest) {
+		// the player equipped an item from their inventory
+		c.game.DoEquipItem(c.player, req.ItemID, req.InterfaceID, req.SecondaryActionID)
+	})
+
+	registerHandler(request.UnequipItemRequestHeader, func(c *ClientHandler, req *request.UnequipItemRequest) {
+		// the player unequipped an item from their equipment
+		c.game.DoUnequipItem(c.player, req.ItemID, req.InterfaceID, req.SlotType)
+	})
+
+	registerHandler(request.UseItemRequestHeader, func(c *ClientHandler, req *request.UseItemRequest) {
+		// the player initiated the default action on an item
+		c.game.DoUseItem(c.player, req.ItemID, req.InterfaceID, req.ActionID)
+	})
+
+	registerHandler(request.UseInventoryItemsRequestHeader, func(c *ClientHandler, req *request.UseInventoryItemsRequest) {
+		// the player used an inventory item on another
+		c.game.DoUseInventoryItem(c.player, req.SourceItemID, req.SourceInterfaceID, req.SourceSlotID,
+			req.TargetItemID, req.TargetInterfaceID, req.TargetSlotID)
+	})
+
+	registerHandler(request.AddFriendRequestHeader, func(c *ClientHandler, req *request.ModifyFriendRequest) {
+		// the player requested another player be added to their friends list
+		c.game.AddFriend(c.player, req.Username)
+	})
+
+	registerHandler(request.RemoveFriendRequestHeader, func(c *ClientHandler, req *request.ModifyFriendRequest) {
+		// the player requested another player be removed from their friends list
+		c.game.RemoveFriend(c.player, req.Username)
+	})
+
+	registerHandler(request.AddIgnoreRequestHeader, func(c *ClientHandler, req *request.ModifyIgnoreRequest) {
+		// the player requested another player be added to their ignore list
+		c.game.AddIgnored(c.player, req.Username)
+	})
+
+	registerHandler(request.RemoveIgnoreRequestHeader, func(c *ClientHandler, req *request.ModifyIgnoreRequest) {
+		// the player requested another player be removed from their ignore list
+		c.game.RemoveIgnored(c.player, req.Username)
+	})
+
+	registerHandler(request.InterfaceActionRequestHeader, func(c *ClientHandler, req *request.InterfaceActionRequest) {
+		// the player has performed an action on an interface
+		c.game.DoInterfaceAction(c.player, req.Action)
+	})
+
+	registerHandler(request.InteractObjectRequestHeader, func(c *ClientHandler, req *request.InteractObjectRequest) {
+		// the player interacted with an object
+		c.game.DoInteractWithObject(c.player, req.Action, req.GlobalPos)
+	})
+
+	registerHandler(request.CastSpellOnItemRequestHeader, func(c *ClientHandler, req *request.CastSpellOnItemRequest) {
+		// the player cast a spell on an inventory item
+		c.game.DoCastSpellOnItem(c.player, req.SlotID, req.ItemID, req.InventoryInterfaceID, req.SpellInterfaceID)
+	})
+
+	registerHandler(request.CharacterDesignRequestHeader, func(c *ClientHandler, req *request.CharacterDesignRequest) {
+		// the player submitted a new character design
+		c.game.DoSetPlayerDesign(c.player, req.Gender, req.Base, req.BodyColors)
+	})
+
+	registerHandler(request.AttackNPCRequestHeader, func(c *ClientHandler, req *request.AttackNPCRequest) {
+		// the player attacked an npc
+		c.game.DoAttackNPC(c.player, req.TargetID)
+	})
+
+	// the player interacted with an npc
+	interactNPC := func(c *ClientHandler, req *request.InteractWithNPCRequest) {
+		c.game.DoInteractWithNPC(c.player, req.ActionIndex, req.TargetID)
+	}
+
+	registerHandler(request.InteractWithNPCAction1RequestHeader, interactNPC)
+	registerHandler(request.InteractWithNPCAction2RequestHeader, interactNPC)
+	registerHandler(request.InteractWithNPCAction3RequestHeader, interactNPC)
+	registerHandler(request.InteractWithNPCAction4RequestHeader, interactNPC)
+}
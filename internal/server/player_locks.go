@@ -0,0 +1,58 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/model"
+	"sync"
+)
+
+// PlayerLocks serializes work on each player's persistent data across client connections. A client holds the lock for
+// a username while the player is loaded during login, and while they are saved once their client disconnects, so a
+// player who logs in again is never loaded before their previous session has been saved.
+type PlayerLocks struct {
+	locks map[string]*playerLock
+	mu    sync.Mutex
+}
+
+// playerLock is the lock for a single username, along with the number of clients holding or waiting on it.
+type playerLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewPlayerLocks creates a set of per-username locks.
+func NewPlayerLocks() *PlayerLocks {
+	return &PlayerLocks{
+		locks: map[string]*playerLock{},
+	}
+}
+
+// Lock blocks until no other client holds the lock for a username, and returns a function that releases it. Usernames
+// that only differ in case, or in spaces and underscores, share the same lock.
+func (l *PlayerLocks) Lock(username string) func() {
+	key := model.NormalizeUsername(username)
+
+	l.mu.Lock()
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &playerLock{}
+		l.locks[key] = lock
+	}
+
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		// forget about the lock once no client needs it anymore
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
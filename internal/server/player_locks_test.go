@@ -0,0 +1,38 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_PlayerLocks_Lock(t *testing.T) {
+	l := NewPlayerLocks()
+
+	unlock := l.Lock("Mike_Smith")
+
+	// other usernames are not blocked
+	l.Lock("Hurz")()
+
+	locked := make(chan func())
+	go func() {
+		locked <- l.Lock("mike smith")
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected lock for the same username to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case unlock = <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("expected lock to be acquired once released")
+	}
+
+	unlock()
+	assert.Empty(t, l.locks)
+}
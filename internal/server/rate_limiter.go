@@ -0,0 +1,114 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"math"
+	"time"
+)
+
+// rateLimit identifies a limit on the number of packets a client may send.
+type rateLimit string
+
+const (
+	// rateLimitConnection limits the rate of all packets sent over a connection.
+	rateLimitConnection rateLimit = "connection"
+	// rateLimitOpcode limits the rate of packets with a particular opcode.
+	rateLimitOpcode rateLimit = "opcode"
+	// rateLimitTick limits the number of packets sent during a single game tick.
+	rateLimitTick rateLimit = "tick"
+)
+
+// tokenBucket is a rate limiter that allows a sustained rate of events, with occasional bursts above that rate.
+type tokenBucket struct {
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+// newTokenBucket returns a full token bucket that refills at a rate of tokens per second.
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: now,
+	}
+}
+
+// take consumes a token from the bucket, returning false if none are available.
+func (b *tokenBucket) take(now time.Time) bool {
+	elapsed := now.Sub(b.lastFill).Seconds()
+	b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	b.lastFill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// RateLimiter tracks the packets sent by a single client connection, and determines if the client has exceeded any of
+// its limits. A RateLimiter is not safe for concurrent use.
+type RateLimiter struct {
+	connection     *tokenBucket
+	opcodes        map[byte]*tokenBucket
+	packetsPerTick int
+	tick           uint64
+	tickPackets    int
+	now            func() time.Time
+}
+
+// NewRateLimiter returns a rate limiter for a client connection.
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return newRateLimiter(cfg, time.Now)
+}
+
+// newRateLimiter returns a rate limiter that uses a clock function to determine the current time.
+func newRateLimiter(cfg config.RateLimitConfig, now func() time.Time) *RateLimiter {
+	start := now()
+
+	r := &RateLimiter{
+		opcodes:        map[byte]*tokenBucket{},
+		packetsPerTick: cfg.PacketsPerTick,
+		now:            now,
+	}
+
+	if cfg.PacketsPerSecond > 0 {
+		r.connection = newTokenBucket(cfg.PacketsPerSecond, max(cfg.Burst, 1), start)
+	}
+
+	for _, op := range cfg.Opcodes {
+		r.opcodes[byte(op.Opcode)] = newTokenBucket(op.PacketsPerSecond, max(op.Burst, 1), start)
+	}
+
+	return r
+}
+
+// Allow records a packet received during a game tick, and returns the limit that was exceeded by it, if any.
+func (r *RateLimiter) Allow(opcode byte, tick uint64) (rateLimit, bool) {
+	now := r.now()
+
+	// reset the per-tick budget once the game state has advanced
+	if tick != r.tick {
+		r.tick = tick
+		r.tickPackets = 0
+	}
+
+	r.tickPackets++
+	if r.packetsPerTick > 0 && r.tickPackets > r.packetsPerTick {
+		return rateLimitTick, false
+	}
+
+	if r.connection != nil && !r.connection.take(now) {
+		return rateLimitConnection, false
+	}
+
+	if bucket, ok := r.opcodes[opcode]; ok && !bucket.take(now) {
+		return rateLimitOpcode, false
+	}
+
+	return "", true
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_RateLimiter_Allow_connection(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newRateLimiter(config.RateLimitConfig{PacketsPerSecond: 2, Burst: 2}, func() time.Time { return now })
+
+	for i := 0; i < 2; i++ {
+		_, ok := r.Allow(0x00, uint64(i))
+		assert.True(t, ok)
+	}
+
+	limit, ok := r.Allow(0x00, 2)
+	assert.False(t, ok)
+	assert.Equal(t, rateLimitConnection, limit)
+
+	// a token is refilled after half a second
+	now = now.Add(500 * time.Millisecond)
+	_, ok = r.Allow(0x00, 3)
+	assert.True(t, ok)
+}
+
+func Test_RateLimiter_Allow_opcode(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newRateLimiter(config.RateLimitConfig{
+		Opcodes: []config.OpcodeRateLimitConfig{{Opcode: 0x04, PacketsPerSecond: 1, Burst: 1}},
+	}, func() time.Time { return now })
+
+	_, ok := r.Allow(0x04, 0)
+	assert.True(t, ok)
+
+	// other opcodes are not affected by the limit
+	_, ok = r.Allow(0x05, 0)
+	assert.True(t, ok)
+
+	limit, ok := r.Allow(0x04, 0)
+	assert.False(t, ok)
+	assert.Equal(t, rateLimitOpcode, limit)
+}
+
+func Test_RateLimiter_Allow_tick(t *testing.T) {
+	r := newRateLimiter(config.RateLimitConfig{PacketsPerTick: 2}, time.Now)
+
+	for i := 0; i < 2; i++ {
+		_, ok := r.Allow(0x00, 1)
+		assert.True(t, ok)
+	}
+
+	limit, ok := r.Allow(0x00, 1)
+	assert.False(t, ok)
+	assert.Equal(t, rateLimitTick, limit)
+
+	// the budget is reset on the next tick
+	_, ok = r.Allow(0x00, 2)
+	assert.True(t, ok)
+}
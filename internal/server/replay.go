@@ -0,0 +1,77 @@
+package server
+
+import (
+	"bytes"
+	"github.com/mbpolan/openmcs/internal/game"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/protocol/r317"
+	"github.com/mbpolan/openmcs/internal/recording"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// Replay feeds the inbound packets of a recorded session to a game whose game loop is not running. Before each packet
+// is processed, the game state is advanced to the tick at which the packet was originally received. All responses
+// that the game sends to the player are recorded to w.
+func Replay(g *game.Game, session *recording.Session, w io.Writer) error {
+	recorder, err := recording.NewRecorder(w, session.Player, session.LowMemory, g.Tick)
+	if err != nil {
+		return errors.Wrap(err, "failed to create recorder")
+	}
+
+	// the handler is not backed by a connection, and only used to dispatch decoded requests. sessions are recorded
+	// using the server's opcodes, so they are always decoded with the 317 revision.
+	c := &ClientHandler{
+		game:     g,
+		player:   session.Player,
+		revision: r317.New(),
+		state:    active,
+	}
+
+	start := g.Tick()
+	g.AddPlayer(session.Player, session.LowMemory, network.NewWriter(io.Discard), recorder)
+
+	for i, packet := range session.Packets {
+		if packet.Direction != recording.Inbound {
+			continue
+		}
+
+		// advance the game state up to the tick the packet was received on
+		for g.Tick()-start < packet.Tick {
+			err := g.Step()
+			if err != nil {
+				return errors.Wrapf(err, "failed to update game state on tick %d", g.Tick()-start)
+			}
+		}
+
+		// decode the packet from its original bytes and dispatch it to its handler
+		data := append([]byte{packet.Opcode}, packet.Payload...)
+		_, req, err := c.revision.Requests().Decode(network.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode packet %d (opcode %2x)", i, packet.Opcode)
+		}
+
+		if req == nil {
+			continue
+		}
+
+		if handler, ok := packetHandlers[packet.Opcode]; ok {
+			handler(c, req)
+		}
+	}
+
+	// process the last tick and wait until all responses have been sent before removing the player
+	err = g.Step()
+	if err != nil {
+		return errors.Wrap(err, "failed to update game state")
+	}
+
+	g.FlushPlayer(session.Player)
+	err = recorder.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to write recording")
+	}
+
+	g.RemovePlayer(session.Player)
+	return g.Step()
+}
@@ -2,16 +2,28 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
 	"fmt"
+	"github.com/mbpolan/openmcs/internal/asset"
+	"github.com/mbpolan/openmcs/internal/auth"
 	"github.com/mbpolan/openmcs/internal/config"
 	"github.com/mbpolan/openmcs/internal/game"
 	"github.com/mbpolan/openmcs/internal/logger"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/moderation"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/mbpolan/openmcs/internal/protocol"
+	"github.com/mbpolan/openmcs/internal/protocol/r317"
 	"github.com/mbpolan/openmcs/internal/store"
+	"github.com/mbpolan/openmcs/internal/store/playerdata"
 	"github.com/mbpolan/openmcs/internal/telemetry"
 	"github.com/mbpolan/openmcs/internal/util"
 	"github.com/pkg/errors"
 	"net"
 	"sync"
+	"time"
 )
 
 // Options contains parameters to configure a Server instance.
@@ -22,17 +34,25 @@ type Options struct {
 
 // Server provides the network infrastructure for a game and login server.
 type Server struct {
-	config      *config.Config
-	bindAddress string
-	clients     []*ClientHandler
-	closeChan   chan *ClientHandler
-	store       *store.Store
-	doneChan    chan bool
-	listener    net.Listener
-	game        *game.Game
-	mu          sync.Mutex
-	sessionKey  uint64
-	telemetry   telemetry.Telemetry
+	assets        *asset.Manager
+	autosaver     *Autosaver
+	config        *config.Config
+	bindAddress   string
+	clients       []*ClientHandler
+	closeChan     chan *ClientHandler
+	store         *store.Store
+	doneChan      chan bool
+	listener      net.Listener
+	loginThrottle *LoginThrottle
+	hasher        auth.PasswordHasher
+	accounts      *auth.AccountManager
+	bans          *moderation.BanList
+	game          *game.Game
+	mu            sync.Mutex
+	playerLocks   *PlayerLocks
+	privateKey    *rsa.PrivateKey
+	revisions     protocol.Set
+	telemetry     telemetry.Telemetry
 }
 
 // New creates a server instance with a configuration.
@@ -44,7 +64,8 @@ func New(opts Options) (*Server, error) {
 		config:      opts.Config,
 		doneChan:    make(chan bool, 1),
 		mu:          sync.Mutex{},
-		sessionKey:  0,
+		playerLocks: NewPlayerLocks(),
+		revisions:   protocol.NewSet(r317.New()),
 		telemetry:   opts.Telemetry,
 	}, nil
 }
@@ -73,17 +94,79 @@ func (s *Server) Run() error {
 		return errors.Wrap(err, "failed running persistent store migrations")
 	}
 
+	// prepare the asset manager for serving game cache files to clients
+	s.assets = asset.NewManager(s.config.Server.AssetDir)
+	defer s.assets.Close()
+
+	// compute archive checksums up front so that clients with outdated caches can be rejected at login
+	_, err = s.assets.ArchiveCRCs()
+	if err != nil {
+		return errors.Wrap(err, "failed to compute archive checksums")
+	}
+
+	// start serving cache archives to clients that fetch them before connecting to the game server
+	if s.config.JAGGRAB.Enabled {
+		jaggrab := NewJAGGRABServer(s.config.Server.Host, s.config.JAGGRAB, s.assets)
+		err = jaggrab.Start()
+		if err != nil {
+			return errors.Wrap(err, "failed to start jaggrab server")
+		}
+
+		defer jaggrab.Stop()
+	}
+
+	// load the rsa key used to decrypt login requests, if one is configured
+	if s.config.Server.RSAKeyFile != "" {
+		s.privateKey, err = network.LoadRSAPrivateKey(s.config.Server.RSAKeyFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to load rsa private key")
+		}
+	} else {
+		logger.Warnf("no rsa key configured; login requests are expected in plaintext")
+	}
+
+	// track failed login attempts in the persistent store
+	s.loginThrottle = NewLoginThrottle(s.config.Login, s.store)
+
+	// prepare the password hasher for verifying and upgrading player passwords
+	s.hasher, err = auth.NewPasswordHasher(s.config.Login.PasswordHashing)
+	if err != nil {
+		return errors.Wrap(err, "failed to create password hasher")
+	}
+
+	s.accounts = auth.NewAccountManager(s.config.Registration, s.hasher, s.store)
+	s.bans = moderation.NewBanList(s.store)
+
 	// load server-side game data
 	attributes, err := s.store.LoadItemAttributes()
 	if err != nil {
 		return errors.Wrap(err, "failed to load item attributes")
 	}
 
+	// restore the world state saved when the server last stopped
+	var worldState *model.WorldState
+	if s.config.Store.PersistWorldState {
+		worldState, err = s.store.LoadWorldState(s.config.Server.WorldID)
+		if err != nil {
+			return errors.Wrap(err, "failed to load world state")
+		}
+
+		logger.Infof("restoring %d ground items", len(worldState.GroundItems))
+	}
+
+	// take a snapshot of players each time they are saved, so they can be rolled back
+	history := playerdata.NewHistory(s.store.Driver(), s.config.Store.SnapshotRetention)
+
 	// create a new game engine instance
 	s.game, err = game.NewGame(game.Options{
+		Bans:           s.bans,
 		Config:         s.config,
 		ItemAttributes: attributes,
+		Mutes:          moderation.NewMuteList(s.store),
+		Reports:        moderation.NewReportQueue(s.store),
+		Snapshots:      history,
 		Telemetry:      s.telemetry,
+		WorldState:     worldState,
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed creating game world")
@@ -98,6 +181,19 @@ func (s *Server) Run() error {
 	// start the game engine loop
 	s.game.Run()
 
+	// periodically save players and the world state, and save them once more after the game engine has stopped
+	s.autosaver = NewAutosaver(AutosaverOptions{
+		Game:              s.game,
+		History:           history,
+		Interval:          time.Duration(s.config.Store.AutosaveSeconds) * time.Second,
+		PersistWorldState: s.config.Store.PersistWorldState,
+		Store:             s.store,
+		Telemetry:         s.telemetry,
+		WorldID:           s.config.Server.WorldID,
+	})
+	s.autosaver.Start()
+	defer s.autosaver.Stop()
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer s.listener.Close()
 	defer s.game.Stop()
@@ -105,6 +201,17 @@ func (s *Server) Run() error {
 
 	go s.cleanUpHandler(ctx)
 
+	// start accepting connections from clients that connect over websocket
+	if s.config.WebSocket.Enabled {
+		ws := NewWebSocketServer(s.config.Server.Host, s.config.WebSocket, s.accept)
+		err = ws.Start()
+		if err != nil {
+			return errors.Wrap(err, "failed to start websocket server")
+		}
+
+		defer ws.Stop()
+	}
+
 	logger.Infof("server listening on %s", s.bindAddress)
 
 	for {
@@ -119,14 +226,56 @@ func (s *Server) Run() error {
 			}
 		}
 
-		client := NewClientHandler(conn, s.closeChan, s.store, s.game, s.sessionKey)
+		s.accept(conn)
+	}
+}
 
-		s.mu.Lock()
-		s.clients = append(s.clients, client)
-		s.mu.Unlock()
+// accept creates a handler for a new client connection and begins processing its requests.
+func (s *Server) accept(conn net.Conn) {
+	// each connection is issued a unique session key, which the client uses as part of its cipher seeds
+	sessionKey, err := newSessionKey()
+	if err != nil {
+		logger.Errorf("failed to generate session key: %s", err)
+		_ = conn.Close()
+		return
+	}
+
+	client := NewClientHandler(conn, ClientHandlerOptions{
+		Accounts:       s.accounts,
+		Assets:         s.assets,
+		Autosaver:      s.autosaver,
+		AutoRegister:   s.config.Registration.AutoRegister,
+		Bans:           s.bans,
+		CloseChan:      s.closeChan,
+		Game:           s.game,
+		LoginThrottle:  s.loginThrottle,
+		PasswordHasher: s.hasher,
+		PlayerLocks:    s.playerLocks,
+		PrivateKey:     s.privateKey,
+		RateLimit:      s.config.RateLimit,
+		RecordingDir:   s.config.Server.RecordingDir,
+		Revisions:      s.revisions,
+		SessionKey:     sessionKey,
+		Store:          s.store,
+		Telemetry:      s.telemetry,
+	})
 
-		go client.Handle()
+	s.mu.Lock()
+	s.clients = append(s.clients, client)
+	s.mu.Unlock()
+
+	go client.Handle()
+}
+
+// newSessionKey returns a random session key. Session keys seed a client's ciphers, so they must not be predictable.
+func newSessionKey() (uint64, error) {
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return 0, err
 	}
+
+	return binary.BigEndian.Uint64(b[:]), nil
 }
 
 func (s *Server) cleanUpHandler(ctx context.Context) {
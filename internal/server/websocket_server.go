@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/logger"
+	"github.com/mbpolan/openmcs/internal/network"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WebSocketServer accepts game connections from clients that connect over WebSocket instead of TCP, such as clients
+// running in a web browser. Each connection is adapted into a net.Conn and handed off to the game server.
+type WebSocketServer struct {
+	accept      func(conn net.Conn)
+	bindAddress string
+	httpServer  *http.Server
+	path        string
+	upgrader    websocket.Upgrader
+}
+
+// NewWebSocketServer creates a new WebSocket server that passes each established connection to an accept function.
+func NewWebSocketServer(host string, cfg config.WebSocketConfig, accept func(conn net.Conn)) *WebSocketServer {
+	s := &WebSocketServer{
+		accept:      accept,
+		bindAddress: fmt.Sprintf("%s:%d", host, cfg.Port),
+		path:        cfg.Path,
+	}
+
+	if s.path == "" {
+		s.path = "/"
+	}
+
+	// the upgrader only allows same-origin requests by default, unless a list of origins is configured
+	if len(cfg.AllowedOrigins) > 0 {
+		allowed := map[string]bool{}
+		for _, origin := range cfg.AllowedOrigins {
+			allowed[origin] = true
+		}
+
+		s.upgrader.CheckOrigin = func(r *http.Request) bool {
+			return allowed[r.Header.Get("Origin")]
+		}
+	}
+
+	return s
+}
+
+// Start begins listening for WebSocket connections.
+func (s *WebSocketServer) Start() error {
+	listener, err := net.Listen("tcp", s.bindAddress)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen on websocket socket")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.path, s.handleUpgrade)
+
+	s.httpServer = &http.Server{
+		Handler: mux,
+	}
+
+	go func() {
+		err := s.httpServer.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("failed to serve websocket connections: %s", err)
+		}
+	}()
+
+	logger.Infof("websocket server listening on %s%s", s.bindAddress, s.path)
+	return nil
+}
+
+// Stop terminates the WebSocket server. Connections that were already established are not affected.
+func (s *WebSocketServer) Stop() {
+	if s.httpServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = s.httpServer.Shutdown(ctx)
+}
+
+// handleUpgrade upgrades an HTTP request to a WebSocket connection.
+func (s *WebSocketServer) handleUpgrade(w http.ResponseWriter, r *http.Request) {
+	// the upgrader writes an error response to the client if the request is rejected
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Debugf("failed to upgrade websocket connection: %s", err)
+		return
+	}
+
+	s.accept(network.NewWebSocketConn(conn))
+}
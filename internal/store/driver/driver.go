@@ -3,11 +3,13 @@ package driver
 import (
 	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/mbpolan/openmcs/internal/model"
+	"time"
 )
 
 // Driver is an interface for a driver that interfaces with a backend database.
 type Driver interface {
-	// Migration returns a handle to the underlying store for use with migrations.
+	// Migration returns a handle to the underlying store for use with migrations. If the driver has no schema, nil is
+	// returned.
 	Migration() (database.Driver, error)
 
 	// LoadItemAttributes loads information about all item attributes.
@@ -16,9 +18,85 @@ type Driver interface {
 	// SavePlayer saves data about a player.
 	SavePlayer(p *model.Player) error
 
-	// LoadPlayer loads data about a player with a username.
+	// LoadPlayer loads data about a player with a username. Usernames are compared by their normalized form, so a
+	// username matches one that differs only in case or in its use of spaces and underscores.
 	LoadPlayer(username string) (*model.Player, error)
 
+	// CreatePlayer creates a new player, and assigns their ID. An error is returned if another player has a username
+	// with the same normalized form.
+	CreatePlayer(p *model.Player) error
+
+	// ReplacePlayer overwrites the account details and all data of an existing player with the same ID in a single
+	// operation. The player's snapshots, and their place on the friends and ignored lists of others, are kept.
+	ReplacePlayer(p *model.Player) error
+
+	// DeletePlayer removes a player and all of their data.
+	DeletePlayer(playerID int) error
+
+	// SavePasswordHash updates the hash of a player's password.
+	SavePasswordHash(playerID int, hash string) error
+
+	// SavePlayerSnapshot creates a new snapshot of a player's data and assigns its ID. Only the most recent snapshots
+	// of the player, up to the retain amount, are kept.
+	SavePlayerSnapshot(snapshot *model.PlayerSnapshot, retain int) error
+
+	// LoadPlayerSnapshots loads all snapshots of a player without their data, newest first.
+	LoadPlayerSnapshots(playerID int) ([]*model.PlayerSnapshot, error)
+
+	// LoadPlayerSnapshot loads a snapshot of a player along with its data. If the player has no snapshot with the ID,
+	// nil is returned.
+	LoadPlayerSnapshot(playerID, snapshotID int) (*model.PlayerSnapshot, error)
+
+	// SavePendingRollback records a snapshot that a player is rolled back to the next time they log in. A snapshot ID
+	// of zero clears the pending rollback.
+	SavePendingRollback(playerID, snapshotID int) error
+
+	// LoadPendingRollback loads the ID of the snapshot that a player is rolled back to the next time they log in. If
+	// no rollback is pending, zero is returned.
+	LoadPendingRollback(playerID int) (int, error)
+
+	// LoadLoginAttempts loads failed login attempts tracked against a username or network address. If no attempts
+	// have been tracked, nil is returned.
+	LoadLoginAttempts(source model.LoginAttemptSource, key string) (*model.LoginAttempts, error)
+
+	// SaveLoginAttempts saves failed login attempts tracked against a username or network address.
+	SaveLoginAttempts(source model.LoginAttemptSource, key string, attempts *model.LoginAttempts) error
+
+	// DeleteLoginAttempts removes all failed login attempts tracked against a username or network address.
+	DeleteLoginAttempts(source model.LoginAttemptSource, key string) error
+
+	// SaveLoginAudit records a login attempt.
+	SaveLoginAudit(entry *model.LoginAuditEntry) error
+
+	// LoadBans loads all bans applied against a username, network address or client identifier.
+	LoadBans(target model.BanTarget, value string) ([]*model.Ban, error)
+
+	// SaveBan creates a new ban, and assigns its ID.
+	SaveBan(ban *model.Ban) error
+
+	// DeleteBans removes all bans applied against a username, network address or client identifier, returning the
+	// number of bans that were removed.
+	DeleteBans(target model.BanTarget, value string) (int, error)
+
+	// SaveMute updates whether a player is muted, and when their mute ends. A nil expiry makes the mute permanent. If
+	// no player has the username, false is returned.
+	SaveMute(username string, muted bool, expiresAt *time.Time) (bool, error)
+
+	// SaveAbuseReport creates a new abuse report along with its attached chat messages, and assigns its ID.
+	SaveAbuseReport(report *model.AbuseReport) error
+
+	// LoadOpenAbuseReports loads all abuse reports that have not been resolved, oldest first.
+	LoadOpenAbuseReports() ([]*model.AbuseReport, error)
+
+	// ResolveAbuseReport marks an open abuse report as resolved. If no open report has the ID, false is returned.
+	ResolveAbuseReport(id int, resolvedBy string, resolvedAt time.Time) (bool, error)
+
+	// LoadWorldState loads the mutable state of a game world's map, such as ground items.
+	LoadWorldState(worldID int) (*model.WorldState, error)
+
+	// SaveWorldState replaces the mutable state of a game world's map.
+	SaveWorldState(worldID int, state *model.WorldState) error
+
 	// Close cleans up resources used by the driver.
 	Close() error
 }
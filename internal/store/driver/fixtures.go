@@ -0,0 +1,399 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Fixtures is a set of data that an in-memory driver is preloaded with. Fixtures can be written in YAML or JSON.
+type Fixtures struct {
+	Items         []ItemFixture         `json:"items" yaml:"items"`
+	Players       []PlayerFixture       `json:"players" yaml:"players"`
+	LoginAttempts []LoginAttemptFixture `json:"loginAttempts" yaml:"loginAttempts"`
+	Bans          []BanFixture          `json:"bans" yaml:"bans"`
+	AbuseReports  []AbuseReportFixture  `json:"abuseReports" yaml:"abuseReports"`
+	GroundItems   []GroundItemFixture   `json:"groundItems" yaml:"groundItems"`
+}
+
+// ItemFixture contains the attributes of an item. Equipment slots and weapon styles use the same values as the
+// ITEM_ATTRIBUTES database table.
+type ItemFixture struct {
+	ItemID        int                    `json:"itemId" yaml:"itemId"`
+	EquipSlot     *int                   `json:"equipSlot" yaml:"equipSlot"`
+	WeaponStyle   string                 `json:"weaponStyle" yaml:"weaponStyle"`
+	Speed         int                    `json:"speed" yaml:"speed"`
+	Weight        float32                `json:"weight" yaml:"weight"`
+	Value         int                    `json:"value" yaml:"value"`
+	Attack        CombatAttributeFixture `json:"attack" yaml:"attack"`
+	Defense       CombatAttributeFixture `json:"defense" yaml:"defense"`
+	StrengthBonus int                    `json:"strengthBonus" yaml:"strengthBonus"`
+	PrayerBonus   int                    `json:"prayerBonus" yaml:"prayerBonus"`
+}
+
+// CombatAttributeFixture contains the offensive or defensive combat bonuses of an item.
+type CombatAttributeFixture struct {
+	Stab  int `json:"stab" yaml:"stab"`
+	Slash int `json:"slash" yaml:"slash"`
+	Crush int `json:"crush" yaml:"crush"`
+	Magic int `json:"magic" yaml:"magic"`
+	Range int `json:"range" yaml:"range"`
+}
+
+// PlayerFixture contains a player account and their persistent data. If no ID is given, the player is assigned the
+// next available ID.
+type PlayerFixture struct {
+	ID            int                 `json:"id" yaml:"id"`
+	Username      string              `json:"username" yaml:"username"`
+	PasswordHash  string              `json:"passwordHash" yaml:"passwordHash"`
+	Type          int                 `json:"type" yaml:"type"`
+	Position      PositionFixture     `json:"position" yaml:"position"`
+	Gender        int                 `json:"gender" yaml:"gender"`
+	Appearance    *AppearanceFixture  `json:"appearance" yaml:"appearance"`
+	UpdateDesign  bool                `json:"updateDesign" yaml:"updateDesign"`
+	Flagged       bool                `json:"flagged" yaml:"flagged"`
+	Muted         bool                `json:"muted" yaml:"muted"`
+	MuteExpiry    *time.Time          `json:"muteExpiry" yaml:"muteExpiry"`
+	Member        bool                `json:"member" yaml:"member"`
+	MemberExpiry  *time.Time          `json:"memberExpiry" yaml:"memberExpiry"`
+	Running       bool                `json:"running" yaml:"running"`
+	RunEnergy     *int                `json:"runEnergy" yaml:"runEnergy"`
+	AutoRetaliate bool                `json:"autoRetaliate" yaml:"autoRetaliate"`
+	Modes         ModesFixture        `json:"modes" yaml:"modes"`
+	Skills        []SkillFixture      `json:"skills" yaml:"skills"`
+	Inventory     []SlotFixture       `json:"inventory" yaml:"inventory"`
+	Equipment     []SlotFixture       `json:"equipment" yaml:"equipment"`
+	Friends       []string            `json:"friends" yaml:"friends"`
+	Ignored       []string            `json:"ignored" yaml:"ignored"`
+	GameOptions   map[int]string      `json:"gameOptions" yaml:"gameOptions"`
+	QuestStatuses map[int]int         `json:"questStatuses" yaml:"questStatuses"`
+	QuestFlags    map[int]map[int]int `json:"questFlags" yaml:"questFlags"`
+	MusicTracks   map[int]bool        `json:"musicTracks" yaml:"musicTracks"`
+}
+
+// PositionFixture is a position in global coordinates.
+type PositionFixture struct {
+	X int `json:"x" yaml:"x"`
+	Y int `json:"y" yaml:"y"`
+	Z int `json:"z" yaml:"z"`
+}
+
+// AppearanceFixture contains the body parts of a player's model.
+type AppearanceFixture struct {
+	Head  int `json:"head" yaml:"head"`
+	Face  int `json:"face" yaml:"face"`
+	Body  int `json:"body" yaml:"body"`
+	Arms  int `json:"arms" yaml:"arms"`
+	Hands int `json:"hands" yaml:"hands"`
+	Legs  int `json:"legs" yaml:"legs"`
+	Feet  int `json:"feet" yaml:"feet"`
+}
+
+// ModesFixture contains a player's chat and interaction settings.
+type ModesFixture struct {
+	PublicChat  int `json:"publicChat" yaml:"publicChat"`
+	PrivateChat int `json:"privateChat" yaml:"privateChat"`
+	Interaction int `json:"interaction" yaml:"interaction"`
+}
+
+// SkillFixture contains a player's progress in a skill. Either a level or an amount of experience may be given; if
+// both are omitted the skill is left at level 1.
+type SkillFixture struct {
+	Skill      int     `json:"skill" yaml:"skill"`
+	Level      int     `json:"level" yaml:"level"`
+	Experience float64 `json:"experience" yaml:"experience"`
+}
+
+// SlotFixture contains an item in an inventory or equipment slot. Equipment slots use the same values as the
+// PLAYER_EQUIPMENT database table.
+type SlotFixture struct {
+	Slot   int `json:"slot" yaml:"slot"`
+	ItemID int `json:"itemId" yaml:"itemId"`
+	Amount int `json:"amount" yaml:"amount"`
+}
+
+// LoginAttemptFixture contains failed login attempts tracked against a username or network address.
+type LoginAttemptFixture struct {
+	Source      int       `json:"source" yaml:"source"`
+	Key         string    `json:"key" yaml:"key"`
+	Failures    int       `json:"failures" yaml:"failures"`
+	Lockouts    int       `json:"lockouts" yaml:"lockouts"`
+	LastFailure time.Time `json:"lastFailure" yaml:"lastFailure"`
+	LockedUntil time.Time `json:"lockedUntil" yaml:"lockedUntil"`
+}
+
+// BanFixture contains a ban against a username, network address or client identifier.
+type BanFixture struct {
+	Target    int        `json:"target" yaml:"target"`
+	Value     string     `json:"value" yaml:"value"`
+	Reason    string     `json:"reason" yaml:"reason"`
+	IssuedBy  string     `json:"issuedBy" yaml:"issuedBy"`
+	IssuedAt  time.Time  `json:"issuedAt" yaml:"issuedAt"`
+	ExpiresAt *time.Time `json:"expiresAt" yaml:"expiresAt"`
+}
+
+// AbuseReportFixture contains an abuse report filed against a player.
+type AbuseReportFixture struct {
+	Reporter   string        `json:"reporter" yaml:"reporter"`
+	Offender   string        `json:"offender" yaml:"offender"`
+	Reason     int           `json:"reason" yaml:"reason"`
+	Muted      bool          `json:"muted" yaml:"muted"`
+	Chat       []ChatFixture `json:"chat" yaml:"chat"`
+	CreatedAt  time.Time     `json:"createdAt" yaml:"createdAt"`
+	ResolvedBy string        `json:"resolvedBy" yaml:"resolvedBy"`
+	ResolvedAt *time.Time    `json:"resolvedAt" yaml:"resolvedAt"`
+}
+
+// ChatFixture contains a chat message attached to an abuse report.
+type ChatFixture struct {
+	Recipient string    `json:"recipient" yaml:"recipient"`
+	Text      string    `json:"text" yaml:"text"`
+	SentAt    time.Time `json:"sentAt" yaml:"sentAt"`
+}
+
+// GroundItemFixture contains an item placed on the ground in a game world. If no remaining lifetime is given, the item
+// stays on the ground indefinitely.
+type GroundItemFixture struct {
+	WorldID          int             `json:"worldId" yaml:"worldId"`
+	ItemID           int             `json:"itemId" yaml:"itemId"`
+	Amount           int             `json:"amount" yaml:"amount"`
+	Position         PositionFixture `json:"position" yaml:"position"`
+	RemainingSeconds *int            `json:"remainingSeconds" yaml:"remainingSeconds"`
+}
+
+// LoadFixtures reads fixtures from a YAML or JSON file, based on the file's extension.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures Fixtures
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fixtures)
+	case ".json":
+		err = json.Unmarshal(data, &fixtures)
+	default:
+		return nil, fmt.Errorf("unsupported fixture file: %s", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fixture file %s: %w", path, err)
+	}
+
+	return &fixtures, nil
+}
+
+// toModel converts the item fixture into item attributes.
+func (f ItemFixture) toModel() (*model.ItemAttributes, error) {
+	attributes := &model.ItemAttributes{
+		ItemID:        f.ItemID,
+		Nature:        model.ItemNatureNotUsable,
+		EquipSlotType: model.EquipmentSlotTypeHead,
+		WeaponStyle:   model.WeaponStyleUnarmed,
+		Speed:         f.Speed,
+		Weight:        f.Weight,
+		Value:         f.Value,
+		Attack:        f.Attack.toModel(),
+		Defense:       f.Defense.toModel(),
+		StrengthBonus: f.StrengthBonus,
+		PrayerBonus:   f.PrayerBonus,
+	}
+
+	if f.EquipSlot != nil {
+		slot, ok := slotIDsToEquipmentSlots[*f.EquipSlot]
+		if !ok {
+			return nil, fmt.Errorf("item %d: slot ID out of bounds: %d", f.ItemID, *f.EquipSlot)
+		}
+
+		attributes.Nature = model.ItemNatureEquippable
+		attributes.EquipSlotType = slot
+	}
+
+	if f.WeaponStyle != "" {
+		style, ok := weaponStyleValues[strings.ToUpper(f.WeaponStyle)]
+		if !ok {
+			return nil, fmt.Errorf("item %d: unknown weapon style: %s", f.ItemID, f.WeaponStyle)
+		}
+
+		attributes.WeaponStyle = style
+	}
+
+	return attributes, nil
+}
+
+// toModel converts the combat attribute fixture into item combat attributes.
+func (f CombatAttributeFixture) toModel() model.ItemCombatAttributes {
+	return model.ItemCombatAttributes{
+		Stab:  f.Stab,
+		Slash: f.Slash,
+		Crush: f.Crush,
+		Magic: f.Magic,
+		Range: f.Range,
+	}
+}
+
+// toModel converts the player fixture into a player.
+func (f PlayerFixture) toModel() (*model.Player, error) {
+	if f.Username == "" {
+		return nil, fmt.Errorf("player %d: missing username", f.ID)
+	}
+
+	p := model.NewPlayer(f.Username)
+	p.ID = f.ID
+	p.PasswordHash = f.PasswordHash
+	p.Type = model.PlayerType(f.Type)
+	p.GlobalPos = model.Vector3D{X: f.Position.X, Y: f.Position.Y, Z: f.Position.Z}
+	p.Appearance.Gender = model.EntityGender(f.Gender)
+	p.UpdateDesign = f.UpdateDesign
+	p.Flagged = f.Flagged
+	p.Muted = f.Muted
+	p.MuteExpiry = f.MuteExpiry
+	p.Member = f.Member
+	p.MemberExpiry = f.MemberExpiry
+	p.AutoRetaliate = f.AutoRetaliate
+	p.Modes = model.PlayerModes{
+		PublicChat:  model.ChatMode(f.Modes.PublicChat),
+		PrivateChat: model.ChatMode(f.Modes.PrivateChat),
+		Interaction: model.InteractionMode(f.Modes.Interaction),
+	}
+	p.Friends = append([]string(nil), f.Friends...)
+	p.Ignored = append([]string(nil), f.Ignored...)
+
+	if f.Running {
+		p.MovementSpeed = model.MovementSpeedRun
+	}
+
+	if f.RunEnergy != nil {
+		p.RunEnergy = *f.RunEnergy
+	}
+
+	if f.Appearance != nil {
+		p.Appearance.Base = model.EntityBase{
+			Head:  f.Appearance.Head,
+			Face:  f.Appearance.Face,
+			Body:  f.Appearance.Body,
+			Arms:  f.Appearance.Arms,
+			Hands: f.Appearance.Hands,
+			Legs:  f.Appearance.Legs,
+			Feet:  f.Appearance.Feet,
+		}
+	}
+
+	for _, skill := range f.Skills {
+		skillType := model.SkillType(skill.Skill)
+		if _, ok := p.Skills[skillType]; !ok {
+			return nil, fmt.Errorf("player %s: unknown skill: %d", f.Username, skill.Skill)
+		}
+
+		experience := skill.Experience
+		if skill.Level > 0 {
+			if skill.Level > 99 {
+				return nil, fmt.Errorf("player %s: skill level out of bounds: %d", f.Username, skill.Level)
+			}
+
+			experience = model.SkillExperienceLevels[skill.Level]
+		}
+
+		// start the stat level at the skill's base level
+		p.SetSkillExperience(skillType, experience)
+		p.Skills[skillType].StatLevel = p.Skills[skillType].BaseLevel
+	}
+
+	for _, slot := range f.Inventory {
+		if slot.Slot < 0 || slot.Slot >= model.MaxInventorySlots {
+			return nil, fmt.Errorf("player %s: inventory slot out of bounds: %d", f.Username, slot.Slot)
+		}
+
+		p.SetInventoryItem(&model.Item{ID: slot.ItemID}, fixtureAmount(slot.Amount), slot.Slot)
+	}
+
+	for _, slot := range f.Equipment {
+		slotType, ok := slotIDsToEquipmentSlots[slot.Slot]
+		if !ok {
+			return nil, fmt.Errorf("player %s: slot ID out of bounds: %d", f.Username, slot.Slot)
+		}
+
+		p.SetEquippedItem(&model.Item{ID: slot.ItemID}, fixtureAmount(slot.Amount), slotType)
+	}
+
+	for k, v := range f.GameOptions {
+		p.SetGameOption(k, v)
+	}
+
+	for k, v := range f.QuestStatuses {
+		p.SetQuestStatus(k, model.QuestStatus(v))
+	}
+
+	for questID, flags := range f.QuestFlags {
+		for flagID, value := range flags {
+			p.SetQuestFlag(questID, flagID, value)
+		}
+	}
+
+	for k, v := range f.MusicTracks {
+		p.SetMusicTrackUnlocked(k, v)
+	}
+
+	return p, nil
+}
+
+// toModel converts the ban fixture into a ban.
+func (f BanFixture) toModel() *model.Ban {
+	return &model.Ban{
+		Target:    model.BanTarget(f.Target),
+		Value:     f.Value,
+		Reason:    f.Reason,
+		IssuedBy:  f.IssuedBy,
+		IssuedAt:  f.IssuedAt,
+		ExpiresAt: f.ExpiresAt,
+	}
+}
+
+// toModel converts the abuse report fixture into an abuse report.
+func (f AbuseReportFixture) toModel() *model.AbuseReport {
+	report := &model.AbuseReport{
+		Reporter:   f.Reporter,
+		Offender:   f.Offender,
+		Reason:     f.Reason,
+		Muted:      f.Muted,
+		CreatedAt:  f.CreatedAt,
+		ResolvedBy: f.ResolvedBy,
+		ResolvedAt: f.ResolvedAt,
+	}
+
+	for _, chat := range f.Chat {
+		report.Chat = append(report.Chat, &model.ChatLogEntry{
+			Recipient: chat.Recipient,
+			Text:      chat.Text,
+			SentAt:    chat.SentAt,
+		})
+	}
+
+	return report
+}
+
+// toModel converts the ground item fixture into a ground item.
+func (f GroundItemFixture) toModel() *model.WorldGroundItem {
+	return &model.WorldGroundItem{
+		ItemID:           f.ItemID,
+		Amount:           fixtureAmount(f.Amount),
+		GlobalPos:        model.Vector3D{X: f.Position.X, Y: f.Position.Y, Z: f.Position.Z},
+		RemainingSeconds: f.RemainingSeconds,
+	}
+}
+
+// fixtureAmount returns the stack size of an item in a slot, treating an omitted amount as a single item.
+func fixtureAmount(amount int) int {
+	if amount <= 0 {
+		return 1
+	}
+
+	return amount
+}
@@ -0,0 +1,734 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryLoginAttemptKey identifies failed login attempts tracked against a username or network address.
+type memoryLoginAttemptKey struct {
+	source model.LoginAttemptSource
+	key    string
+}
+
+// MemoryDriver is a driver that keeps all data in memory, which is lost once the driver is closed. It's intended for
+// tests and ephemeral game worlds that don't need a database.
+type MemoryDriver struct {
+	mu             sync.Mutex
+	items          []*model.ItemAttributes
+	players        map[int]*model.Player
+	loginAttempts  map[memoryLoginAttemptKey]*model.LoginAttempts
+	loginAudit     []*model.LoginAuditEntry
+	bans           []*model.Ban
+	reports        []*model.AbuseReport
+	snapshots      map[int][]*model.PlayerSnapshot
+	rollbacks      map[int]int
+	worldStates    map[int]*model.WorldState
+	nextPlayerID   int
+	nextBanID      int
+	nextReportID   int
+	nextSnapshotID int
+}
+
+// NewMemoryDriver creates a new in-memory driver preloaded with fixtures from the files listed in the configuration.
+func NewMemoryDriver(cfg *config.MemoryDatabaseConfig) (Driver, error) {
+	var fixtures []*Fixtures
+	if cfg != nil {
+		for _, path := range cfg.Fixtures {
+			f, err := LoadFixtures(path)
+			if err != nil {
+				return nil, err
+			}
+
+			fixtures = append(fixtures, f)
+		}
+	}
+
+	return NewMemoryDriverWithFixtures(fixtures...)
+}
+
+// NewMemoryDriverWithFixtures creates a new in-memory driver preloaded with fixtures. Fixtures are applied in order.
+func NewMemoryDriverWithFixtures(fixtures ...*Fixtures) (*MemoryDriver, error) {
+	s := &MemoryDriver{
+		players:        map[int]*model.Player{},
+		loginAttempts:  map[memoryLoginAttemptKey]*model.LoginAttempts{},
+		snapshots:      map[int][]*model.PlayerSnapshot{},
+		rollbacks:      map[int]int{},
+		worldStates:    map[int]*model.WorldState{},
+		nextPlayerID:   1,
+		nextBanID:      1,
+		nextReportID:   1,
+		nextSnapshotID: 1,
+	}
+
+	for _, f := range fixtures {
+		err := s.apply(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to apply fixtures")
+		}
+	}
+
+	return s, nil
+}
+
+// Migration returns nil since an in-memory driver has no schema to migrate.
+func (s *MemoryDriver) Migration() (database.Driver, error) {
+	return nil, nil
+}
+
+// LoadItemAttributes loads information about all item attributes from memory.
+func (s *MemoryDriver) LoadItemAttributes() ([]*model.ItemAttributes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attributes := make([]*model.ItemAttributes, len(s.items))
+	for i, item := range s.items {
+		copied := *item
+		attributes[i] = &copied
+	}
+
+	return attributes, nil
+}
+
+// SavePlayer updates a player's information in memory.
+func (s *MemoryDriver) SavePlayer(p *model.Player) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.players[p.ID]
+	if !ok {
+		return fmt.Errorf("expected 1 row update, got 0")
+	}
+
+	// account details are not changed when a player is saved, only their game data
+	saved := copyPlayer(p)
+	saved.Username = existing.Username
+	saved.PasswordHash = existing.PasswordHash
+	saved.Type = existing.Type
+	saved.Member = existing.Member
+	saved.MemberExpiry = existing.MemberExpiry
+
+	s.players[p.ID] = saved
+	return nil
+}
+
+// LoadPlayer loads information about a player from memory.
+func (s *MemoryDriver) LoadPlayer(username string) (*model.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.findPlayerByNormalizedUsername(username)
+	if existing == nil {
+		return nil, nil
+	}
+
+	p := copyPlayer(existing)
+
+	// only include players on the friends and ignored lists that still exist, using their current usernames
+	p.Friends = s.existingUsernames(existing.Friends)
+	p.Ignored = s.existingUsernames(existing.Ignored)
+
+	return p, nil
+}
+
+// CreatePlayer creates a new player in memory, and assigns their ID.
+func (s *MemoryDriver) CreatePlayer(p *model.Player) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createPlayer(p)
+}
+
+// DeletePlayer removes a player and all of their data from memory.
+func (s *MemoryDriver) DeletePlayer(playerID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.players[playerID]
+	if !ok {
+		return fmt.Errorf("expected 1 row deleted for player ID %d, got 0", playerID)
+	}
+
+	delete(s.players, playerID)
+	delete(s.snapshots, playerID)
+	delete(s.rollbacks, playerID)
+
+	// the player may also appear on the friends and ignored lists of other players
+	for _, p := range s.players {
+		p.Friends = removeUsername(p.Friends, existing.Username)
+		p.Ignored = removeUsername(p.Ignored, existing.Username)
+	}
+
+	return nil
+}
+
+// SavePasswordHash updates the hash of a player's password in memory.
+func (s *MemoryDriver) SavePasswordHash(playerID int, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.players[playerID]; ok {
+		p.PasswordHash = hash
+	}
+
+	return nil
+}
+
+// ReplacePlayer overwrites the account details and all data of an existing player in memory, keeping their ID.
+func (s *MemoryDriver) ReplacePlayer(p *model.Player) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.players[p.ID]; !ok {
+		return fmt.Errorf("expected 1 row update, got 0")
+	}
+
+	if existing := s.findPlayerByNormalizedUsername(p.Username); existing != nil && existing.ID != p.ID {
+		return fmt.Errorf("username already exists: %s", p.Username)
+	}
+
+	s.players[p.ID] = copyPlayer(p)
+	return nil
+}
+
+// SavePlayerSnapshot creates a new snapshot of a player's data in memory, and removes the player's oldest snapshots
+// beyond the retain amount.
+func (s *MemoryDriver) SavePlayerSnapshot(snapshot *model.PlayerSnapshot, retain int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.players[snapshot.PlayerID]; !ok {
+		return fmt.Errorf("no player with ID %d", snapshot.PlayerID)
+	}
+
+	snapshot.ID = s.nextSnapshotID
+	s.nextSnapshotID++
+
+	// snapshots are kept oldest first
+	snapshots := append(s.snapshots[snapshot.PlayerID], copySnapshot(snapshot, true))
+	if len(snapshots) > retain {
+		snapshots = snapshots[len(snapshots)-max(retain, 0):]
+	}
+
+	s.snapshots[snapshot.PlayerID] = snapshots
+	return nil
+}
+
+// LoadPlayerSnapshots loads all snapshots of a player without their data from memory, newest first.
+func (s *MemoryDriver) LoadPlayerSnapshots(playerID int) ([]*model.PlayerSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.snapshots[playerID]
+	snapshots := make([]*model.PlayerSnapshot, len(stored))
+	for i, snapshot := range stored {
+		snapshots[len(stored)-1-i] = copySnapshot(snapshot, false)
+	}
+
+	return snapshots, nil
+}
+
+// LoadPlayerSnapshot loads a snapshot of a player along with its data from memory.
+func (s *MemoryDriver) LoadPlayerSnapshot(playerID, snapshotID int) (*model.PlayerSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, snapshot := range s.snapshots[playerID] {
+		if snapshot.ID == snapshotID {
+			return copySnapshot(snapshot, true), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SavePendingRollback records a snapshot that a player is rolled back to the next time they log in in memory.
+func (s *MemoryDriver) SavePendingRollback(playerID, snapshotID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.players[playerID]; !ok {
+		return fmt.Errorf("no player with ID %d", playerID)
+	}
+
+	if snapshotID == 0 {
+		delete(s.rollbacks, playerID)
+	} else {
+		s.rollbacks[playerID] = snapshotID
+	}
+
+	return nil
+}
+
+// LoadPendingRollback loads the ID of the snapshot that a player is rolled back to the next time they log in from
+// memory.
+func (s *MemoryDriver) LoadPendingRollback(playerID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rollbacks[playerID], nil
+}
+
+// LoadLoginAttempts loads failed login attempts tracked against a username or network address from memory.
+func (s *MemoryDriver) LoadLoginAttempts(source model.LoginAttemptSource, key string) (*model.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts, ok := s.loginAttempts[memoryLoginAttemptKey{source: source, key: key}]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *attempts
+	return &copied, nil
+}
+
+// SaveLoginAttempts saves failed login attempts tracked against a username or network address in memory.
+func (s *MemoryDriver) SaveLoginAttempts(source model.LoginAttemptSource, key string, attempts *model.LoginAttempts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *attempts
+	s.loginAttempts[memoryLoginAttemptKey{source: source, key: key}] = &copied
+	return nil
+}
+
+// DeleteLoginAttempts removes all failed login attempts tracked against a username or network address from memory.
+func (s *MemoryDriver) DeleteLoginAttempts(source model.LoginAttemptSource, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.loginAttempts, memoryLoginAttemptKey{source: source, key: key})
+	return nil
+}
+
+// SaveLoginAudit records a login attempt in memory.
+func (s *MemoryDriver) SaveLoginAudit(entry *model.LoginAuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *entry
+	s.loginAudit = append(s.loginAudit, &copied)
+	return nil
+}
+
+// LoginAudit returns all login attempts that have been recorded, oldest first.
+func (s *MemoryDriver) LoginAudit() []*model.LoginAuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*model.LoginAuditEntry, len(s.loginAudit))
+	for i, entry := range s.loginAudit {
+		copied := *entry
+		entries[i] = &copied
+	}
+
+	return entries
+}
+
+// LoadBans loads all bans applied against a username, network address or client identifier from memory.
+func (s *MemoryDriver) LoadBans(target model.BanTarget, value string) ([]*model.Ban, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bans []*model.Ban
+	for _, ban := range s.bans {
+		if ban.Target == target && ban.Value == value {
+			bans = append(bans, copyBan(ban))
+		}
+	}
+
+	return bans, nil
+}
+
+// SaveBan creates a new ban in memory, and assigns its ID.
+func (s *MemoryDriver) SaveBan(ban *model.Ban) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saveBan(ban)
+	return nil
+}
+
+// DeleteBans removes all bans applied against a username, network address or client identifier from memory.
+func (s *MemoryDriver) DeleteBans(target model.BanTarget, value string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var remaining []*model.Ban
+	for _, ban := range s.bans {
+		if ban.Target != target || ban.Value != value {
+			remaining = append(remaining, ban)
+		}
+	}
+
+	count := len(s.bans) - len(remaining)
+	s.bans = remaining
+	return count, nil
+}
+
+// SaveMute updates whether a player is muted, and when their mute ends, in memory.
+func (s *MemoryDriver) SaveMute(username string, muted bool, expiresAt *time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.findPlayer(username)
+	if p == nil {
+		return false, nil
+	}
+
+	p.Muted = muted
+	p.MuteExpiry = copyTime(expiresAt)
+	return true, nil
+}
+
+// SaveAbuseReport creates a new abuse report along with its attached chat messages in memory, and assigns its ID.
+func (s *MemoryDriver) SaveAbuseReport(report *model.AbuseReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saveAbuseReport(report)
+	return nil
+}
+
+// LoadOpenAbuseReports loads all abuse reports that have not been resolved from memory, oldest first.
+func (s *MemoryDriver) LoadOpenAbuseReports() ([]*model.AbuseReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reports []*model.AbuseReport
+	for _, report := range s.reports {
+		if report.ResolvedAt == nil {
+			reports = append(reports, copyAbuseReport(report))
+		}
+	}
+
+	return reports, nil
+}
+
+// ResolveAbuseReport marks an open abuse report as resolved in memory.
+func (s *MemoryDriver) ResolveAbuseReport(id int, resolvedBy string, resolvedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, report := range s.reports {
+		if report.ID == id && report.ResolvedAt == nil {
+			report.ResolvedBy = resolvedBy
+			report.ResolvedAt = &resolvedAt
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// LoadWorldState loads the mutable state of a game world's map from memory.
+func (s *MemoryDriver) LoadWorldState(worldID int) (*model.WorldState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyWorldState(s.worldStates[worldID]), nil
+}
+
+// SaveWorldState replaces the mutable state of a game world's map in memory.
+func (s *MemoryDriver) SaveWorldState(worldID int, state *model.WorldState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.worldStates[worldID] = copyWorldState(state)
+	return nil
+}
+
+// Close discards all data held by the in-memory driver.
+func (s *MemoryDriver) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = nil
+	s.players = map[int]*model.Player{}
+	s.loginAttempts = map[memoryLoginAttemptKey]*model.LoginAttempts{}
+	s.loginAudit = nil
+	s.bans = nil
+	s.reports = nil
+	s.worldStates = map[int]*model.WorldState{}
+	return nil
+}
+
+// apply adds fixtures to the driver's data.
+func (s *MemoryDriver) apply(f *Fixtures) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range f.Items {
+		attributes, err := item.toModel()
+		if err != nil {
+			return err
+		}
+
+		s.items = append(s.items, attributes)
+	}
+
+	for _, player := range f.Players {
+		p, err := player.toModel()
+		if err != nil {
+			return err
+		}
+
+		err = s.createPlayer(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, attempt := range f.LoginAttempts {
+		s.loginAttempts[memoryLoginAttemptKey{source: model.LoginAttemptSource(attempt.Source), key: attempt.Key}] =
+			&model.LoginAttempts{
+				Failures:    attempt.Failures,
+				Lockouts:    attempt.Lockouts,
+				LastFailure: attempt.LastFailure,
+				LockedUntil: attempt.LockedUntil,
+			}
+	}
+
+	for _, ban := range f.Bans {
+		s.saveBan(ban.toModel())
+	}
+
+	for _, report := range f.AbuseReports {
+		s.saveAbuseReport(report.toModel())
+	}
+
+	for _, item := range f.GroundItems {
+		state, ok := s.worldStates[item.WorldID]
+		if !ok {
+			state = &model.WorldState{}
+			s.worldStates[item.WorldID] = state
+		}
+
+		state.GroundItems = append(state.GroundItems, item.toModel())
+	}
+
+	// keep item attributes in a stable order, as a database would return them
+	sort.SliceStable(s.items, func(i, j int) bool {
+		return s.items[i].ItemID < s.items[j].ItemID
+	})
+
+	return nil
+}
+
+// createPlayer stores a new player, keeping their ID if they already have one.
+// Concurrency requirements: (a) the driver should be locked.
+func (s *MemoryDriver) createPlayer(p *model.Player) error {
+	if s.findPlayerByNormalizedUsername(p.Username) != nil {
+		return fmt.Errorf("username already exists: %s", p.Username)
+	}
+
+	if p.ID == 0 {
+		p.ID = s.nextPlayerID
+	} else if _, ok := s.players[p.ID]; ok {
+		return fmt.Errorf("player ID already exists: %d", p.ID)
+	}
+
+	if p.ID >= s.nextPlayerID {
+		s.nextPlayerID = p.ID + 1
+	}
+
+	s.players[p.ID] = copyPlayer(p)
+	return nil
+}
+
+// saveBan stores a new ban, and assigns its ID.
+// Concurrency requirements: (a) the driver should be locked.
+func (s *MemoryDriver) saveBan(ban *model.Ban) {
+	ban.ID = s.nextBanID
+	s.nextBanID++
+
+	s.bans = append(s.bans, copyBan(ban))
+}
+
+// saveAbuseReport stores a new abuse report, and assigns its ID.
+// Concurrency requirements: (a) the driver should be locked.
+func (s *MemoryDriver) saveAbuseReport(report *model.AbuseReport) {
+	report.ID = s.nextReportID
+	s.nextReportID++
+
+	s.reports = append(s.reports, copyAbuseReport(report))
+}
+
+// findPlayer returns the player with a username, ignoring case, or nil if no such player exists.
+// Concurrency requirements: (a) the driver should be locked.
+func (s *MemoryDriver) findPlayer(username string) *model.Player {
+	for _, p := range s.players {
+		if strings.EqualFold(p.Username, username) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// findPlayerByNormalizedUsername returns the player whose username has the same normalized form as a username, or nil
+// if no such player exists.
+// Concurrency requirements: (a) the driver should be locked.
+func (s *MemoryDriver) findPlayerByNormalizedUsername(username string) *model.Player {
+	normalized := model.NormalizeUsername(username)
+	for _, p := range s.players {
+		if model.NormalizeUsername(p.Username) == normalized {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// existingUsernames returns the current usernames of players that exist from a list of usernames.
+// Concurrency requirements: (a) the driver should be locked.
+func (s *MemoryDriver) existingUsernames(usernames []string) []string {
+	var existing []string
+	for _, username := range usernames {
+		if p := s.findPlayer(username); p != nil {
+			existing = append(existing, p.Username)
+		}
+	}
+
+	return existing
+}
+
+// copyPlayer returns a copy of a player's persistent data, in the same form as it would be loaded from a database.
+func copyPlayer(p *model.Player) *model.Player {
+	c := model.NewPlayer(p.Username)
+	c.ID = p.ID
+	c.PasswordHash = p.PasswordHash
+	c.Type = p.Type
+	c.GlobalPos = p.GlobalPos
+	c.Appearance.Gender = p.Appearance.Gender
+	c.Appearance.Base = p.Appearance.Base
+	c.UpdateDesign = p.UpdateDesign
+	c.Flagged = p.Flagged
+	c.Muted = p.Muted
+	c.MuteExpiry = copyTime(p.MuteExpiry)
+	c.Member = p.Member
+	c.MemberExpiry = copyTime(p.MemberExpiry)
+	c.MovementSpeed = p.MovementSpeed
+	c.RunEnergy = p.RunEnergy
+	c.Modes = p.Modes
+	c.AutoRetaliate = p.AutoRetaliate
+	c.Friends = append([]string(nil), p.Friends...)
+	c.Ignored = append([]string(nil), p.Ignored...)
+
+	for slotType, slot := range p.Appearance.Equipment {
+		c.SetEquippedItem(&model.Item{ID: slot.Item.ID}, slot.Amount, slotType)
+	}
+
+	for skillType, skill := range p.Skills {
+		c.Skills[skillType].StatLevel = skill.StatLevel
+		c.SetSkillExperience(skillType, skill.Experience)
+	}
+
+	for _, slot := range p.Inventory {
+		if slot != nil {
+			c.SetInventoryItem(&model.Item{ID: slot.Item.ID}, slot.Amount, slot.ID)
+		}
+	}
+
+	for k, v := range p.GameOptions {
+		c.SetGameOption(k, v)
+	}
+
+	for k, v := range p.QuestStatuses {
+		c.SetQuestStatus(k, v)
+	}
+
+	for questID, flags := range p.QuestFlags {
+		for flagID, value := range flags {
+			c.SetQuestFlag(questID, flagID, value)
+		}
+	}
+
+	for k, v := range p.MusicTracks {
+		c.SetMusicTrackUnlocked(k, v)
+	}
+
+	return c
+}
+
+// copyBan returns a copy of a ban.
+func copyBan(ban *model.Ban) *model.Ban {
+	copied := *ban
+	copied.ExpiresAt = copyTime(ban.ExpiresAt)
+	return &copied
+}
+
+// copyAbuseReport returns a copy of an abuse report and its attached chat messages.
+func copyAbuseReport(report *model.AbuseReport) *model.AbuseReport {
+	copied := *report
+	copied.ResolvedAt = copyTime(report.ResolvedAt)
+	copied.Chat = make([]*model.ChatLogEntry, len(report.Chat))
+	for i, entry := range report.Chat {
+		chat := *entry
+		copied.Chat[i] = &chat
+	}
+
+	return &copied
+}
+
+// copySnapshot returns a copy of a player snapshot, including its data only if requested.
+func copySnapshot(snapshot *model.PlayerSnapshot, withData bool) *model.PlayerSnapshot {
+	copied := *snapshot
+	copied.Data = nil
+	if withData {
+		copied.Data = append([]byte(nil), snapshot.Data...)
+	}
+
+	return &copied
+}
+
+// copyWorldState returns a copy of the mutable state of a game world's map. If there is no state, an empty state is
+// returned.
+func copyWorldState(state *model.WorldState) *model.WorldState {
+	copied := &model.WorldState{}
+	if state == nil {
+		return copied
+	}
+
+	for _, item := range state.GroundItems {
+		groundItem := *item
+		if item.RemainingSeconds != nil {
+			remaining := *item.RemainingSeconds
+			groundItem.RemainingSeconds = &remaining
+		}
+
+		copied.GroundItems = append(copied.GroundItems, &groundItem)
+	}
+
+	return copied
+}
+
+// copyTime returns a copy of an optional point in time.
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	copied := *t
+	return &copied
+}
+
+// removeUsername returns a list of usernames without a particular username, ignoring case.
+func removeUsername(usernames []string, username string) []string {
+	var remaining []string
+	for _, u := range usernames {
+		if !strings.EqualFold(u, username) {
+			remaining = append(remaining, u)
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,239 @@
+package driver
+
+import (
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_MemoryDriver_fixtures(t *testing.T) {
+	d, err := NewMemoryDriver(&config.MemoryDatabaseConfig{
+		Fixtures: []string{"testdata/fixtures.yaml", "testdata/fixtures.json"},
+	})
+	require.NoError(t, err)
+
+	items, err := d.LoadItemAttributes()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, 995, items[0].ItemID)
+	assert.Equal(t, model.ItemNatureNotUsable, items[0].Nature)
+	assert.Equal(t, model.ItemNatureEquippable, items[1].Nature)
+	assert.Equal(t, model.EquipmentSlotType(model.EquipmentSlotTypeWeapon), items[1].EquipSlotType)
+	assert.Equal(t, model.WeaponStyleSlashSword, items[1].WeaponStyle)
+	assert.Equal(t, 29, items[1].Attack.Slash)
+
+	p, err := d.LoadPlayer("mike")
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, 5, p.ID)
+	assert.Equal(t, model.PlayerAdmin, p.Type)
+	assert.Equal(t, model.MovementSpeedRun, p.MovementSpeed)
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), *p.MemberExpiry)
+	assert.Equal(t, 10, p.Skills[model.SkillTypeAttack].BaseLevel)
+	assert.Equal(t, 10, p.Skills[model.SkillTypeAttack].StatLevel)
+	assert.Equal(t, 10, p.Skills[model.SkillTypeHitpoints].BaseLevel)
+	assert.Equal(t, 100, p.Inventory[0].Amount)
+	assert.Equal(t, 1333, p.EquipmentSlot(model.EquipmentSlotTypeWeapon).Item.ID)
+	assert.Equal(t, []string{"Hurz"}, p.Friends)
+	assert.Equal(t, model.QuestStatusFinished, p.QuestStatuses[1])
+	assert.Equal(t, 3, p.QuestFlags[1][2])
+	assert.True(t, p.MusicTracks[7])
+
+	// players without an id are assigned the next available one
+	hurz, err := d.LoadPlayer("Hurz")
+	require.NoError(t, err)
+	assert.Equal(t, 6, hurz.ID)
+
+	zezima, err := d.LoadPlayer("Zezima")
+	require.NoError(t, err)
+	assert.Equal(t, "1", zezima.GameOptions[3])
+	assert.Equal(t, model.ChatMode(1), zezima.Modes.PublicChat)
+
+	attempts, err := d.LoadLoginAttempts(model.LoginAttemptSourceUsername, "zezima")
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts.Failures)
+
+	bans, err := d.LoadBans(model.BanTargetAddress, "10.0.0.1")
+	require.NoError(t, err)
+	require.Len(t, bans, 1)
+	assert.Equal(t, "botting", bans[0].Reason)
+
+	reports, err := d.LoadOpenAbuseReports()
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "Mike", reports[0].Offender)
+	assert.Equal(t, "hello", reports[0].Chat[0].Text)
+}
+
+func Test_MemoryDriver_fixtures_invalid(t *testing.T) {
+	_, err := NewMemoryDriverWithFixtures(&Fixtures{
+		Players: []PlayerFixture{{Username: "Mike"}, {Username: "mike"}},
+	})
+	assert.Error(t, err)
+
+	_, err = NewMemoryDriverWithFixtures(&Fixtures{
+		Items: []ItemFixture{{ItemID: 1, WeaponStyle: "SPOON"}},
+	})
+	assert.Error(t, err)
+}
+
+func Test_MemoryDriver_players(t *testing.T) {
+	d, err := NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	p := model.NewPlayer("Mike")
+	p.PasswordHash = "hash"
+	p.SetInventoryItem(&model.Item{ID: 995}, 10, 3)
+	require.NoError(t, d.CreatePlayer(p))
+	assert.Equal(t, 1, p.ID)
+
+	assert.Error(t, d.CreatePlayer(model.NewPlayer("MIKE")))
+
+	// changes to a player are only stored once they are saved
+	p.SetInventoryItem(&model.Item{ID: 995}, 20, 3)
+	p.PasswordHash = "changed"
+
+	loaded, err := d.LoadPlayer("mike")
+	require.NoError(t, err)
+	assert.Equal(t, 10, loaded.Inventory[3].Amount)
+
+	require.NoError(t, d.SavePlayer(p))
+	loaded, err = d.LoadPlayer("mike")
+	require.NoError(t, err)
+	assert.Equal(t, 20, loaded.Inventory[3].Amount)
+	assert.Equal(t, "hash", loaded.PasswordHash)
+
+	require.NoError(t, d.SavePasswordHash(p.ID, "changed"))
+	loaded, err = d.LoadPlayer("mike")
+	require.NoError(t, err)
+	assert.Equal(t, "changed", loaded.PasswordHash)
+
+	found, err := d.SaveMute("MIKE", true, nil)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	require.NoError(t, d.DeletePlayer(p.ID))
+	loaded, err = d.LoadPlayer("mike")
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+	assert.Error(t, d.DeletePlayer(p.ID))
+}
+
+func Test_MemoryDriver_abuseReports(t *testing.T) {
+	d, err := NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	report := &model.AbuseReport{Reporter: "Mike", Offender: "Hurz", CreatedAt: time.Now()}
+	require.NoError(t, d.SaveAbuseReport(report))
+	assert.Equal(t, 1, report.ID)
+
+	resolved, err := d.ResolveAbuseReport(report.ID, "Mike", time.Now())
+	require.NoError(t, err)
+	assert.True(t, resolved)
+
+	resolved, err = d.ResolveAbuseReport(report.ID, "Mike", time.Now())
+	require.NoError(t, err)
+	assert.False(t, resolved)
+
+	reports, err := d.LoadOpenAbuseReports()
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func Test_MemoryDriver_sampleFixtures(t *testing.T) {
+	d, err := NewMemoryDriver(&config.MemoryDatabaseConfig{
+		Fixtures: []string{"../../../fixtures/sample.yaml"},
+	})
+	require.NoError(t, err)
+
+	p, err := d.LoadPlayer("mike")
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, 10, p.Skills[model.SkillTypeHitpoints].BaseLevel)
+}
+
+func Test_MemoryDriver_worldState(t *testing.T) {
+	d, err := NewMemoryDriver(&config.MemoryDatabaseConfig{
+		Fixtures: []string{"testdata/fixtures.yaml"},
+	})
+	require.NoError(t, err)
+
+	state, err := d.LoadWorldState(69)
+	require.NoError(t, err)
+	require.Len(t, state.GroundItems, 2)
+	assert.Equal(t, 500, state.GroundItems[0].Amount)
+	assert.Nil(t, state.GroundItems[0].RemainingSeconds)
+	assert.Equal(t, 1, state.GroundItems[1].Amount)
+	assert.Equal(t, 60, *state.GroundItems[1].RemainingSeconds)
+
+	// other worlds keep their own state
+	state, err = d.LoadWorldState(70)
+	require.NoError(t, err)
+	assert.Empty(t, state.GroundItems)
+
+	require.NoError(t, d.SaveWorldState(69, &model.WorldState{}))
+	state, err = d.LoadWorldState(69)
+	require.NoError(t, err)
+	assert.Empty(t, state.GroundItems)
+}
+
+func Test_MemoryDriver_playerSnapshots(t *testing.T) {
+	d, err := NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	p := model.NewPlayer("Mike")
+	require.NoError(t, d.CreatePlayer(p))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, d.SavePlayerSnapshot(&model.PlayerSnapshot{
+			PlayerID:      p.ID,
+			SchemaVersion: 1,
+			Data:          []byte{byte(i)},
+			CreatedAt:     time.Now(),
+		}, 2))
+	}
+
+	assert.Error(t, d.SavePlayerSnapshot(&model.PlayerSnapshot{PlayerID: p.ID + 1}, 2))
+
+	// only the newest snapshots are kept, and they're listed without their data
+	snapshots, err := d.LoadPlayerSnapshots(p.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, 3, snapshots[0].ID)
+	assert.Equal(t, 2, snapshots[1].ID)
+	assert.Nil(t, snapshots[0].Data)
+
+	snapshot, err := d.LoadPlayerSnapshot(p.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, snapshot.Data)
+
+	snapshot, err = d.LoadPlayerSnapshot(p.ID, 1)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	require.NoError(t, d.DeletePlayer(p.ID))
+	snapshots, err = d.LoadPlayerSnapshots(p.ID)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func Test_MemoryDriver_normalizedUsername(t *testing.T) {
+	d, err := NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	p := model.NewPlayer("Bob Smith")
+	require.NoError(t, d.CreatePlayer(p))
+
+	// usernames that differ only in case, or in spaces and underscores, belong to the same player
+	for _, username := range []string{"bob smith", "BOB_SMITH", "bob_Smith"} {
+		loaded, err := d.LoadPlayer(username)
+		require.NoError(t, err)
+		require.NotNil(t, loaded, username)
+		assert.Equal(t, p.ID, loaded.ID)
+
+		assert.Error(t, d.CreatePlayer(model.NewPlayer(username)), username)
+	}
+}
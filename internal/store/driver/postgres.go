@@ -0,0 +1,1617 @@
+package driver
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/lib/pq"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"strings"
+	"time"
+)
+
+// PostgresDriver is a driver that interfaces with a PostgreSQL database.
+type PostgresDriver struct {
+	db *sql.DB
+}
+
+// NewPostgresDriver creates a new PostgreSQL database driver with a pool of connections.
+func NewPostgresDriver(cfg *config.PostgresDatabaseConfig, pool config.StorePoolConfig) (Driver, error) {
+	db, err := sql.Open("postgres", cfg.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetimeSeconds) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTimeSeconds) * time.Second)
+
+	// fail early if the database is unreachable, rather than when the first player logs in
+	err = db.Ping()
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &PostgresDriver{
+		db: db,
+	}, nil
+}
+
+// Migration returns a handle to the underlying store for use with PostgreSQL migrations.
+func (s *PostgresDriver) Migration() (database.Driver, error) {
+	return postgres.WithInstance(s.db, &postgres.Config{})
+}
+
+// LoadItemAttributes loads information about all item attributes from a PostgreSQL database.
+func (s *PostgresDriver) LoadItemAttributes() ([]*model.ItemAttributes, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ITEM_ID,
+		    EQUIP_SLOT_ID,
+		    SPEED,
+		    WEIGHT,
+		    VALUE,
+		    WEAPON_STYLE,
+		    ATTACK_STAB,
+			ATTACK_SLASH,
+			ATTACK_CRUSH,
+			ATTACK_MAGIC,
+			ATTACK_RANGE,
+			DEFENSE_STAB,
+			DEFENSE_SLASH,
+			DEFENSE_CRUSH,
+			DEFENSE_MAGIC,
+			DEFENSE_RANGE,
+			STRENGTH_BONUS,
+			PRAYER_BONUS
+		FROM
+		    ITEM_ATTRIBUTES
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []*model.ItemAttributes
+
+	defer rows.Close()
+	for rows.Next() {
+		var itemID, value int
+		var weight float64
+		var weaponStyleStr sql.NullString
+		var slotID, speed sql.NullInt32
+		var atkStab, atkSlash, atkCrush, atkMagic, atkRange sql.NullInt32
+		var defStab, defSlash, defCrush, defMagic, defRange sql.NullInt32
+		var strength, prayer sql.NullInt32
+
+		err := rows.Scan(&itemID, &slotID, &speed, &weight, &value, &weaponStyleStr,
+			&atkStab, &atkSlash, &atkCrush, &atkMagic, &atkRange,
+			&defStab, &defSlash, &defCrush, &defMagic, &defRange,
+			&strength, &prayer)
+		if err != nil {
+			return nil, err
+		}
+
+		nature := model.ItemNatureNotUsable
+		if slotID.Valid {
+			nature = model.ItemNatureEquippable
+		}
+
+		weaponStyle := model.WeaponStyleUnarmed
+		if weaponStyleStr.Valid {
+			weaponStyle = weaponStyleValues[weaponStyleStr.String]
+		}
+
+		equipSlotID := model.EquipmentSlotTypeHead
+		if slotID.Valid {
+			equipSlotID = slotIDsToEquipmentSlots[int(slotID.Int32)]
+		}
+
+		attributes = append(attributes, &model.ItemAttributes{
+			ItemID:        itemID,
+			Nature:        nature,
+			EquipSlotType: equipSlotID,
+			WeaponStyle:   weaponStyle,
+			Speed:         safeNullInt32(speed, 0),
+			Weight:        float32(weight),
+			Value:         value,
+			Attack: model.ItemCombatAttributes{
+				Stab:  safeNullInt32(atkStab, 0),
+				Slash: safeNullInt32(atkSlash, 0),
+				Crush: safeNullInt32(atkCrush, 0),
+				Magic: safeNullInt32(atkMagic, 0),
+				Range: safeNullInt32(atkRange, 0),
+			},
+			Defense: model.ItemCombatAttributes{
+				Stab:  safeNullInt32(defStab, 0),
+				Slash: safeNullInt32(defSlash, 0),
+				Crush: safeNullInt32(defCrush, 0),
+				Magic: safeNullInt32(defMagic, 0),
+				Range: safeNullInt32(defRange, 0),
+			},
+			StrengthBonus: safeNullInt32(strength, 0),
+			PrayerBonus:   safeNullInt32(prayer, 0),
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+// SavePlayer updates a player's information in a PostgreSQL database. All of the player's data is saved in a single
+// transaction.
+func (s *PostgresDriver) SavePlayer(p *model.Player) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = s.savePlayer(tx, p)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LoadPlayer loads information about a player from a PostgreSQL database.
+func (s *PostgresDriver) LoadPlayer(username string) (*model.Player, error) {
+	// prepare a player model for populating
+	p := model.NewPlayer(username)
+
+	// load their basic information first
+	err := s.loadPlayerInfo(username, p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	// load the remainder of their data
+	loaders := []func(p *model.Player) error{
+		s.loadPlayerEquipment,
+		s.loadPlayerAppearance,
+		s.loadPlayerLists,
+		s.loadPlayerSkills,
+		s.loadPlayerInventory,
+		s.loadPlayerGameOptions,
+		s.loadPlayerQuestStatuses,
+		s.loadPlayerQuestFlags,
+		s.loadPlayerMusicTracks,
+	}
+
+	for _, loader := range loaders {
+		err = loader(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// CreatePlayer creates a new player in a PostgreSQL database, and assigns their ID. The player and all of their data
+// are inserted in a single transaction, so a partial account is never left behind.
+func (s *PostgresDriver) CreatePlayer(p *model.Player) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO PLAYER (
+		    USERNAME,
+		    USERNAME_NORMALIZED,
+		    PASSWORD_HASH,
+		    EMAIL,
+		    GLOBAL_X,
+		    GLOBAL_Y,
+		    GLOBAL_Z,
+		    GENDER,
+		    UPDATE_DESIGN,
+		    FLAGGED,
+		    MUTED,
+		    MOVEMENT_SPEED,
+		    RUN_ENERGY,
+		    PUBLIC_CHAT_MODE,
+		    PRIVATE_CHAT_MODE,
+		    INTERACTION_MODE,
+		    AUTO_RETALIATE,
+		    TYPE,
+		    MEMBER,
+		    MEMBER_END_DTTM
+		)
+		VALUES ($1, $2, $3, '', $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING ID
+	`,
+		p.Username,
+		model.NormalizeUsername(p.Username),
+		p.PasswordHash,
+		p.GlobalPos.X,
+		p.GlobalPos.Y,
+		p.GlobalPos.Z,
+		p.Appearance.Gender,
+		p.UpdateDesign,
+		p.Flagged,
+		p.Muted,
+		p.MovementSpeed,
+		p.RunEnergy,
+		p.Modes.PublicChat,
+		p.Modes.PrivateChat,
+		p.Modes.Interaction,
+		p.AutoRetaliate,
+		p.Type,
+		p.Member,
+		nullTime(p.MemberExpiry)).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO PLAYER_APPEARANCE (
+		    PLAYER_ID,
+		    HEAD_ID,
+		    FACE_ID,
+		    BODY_ID,
+		    ARMS_ID,
+		    HANDS_ID,
+		    LEGS_ID,
+		    FEET_ID
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		id,
+		p.Appearance.Base.Head,
+		p.Appearance.Base.Face,
+		p.Appearance.Base.Body,
+		p.Appearance.Base.Arms,
+		p.Appearance.Base.Hands,
+		p.Appearance.Base.Legs,
+		p.Appearance.Base.Feet)
+	if err != nil {
+		return err
+	}
+
+	// save the remainder of the player's data
+	p.ID = id
+	err = s.savePlayer(tx, p)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeletePlayer removes a player and all of their data from a PostgreSQL database.
+func (s *PostgresDriver) DeletePlayer(playerID int) error {
+	// all tables that reference the player cascade their deletes, including the friends and ignored lists of other
+	// players
+	rs, err := s.db.Exec("DELETE FROM PLAYER WHERE ID = $1", playerID)
+	if err != nil {
+		return err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count != 1 {
+		return fmt.Errorf("expected 1 row deleted for player ID %d, got %d", playerID, count)
+	}
+
+	return nil
+}
+
+// SavePasswordHash updates the hash of a player's password in a PostgreSQL database.
+func (s *PostgresDriver) SavePasswordHash(playerID int, hash string) error {
+	_, err := s.db.Exec(`
+		UPDATE
+			PLAYER
+		SET
+			PASSWORD_HASH = $1
+		WHERE
+			ID = $2
+	`, hash, playerID)
+	return err
+}
+
+// ReplacePlayer overwrites the account details and all data of an existing player in a PostgreSQL database in a single
+// transaction, keeping their ID.
+func (s *PostgresDriver) ReplacePlayer(p *model.Player) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// account details are not changed when a player is saved, so they are replaced first
+	_, err = tx.Exec(`
+		UPDATE
+			PLAYER
+		SET
+			USERNAME = $1,
+			USERNAME_NORMALIZED = $2,
+			PASSWORD_HASH = $3,
+			TYPE = $4,
+			MEMBER = $5,
+			MEMBER_END_DTTM = $6
+		WHERE
+			ID = $7
+	`,
+		p.Username,
+		model.NormalizeUsername(p.Username),
+		p.PasswordHash,
+		p.Type,
+		p.Member,
+		nullTime(p.MemberExpiry),
+		p.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.savePlayer(tx, p)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SavePlayerSnapshot creates a new snapshot of a player's data in a PostgreSQL database, and removes the player's
+// oldest snapshots beyond the retain amount.
+func (s *PostgresDriver) SavePlayerSnapshot(snapshot *model.PlayerSnapshot, retain int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO PLAYER_SNAPSHOT (
+			PLAYER_ID,
+			SCHEMA_VERSION,
+			DATA,
+			CREATED_DTTM
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ID
+	`, snapshot.PlayerID, snapshot.SchemaVersion, snapshot.Data, snapshot.CreatedAt).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM
+			PLAYER_SNAPSHOT
+		WHERE
+			PLAYER_ID = $1
+			AND ID NOT IN (
+				SELECT
+					ID
+				FROM
+					PLAYER_SNAPSHOT
+				WHERE
+					PLAYER_ID = $1
+				ORDER BY
+					ID DESC
+				LIMIT $2
+			)
+	`, snapshot.PlayerID, retain)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	snapshot.ID = id
+	return nil
+}
+
+// LoadPlayerSnapshots loads all snapshots of a player without their data from a PostgreSQL database, newest first.
+func (s *PostgresDriver) LoadPlayerSnapshots(playerID int) ([]*model.PlayerSnapshot, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ID,
+		    SCHEMA_VERSION,
+		    CREATED_DTTM
+		FROM
+		    PLAYER_SNAPSHOT
+		WHERE
+		    PLAYER_ID = $1
+		ORDER BY
+		    ID DESC
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var snapshots []*model.PlayerSnapshot
+	for rows.Next() {
+		snapshot := &model.PlayerSnapshot{PlayerID: playerID}
+
+		err := rows.Scan(&snapshot.ID, &snapshot.SchemaVersion, &snapshot.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// LoadPlayerSnapshot loads a snapshot of a player along with its data from a PostgreSQL database.
+func (s *PostgresDriver) LoadPlayerSnapshot(playerID, snapshotID int) (*model.PlayerSnapshot, error) {
+	snapshot := &model.PlayerSnapshot{ID: snapshotID, PlayerID: playerID}
+
+	err := s.db.QueryRow(`
+		SELECT
+		    SCHEMA_VERSION,
+		    DATA,
+		    CREATED_DTTM
+		FROM
+		    PLAYER_SNAPSHOT
+		WHERE
+		    PLAYER_ID = $1
+		    AND ID = $2
+	`, playerID, snapshotID).Scan(&snapshot.SchemaVersion, &snapshot.Data, &snapshot.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// SavePendingRollback records a snapshot that a player is rolled back to the next time they log in in a PostgreSQL
+// database.
+func (s *PostgresDriver) SavePendingRollback(playerID, snapshotID int) error {
+	var pending sql.NullInt64
+	if snapshotID != 0 {
+		pending = sql.NullInt64{Int64: int64(snapshotID), Valid: true}
+	}
+
+	rs, err := s.db.Exec(`
+		UPDATE
+			PLAYER
+		SET
+			PENDING_ROLLBACK_ID = $1
+		WHERE
+			ID = $2
+	`, pending, playerID)
+	if err != nil {
+		return err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count != 1 {
+		return fmt.Errorf("expected 1 row updated for player ID %d, got %d", playerID, count)
+	}
+
+	return nil
+}
+
+// LoadPendingRollback loads the ID of the snapshot that a player is rolled back to the next time they log in from a
+// PostgreSQL database.
+func (s *PostgresDriver) LoadPendingRollback(playerID int) (int, error) {
+	var pending sql.NullInt64
+
+	err := s.db.QueryRow(`
+		SELECT
+		    PENDING_ROLLBACK_ID
+		FROM
+		    PLAYER
+		WHERE
+		    ID = $1
+	`, playerID).Scan(&pending)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no player with ID %d", playerID)
+	} else if err != nil {
+		return 0, err
+	}
+
+	return int(pending.Int64), nil
+}
+
+// LoadLoginAttempts loads failed login attempts tracked against a username or network address from a PostgreSQL
+// database.
+func (s *PostgresDriver) LoadLoginAttempts(source model.LoginAttemptSource, key string) (*model.LoginAttempts, error) {
+	var attempts model.LoginAttempts
+	var lockedUntil sql.NullTime
+
+	err := s.db.QueryRow(`
+		SELECT
+		    FAILURES,
+		    LOCKOUTS,
+		    LAST_FAILURE_DTTM,
+		    LOCKED_UNTIL_DTTM
+		FROM
+		    LOGIN_ATTEMPT
+		WHERE
+		    SOURCE = $1
+		    AND SOURCE_KEY = $2
+	`, source, key).Scan(&attempts.Failures, &attempts.Lockouts, &attempts.LastFailure, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		attempts.LockedUntil = lockedUntil.Time
+	}
+
+	return &attempts, nil
+}
+
+// SaveLoginAttempts saves failed login attempts tracked against a username or network address to a PostgreSQL
+// database.
+func (s *PostgresDriver) SaveLoginAttempts(source model.LoginAttemptSource, key string, attempts *model.LoginAttempts) error {
+	var lockedUntil sql.NullTime
+	if !attempts.LockedUntil.IsZero() {
+		lockedUntil.Time = attempts.LockedUntil
+		lockedUntil.Valid = true
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO LOGIN_ATTEMPT (
+			SOURCE,
+			SOURCE_KEY,
+			FAILURES,
+			LOCKOUTS,
+			LAST_FAILURE_DTTM,
+			LOCKED_UNTIL_DTTM
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (SOURCE, SOURCE_KEY) DO UPDATE SET
+			FAILURES = EXCLUDED.FAILURES,
+			LOCKOUTS = EXCLUDED.LOCKOUTS,
+			LAST_FAILURE_DTTM = EXCLUDED.LAST_FAILURE_DTTM,
+			LOCKED_UNTIL_DTTM = EXCLUDED.LOCKED_UNTIL_DTTM
+	`, source, key, attempts.Failures, attempts.Lockouts, attempts.LastFailure, lockedUntil)
+	return err
+}
+
+// DeleteLoginAttempts removes all failed login attempts tracked against a username or network address from a
+// PostgreSQL database.
+func (s *PostgresDriver) DeleteLoginAttempts(source model.LoginAttemptSource, key string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM
+			LOGIN_ATTEMPT
+		WHERE
+			SOURCE = $1
+			AND SOURCE_KEY = $2
+	`, source, key)
+	return err
+}
+
+// SaveLoginAudit records a login attempt in a PostgreSQL database.
+func (s *PostgresDriver) SaveLoginAudit(entry *model.LoginAuditEntry) error {
+	_, err := s.db.Exec(`
+		INSERT INTO LOGIN_AUDIT (
+			USERNAME,
+			ADDRESS,
+			CLIENT_UID,
+			RESULT
+		)
+		VALUES ($1, $2, $3, $4)
+	`, entry.Username, entry.Address, int64(entry.UID), entry.Result)
+	return err
+}
+
+// LoadBans loads all bans applied against a username, network address or client identifier from a PostgreSQL
+// database.
+func (s *PostgresDriver) LoadBans(target model.BanTarget, value string) ([]*model.Ban, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ID,
+		    REASON,
+		    ISSUED_BY,
+		    ISSUED_DTTM,
+		    EXPIRES_DTTM
+		FROM
+		    BAN
+		WHERE
+		    TARGET = $1
+		    AND TARGET_VALUE = $2
+	`, target, value)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var bans []*model.Ban
+	for rows.Next() {
+		ban := &model.Ban{
+			Target: target,
+			Value:  value,
+		}
+
+		var expiresAt sql.NullTime
+
+		err := rows.Scan(&ban.ID, &ban.Reason, &ban.IssuedBy, &ban.IssuedAt, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if expiresAt.Valid {
+			ban.ExpiresAt = &expiresAt.Time
+		}
+
+		bans = append(bans, ban)
+	}
+
+	return bans, rows.Err()
+}
+
+// SaveBan creates a new ban in a PostgreSQL database, and assigns its ID.
+func (s *PostgresDriver) SaveBan(ban *model.Ban) error {
+	return s.db.QueryRow(`
+		INSERT INTO BAN (
+			TARGET,
+			TARGET_VALUE,
+			REASON,
+			ISSUED_BY,
+			ISSUED_DTTM,
+			EXPIRES_DTTM
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ID
+	`, ban.Target, ban.Value, ban.Reason, ban.IssuedBy, ban.IssuedAt, nullTime(ban.ExpiresAt)).Scan(&ban.ID)
+}
+
+// DeleteBans removes all bans applied against a username, network address or client identifier from a PostgreSQL
+// database.
+func (s *PostgresDriver) DeleteBans(target model.BanTarget, value string) (int, error) {
+	rs, err := s.db.Exec(`
+		DELETE FROM
+			BAN
+		WHERE
+			TARGET = $1
+			AND TARGET_VALUE = $2
+	`, target, value)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := rs.RowsAffected()
+	return int(count), err
+}
+
+// SaveMute updates whether a player is muted, and when their mute ends, in a PostgreSQL database.
+func (s *PostgresDriver) SaveMute(username string, muted bool, expiresAt *time.Time) (bool, error) {
+	rs, err := s.db.Exec(`
+		UPDATE
+			PLAYER
+		SET
+			MUTED = $1,
+			MUTE_END_DTTM = $2
+		WHERE
+			LOWER(USERNAME) = LOWER($3)
+	`, muted, nullTime(expiresAt), username)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// SaveAbuseReport creates a new abuse report along with its attached chat messages in a PostgreSQL database, and
+// assigns its ID.
+func (s *PostgresDriver) SaveAbuseReport(report *model.AbuseReport) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO ABUSE_REPORT (
+			REPORTER,
+			OFFENDER,
+			REASON,
+			MUTED,
+			CREATED_DTTM
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ID
+	`, report.Reporter, report.Offender, report.Reason, report.Muted, report.CreatedAt).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range report.Chat {
+		var recipient sql.NullString
+		if entry.Recipient != "" {
+			recipient.String = entry.Recipient
+			recipient.Valid = true
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO ABUSE_REPORT_CHAT (
+				REPORT_ID,
+				SEQUENCE,
+				RECIPIENT,
+				TEXT,
+				SENT_DTTM
+			)
+			VALUES ($1, $2, $3, $4, $5)
+		`, id, i, recipient, entry.Text, entry.SentAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	report.ID = id
+	return nil
+}
+
+// LoadOpenAbuseReports loads all abuse reports that have not been resolved from a PostgreSQL database, oldest first.
+func (s *PostgresDriver) LoadOpenAbuseReports() ([]*model.AbuseReport, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ID,
+		    REPORTER,
+		    OFFENDER,
+		    REASON,
+		    MUTED,
+		    CREATED_DTTM
+		FROM
+		    ABUSE_REPORT
+		WHERE
+		    RESOLVED_DTTM IS NULL
+		ORDER BY
+		    ID
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var reports []*model.AbuseReport
+	for rows.Next() {
+		report := &model.AbuseReport{}
+
+		err := rows.Scan(&report.ID, &report.Reporter, &report.Offender, &report.Reason, &report.Muted,
+			&report.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// load the chat messages attached to each report
+	for _, report := range reports {
+		report.Chat, err = s.loadAbuseReportChat(report.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return reports, nil
+}
+
+// ResolveAbuseReport marks an open abuse report as resolved in a PostgreSQL database.
+func (s *PostgresDriver) ResolveAbuseReport(id int, resolvedBy string, resolvedAt time.Time) (bool, error) {
+	rs, err := s.db.Exec(`
+		UPDATE
+			ABUSE_REPORT
+		SET
+			RESOLVED_BY = $1,
+			RESOLVED_DTTM = $2
+		WHERE
+			ID = $3
+			AND RESOLVED_DTTM IS NULL
+	`, resolvedBy, resolvedAt, id)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// LoadWorldState loads the mutable state of a game world's map from a PostgreSQL database.
+func (s *PostgresDriver) LoadWorldState(worldID int) (*model.WorldState, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ITEM_ID,
+		    AMOUNT,
+		    GLOBAL_X,
+		    GLOBAL_Y,
+		    GLOBAL_Z,
+		    REMAINING_SECONDS
+		FROM
+		    WORLD_GROUND_ITEM
+		WHERE
+		    WORLD_ID = $1
+		ORDER BY
+		    SEQUENCE
+	`, worldID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	state := &model.WorldState{}
+	for rows.Next() {
+		item := &model.WorldGroundItem{}
+		var remainingSeconds sql.NullInt32
+
+		err := rows.Scan(&item.ItemID, &item.Amount, &item.GlobalPos.X, &item.GlobalPos.Y, &item.GlobalPos.Z,
+			&remainingSeconds)
+		if err != nil {
+			return nil, err
+		}
+
+		if remainingSeconds.Valid {
+			remaining := int(remainingSeconds.Int32)
+			item.RemainingSeconds = &remaining
+		}
+
+		state.GroundItems = append(state.GroundItems, item)
+	}
+
+	return state, rows.Err()
+}
+
+// SaveWorldState replaces the mutable state of a game world's map in a PostgreSQL database.
+func (s *PostgresDriver) SaveWorldState(worldID int, state *model.WorldState) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM WORLD_GROUND_ITEM WHERE WORLD_ID = $1", worldID)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range state.GroundItems {
+		var remainingSeconds sql.NullInt32
+		if item.RemainingSeconds != nil {
+			remainingSeconds.Int32 = int32(*item.RemainingSeconds)
+			remainingSeconds.Valid = true
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO WORLD_GROUND_ITEM (
+				WORLD_ID,
+				SEQUENCE,
+				ITEM_ID,
+				AMOUNT,
+				GLOBAL_X,
+				GLOBAL_Y,
+				GLOBAL_Z,
+				REMAINING_SECONDS
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, worldID, i, item.ItemID, item.Amount, item.GlobalPos.X, item.GlobalPos.Y, item.GlobalPos.Z,
+			remainingSeconds)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Close cleans up resources used by the PostgreSQL driver.
+func (s *PostgresDriver) Close() error {
+	return s.db.Close()
+}
+
+// loadPlayerInfo loads a player's basic information.
+func (s *PostgresDriver) loadPlayerInfo(username string, p *model.Player) error {
+	var memberEndDate, muteEndDate sql.NullTime
+
+	err := s.db.QueryRow(`
+		SELECT
+		    ID,
+		    USERNAME,
+		    PASSWORD_HASH,
+		    GLOBAL_X,
+		    GLOBAL_Y,
+		    GLOBAL_Z,
+		    GENDER,
+		    UPDATE_DESIGN,
+		    FLAGGED,
+		    MUTED,
+		    MOVEMENT_SPEED,
+		    RUN_ENERGY,
+		    PUBLIC_CHAT_MODE,
+		    PRIVATE_CHAT_MODE,
+		    INTERACTION_MODE,
+		    AUTO_RETALIATE,
+		    TYPE,
+		    MEMBER,
+		    MEMBER_END_DTTM,
+		    MUTE_END_DTTM
+		FROM
+		    PLAYER
+		WHERE
+		    USERNAME_NORMALIZED = $1
+	`, model.NormalizeUsername(username)).Scan(
+		&p.ID,
+		&p.Username,
+		&p.PasswordHash,
+		&p.GlobalPos.X,
+		&p.GlobalPos.Y,
+		&p.GlobalPos.Z,
+		&p.Appearance.Gender,
+		&p.UpdateDesign,
+		&p.Flagged,
+		&p.Muted,
+		&p.MovementSpeed,
+		&p.RunEnergy,
+		&p.Modes.PublicChat,
+		&p.Modes.PrivateChat,
+		&p.Modes.Interaction,
+		&p.AutoRetaliate,
+		&p.Type,
+		&p.Member,
+		&memberEndDate,
+		&muteEndDate)
+	if err != nil {
+		return err
+	}
+
+	if memberEndDate.Valid {
+		p.MemberExpiry = &memberEndDate.Time
+	}
+
+	if muteEndDate.Valid {
+		p.MuteExpiry = &muteEndDate.Time
+	}
+
+	return nil
+}
+
+// loadAbuseReportChat loads the chat messages attached to an abuse report.
+func (s *PostgresDriver) loadAbuseReportChat(reportID int) ([]*model.ChatLogEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    RECIPIENT,
+		    TEXT,
+		    SENT_DTTM
+		FROM
+		    ABUSE_REPORT_CHAT
+		WHERE
+		    REPORT_ID = $1
+		ORDER BY
+		    SEQUENCE
+	`, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []*model.ChatLogEntry
+	for rows.Next() {
+		entry := &model.ChatLogEntry{}
+		var recipient sql.NullString
+
+		err := rows.Scan(&recipient, &entry.Text, &entry.SentAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Recipient = recipient.String
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// loadPlayerEquipment loads a player's equipped items.
+func (s *PostgresDriver) loadPlayerEquipment(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    SLOT_ID,
+		    ITEM_ID,
+		    AMOUNT
+		FROM
+		    PLAYER_EQUIPMENT
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var slotID, itemID, amount int
+		err := rows.Scan(&slotID, &itemID, &amount)
+		if err != nil {
+			return err
+		}
+
+		slot, ok := slotIDsToEquipmentSlots[slotID]
+		if !ok {
+			return fmt.Errorf("slot ID out of bounds: %d", slotID)
+		}
+
+		p.SetEquippedItem(&model.Item{ID: itemID}, amount, slot)
+	}
+
+	return rows.Err()
+}
+
+// loadPlayerAppearance loads a player's body appearance.
+func (s *PostgresDriver) loadPlayerAppearance(p *model.Player) error {
+	err := s.db.QueryRow(`
+		SELECT
+		    HEAD_ID,
+		    FACE_ID,
+		    BODY_ID,
+		    ARMS_ID,
+		    HANDS_ID,
+		    LEGS_ID,
+		    FEET_ID
+		FROM
+		    PLAYER_APPEARANCE
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID).Scan(
+		&p.Appearance.Base.Head,
+		&p.Appearance.Base.Face,
+		&p.Appearance.Base.Body,
+		&p.Appearance.Base.Arms,
+		&p.Appearance.Base.Hands,
+		&p.Appearance.Base.Legs,
+		&p.Appearance.Base.Feet)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	return err
+}
+
+// loadPlayerLists loads a player's friends and ignored lists.
+func (s *PostgresDriver) loadPlayerLists(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    p.USERNAME, l.TYPE
+		FROM
+		    PLAYER_LIST l
+		JOIN
+			PLAYER p ON p.ID = l.OTHER_ID
+		WHERE
+		    l.PLAYER_ID = $1
+		ORDER BY
+		    l.ID
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var username string
+		var entryType int
+		err := rows.Scan(&username, &entryType)
+		if err != nil {
+			return err
+		}
+
+		if entryType == playerListTypeFriend {
+			p.Friends = append(p.Friends, username)
+		} else if entryType == playerListTypeIgnored {
+			p.Ignored = append(p.Ignored, username)
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadPlayerSkills loads a player's skills.
+func (s *PostgresDriver) loadPlayerSkills(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    SKILL_ID,
+		    STAT_LEVEL,
+		    EXPERIENCE
+		FROM
+		    PLAYER_SKILL
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var skillID, statLevel int
+		var experience float64
+		err := rows.Scan(&skillID, &statLevel, &experience)
+		if err != nil {
+			return err
+		}
+
+		// set the skill experience points and let the model recompute the level
+		skillType := model.SkillType(skillID)
+		p.Skills[skillType].StatLevel = statLevel
+		p.SetSkillExperience(skillType, experience)
+	}
+
+	return rows.Err()
+}
+
+// loadPlayerInventory loads a player's inventory items.
+func (s *PostgresDriver) loadPlayerInventory(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    SLOT_ID,
+		    ITEM_ID,
+		    AMOUNT
+		FROM
+		    PLAYER_INVENTORY
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var slotID, itemID, amount int
+		err := rows.Scan(&slotID, &itemID, &amount)
+		if err != nil {
+			return err
+		}
+
+		p.SetInventoryItem(&model.Item{ID: itemID}, amount, slotID)
+	}
+
+	return rows.Err()
+}
+
+// loadPlayerGameOptions loads a player's game option preferences.
+func (s *PostgresDriver) loadPlayerGameOptions(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    OPTION_ID,
+		    OPTION_VALUE
+		FROM
+		    PLAYER_GAME_OPTION
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var value string
+		err := rows.Scan(&id, &value)
+		if err != nil {
+			return err
+		}
+
+		p.SetGameOption(id, value)
+	}
+
+	return rows.Err()
+}
+
+// loadPlayerQuestStatuses loads a player's quest statuses.
+func (s *PostgresDriver) loadPlayerQuestStatuses(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    QUEST_ID,
+		    STATUS
+		FROM
+		    PLAYER_QUEST
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id, status int
+		err := rows.Scan(&id, &status)
+		if err != nil {
+			return err
+		}
+
+		p.SetQuestStatus(id, model.QuestStatus(status))
+	}
+
+	return rows.Err()
+}
+
+// loadPlayerQuestFlags loads a player's quest flags.
+func (s *PostgresDriver) loadPlayerQuestFlags(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    QUEST_ID,
+		    FLAG_ID,
+		    VALUE
+		FROM
+		    PLAYER_QUEST_FLAG
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id, flagID, value int
+		err := rows.Scan(&id, &flagID, &value)
+		if err != nil {
+			return err
+		}
+
+		p.SetQuestFlag(id, flagID, value)
+	}
+
+	return rows.Err()
+}
+
+// loadPlayerMusicTracks loads a player's music track unlock statuses.
+func (s *PostgresDriver) loadPlayerMusicTracks(p *model.Player) error {
+	rows, err := s.db.Query(`
+		SELECT
+		    MUSIC_ID,
+		    UNLOCKED
+		FROM
+		    PLAYER_MUSIC_TRACK
+		WHERE
+		    PLAYER_ID = $1
+	`, p.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var songID int
+		var enabled bool
+		err := rows.Scan(&songID, &enabled)
+		if err != nil {
+			return err
+		}
+
+		p.SetMusicTrackUnlocked(songID, enabled)
+	}
+
+	return rows.Err()
+}
+
+// savePlayer saves all of a player's data as part of a transaction.
+func (s *PostgresDriver) savePlayer(tx *sql.Tx, p *model.Player) error {
+	savers := []func(tx *sql.Tx, p *model.Player) error{
+		s.savePlayerInfo,
+		s.savePlayerEquipment,
+		s.savePlayerAppearance,
+		s.savePlayerLists,
+		s.savePlayerSkills,
+		s.savePlayerInventory,
+		s.savePlayerGameOptions,
+		s.savePlayerQuestStatuses,
+		s.savePlayerQuestFlags,
+		s.savePlayerMusicTracks,
+	}
+
+	for _, saver := range savers {
+		err := saver(tx, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// savePlayerInfo updates a player's basic information.
+func (s *PostgresDriver) savePlayerInfo(tx *sql.Tx, p *model.Player) error {
+	rs, err := tx.Exec(`
+		UPDATE
+			PLAYER
+		SET
+			GLOBAL_X = $1,
+			GLOBAL_Y = $2,
+			GLOBAL_Z = $3,
+			GENDER = $4,
+			UPDATE_DESIGN = $5,
+			FLAGGED = $6,
+			MUTED = $7,
+			MUTE_END_DTTM = $8,
+			MOVEMENT_SPEED = $9,
+			RUN_ENERGY = $10,
+			PUBLIC_CHAT_MODE = $11,
+			PRIVATE_CHAT_MODE = $12,
+			INTERACTION_MODE = $13,
+			AUTO_RETALIATE = $14,
+			LAST_LOGIN_DTTM = NOW()
+		WHERE
+		    ID = $15
+	`,
+		p.GlobalPos.X,
+		p.GlobalPos.Y,
+		p.GlobalPos.Z,
+		p.Appearance.Gender,
+		p.UpdateDesign,
+		p.Flagged,
+		p.Muted,
+		nullTime(p.MuteExpiry),
+		p.MovementSpeed,
+		p.RunEnergy,
+		p.Modes.PublicChat,
+		p.Modes.PrivateChat,
+		p.Modes.Interaction,
+		p.AutoRetaliate,
+		p.ID)
+	if err != nil {
+		return err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count != 1 {
+		return fmt.Errorf("expected 1 row update, got %d", count)
+	}
+
+	return nil
+}
+
+// savePlayerEquipment saves a player's equipment.
+func (s *PostgresDriver) savePlayerEquipment(tx *sql.Tx, p *model.Player) error {
+	var values []any
+	for slotID, slot := range p.Appearance.Equipment {
+		values = append(values, p.ID, int(slotID), slot.Item.ID, slot.Amount)
+	}
+
+	return s.replaceRows(tx, "PLAYER_EQUIPMENT", []string{"PLAYER_ID", "SLOT_ID", "ITEM_ID", "AMOUNT"}, p.ID, values)
+}
+
+// savePlayerAppearance saves a player's appearance information.
+func (s *PostgresDriver) savePlayerAppearance(tx *sql.Tx, p *model.Player) error {
+	_, err := tx.Exec(`
+		INSERT INTO PLAYER_APPEARANCE (
+		    PLAYER_ID,
+		    HEAD_ID,
+		    FACE_ID,
+		    BODY_ID,
+		    ARMS_ID,
+		    HANDS_ID,
+		    LEGS_ID,
+		    FEET_ID
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (PLAYER_ID) DO UPDATE SET
+		    HEAD_ID = EXCLUDED.HEAD_ID,
+		    FACE_ID = EXCLUDED.FACE_ID,
+		    BODY_ID = EXCLUDED.BODY_ID,
+		    ARMS_ID = EXCLUDED.ARMS_ID,
+		    HANDS_ID = EXCLUDED.HANDS_ID,
+		    LEGS_ID = EXCLUDED.LEGS_ID,
+		    FEET_ID = EXCLUDED.FEET_ID
+	`,
+		p.ID,
+		p.Appearance.Base.Head,
+		p.Appearance.Base.Face,
+		p.Appearance.Base.Body,
+		p.Appearance.Base.Arms,
+		p.Appearance.Base.Hands,
+		p.Appearance.Base.Legs,
+		p.Appearance.Base.Feet)
+	return err
+}
+
+// savePlayerLists saves a player's friends and ignored lists.
+func (s *PostgresDriver) savePlayerLists(tx *sql.Tx, p *model.Player) error {
+	_, err := tx.Exec("DELETE FROM PLAYER_LIST WHERE PLAYER_ID = $1", p.ID)
+	if err != nil {
+		return err
+	}
+
+	// if there's nothing to insert, bail out early
+	if len(p.Friends)+len(p.Ignored) == 0 {
+		return nil
+	}
+
+	// look up the ids of the listed players by their usernames, skipping those that no longer exist
+	var values []any
+	for _, username := range p.Friends {
+		values = append(values, username, playerListTypeFriend)
+	}
+
+	for _, username := range p.Ignored {
+		values = append(values, username, playerListTypeIgnored)
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO PLAYER_LIST (
+			PLAYER_ID,
+			OTHER_ID,
+			TYPE
+		)
+		SELECT
+		    $1, p.ID, l.TYPE
+		FROM
+		    (VALUES %s) AS l (USERNAME, TYPE)
+		JOIN
+		    PLAYER p ON LOWER(p.USERNAME) = LOWER(l.USERNAME)
+		ON CONFLICT DO NOTHING
+	`, postgresValues(len(values)/2, 2, 2, "::TEXT", "::INTEGER")), append([]any{p.ID}, values...)...)
+	return err
+}
+
+// savePlayerSkills saves a player's skills.
+func (s *PostgresDriver) savePlayerSkills(tx *sql.Tx, p *model.Player) error {
+	var values []any
+	for _, v := range p.Skills {
+		values = append(values, p.ID, int(v.Type), v.StatLevel, v.BaseLevel, v.Experience)
+	}
+
+	columns := []string{"PLAYER_ID", "SKILL_ID", "STAT_LEVEL", "LEVEL", "EXPERIENCE"}
+	return s.replaceRows(tx, "PLAYER_SKILL", columns, p.ID, values)
+}
+
+// savePlayerInventory saves a player's inventory.
+func (s *PostgresDriver) savePlayerInventory(tx *sql.Tx, p *model.Player) error {
+	var values []any
+	for _, v := range p.Inventory {
+		if v != nil {
+			values = append(values, p.ID, v.ID, v.Item.ID, v.Amount)
+		}
+	}
+
+	return s.replaceRows(tx, "PLAYER_INVENTORY", []string{"PLAYER_ID", "SLOT_ID", "ITEM_ID", "AMOUNT"}, p.ID, values)
+}
+
+// savePlayerGameOptions saves a player's game option preferences.
+func (s *PostgresDriver) savePlayerGameOptions(tx *sql.Tx, p *model.Player) error {
+	var values []any
+	for k, v := range p.GameOptions {
+		values = append(values, p.ID, k, v)
+	}
+
+	columns := []string{"PLAYER_ID", "OPTION_ID", "OPTION_VALUE"}
+	return s.replaceRows(tx, "PLAYER_GAME_OPTION", columns, p.ID, values)
+}
+
+// savePlayerQuestStatuses saves a player's quest statuses.
+func (s *PostgresDriver) savePlayerQuestStatuses(tx *sql.Tx, p *model.Player) error {
+	var values []any
+	for k, v := range p.QuestStatuses {
+		values = append(values, p.ID, k, int(v))
+	}
+
+	return s.replaceRows(tx, "PLAYER_QUEST", []string{"PLAYER_ID", "QUEST_ID", "STATUS"}, p.ID, values)
+}
+
+// savePlayerQuestFlags saves a player's quest flags.
+func (s *PostgresDriver) savePlayerQuestFlags(tx *sql.Tx, p *model.Player) error {
+	var values []any
+	for questID, flags := range p.QuestFlags {
+		for flagID, flagValue := range flags {
+			values = append(values, p.ID, questID, flagID, flagValue)
+		}
+	}
+
+	columns := []string{"PLAYER_ID", "QUEST_ID", "FLAG_ID", "VALUE"}
+	return s.replaceRows(tx, "PLAYER_QUEST_FLAG", columns, p.ID, values)
+}
+
+// savePlayerMusicTracks saves a player's music track unlock statuses.
+func (s *PostgresDriver) savePlayerMusicTracks(tx *sql.Tx, p *model.Player) error {
+	var values []any
+	for k, v := range p.MusicTracks {
+		values = append(values, p.ID, k, v)
+	}
+
+	return s.replaceRows(tx, "PLAYER_MUSIC_TRACK", []string{"PLAYER_ID", "MUSIC_ID", "UNLOCKED"}, p.ID, values)
+}
+
+// replaceRows deletes all rows in a table that belong to a player, and bulk inserts new rows in their place. The
+// values are a flattened list of each row's column values, in the same order as the columns.
+func (s *PostgresDriver) replaceRows(tx *sql.Tx, table string, columns []string, playerID int, values []any) error {
+	_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE PLAYER_ID = $1", table), playerID)
+	if err != nil {
+		return err
+	}
+
+	// bail out if there is nothing to insert
+	if len(values) == 0 {
+		return nil
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, strings.Join(columns, ", "),
+		postgresValues(len(values)/len(columns), len(columns), 1))
+
+	_, err = tx.Exec(insert, values...)
+	return err
+}
+
+// postgresValues returns a list of tuples with numbered placeholders for a bulk insert, starting at a placeholder
+// index. Optional casts are appended to the placeholders of each column in turn.
+func postgresValues(rows, columns, start int, casts ...string) string {
+	tuples := make([]string, rows)
+	for i := 0; i < rows; i++ {
+		placeholders := make([]string, columns)
+		for j := 0; j < columns; j++ {
+			placeholders[j] = fmt.Sprintf("$%d", start+i*columns+j)
+			if j < len(casts) {
+				placeholders[j] += casts[j]
+			}
+		}
+
+		tuples[i] = fmt.Sprintf("(%s)", strings.Join(placeholders, ", "))
+	}
+
+	return strings.Join(tuples, ", ")
+}
+
+// nullTime returns a nullable value for an optional point in time.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *t, Valid: true}
+}
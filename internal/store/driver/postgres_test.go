@@ -0,0 +1,176 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+// postgresTestURIEnv is the environment variable with the URI of a PostgreSQL database used by integration tests.
+const postgresTestURIEnv = "OPENMCS_POSTGRES_URI"
+
+func Test_postgresValues(t *testing.T) {
+	assert.Equal(t, "($1, $2), ($3, $4)", postgresValues(2, 2, 1))
+	assert.Equal(t, "($2::TEXT, $3), ($4::TEXT, $5)", postgresValues(2, 2, 2, "::TEXT"))
+}
+
+func Test_PostgresDriver_playerRoundTrip(t *testing.T) {
+	d := newTestPostgresDriver(t)
+
+	username := fmt.Sprintf("pg%d", time.Now().UnixNano()%1000000000)
+	friend := fmt.Sprintf("%sf", username)
+
+	other := model.NewPlayer(friend)
+	require.NoError(t, d.CreatePlayer(other))
+	defer d.DeletePlayer(other.ID)
+
+	memberExpiry := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	p := model.NewPlayer(username)
+	p.PasswordHash = "hash"
+	p.GlobalPos = model.Vector3D{X: 3222, Y: 3218, Z: 0}
+	p.Member = true
+	p.MemberExpiry = &memberExpiry
+	p.Friends = []string{friend, "nobody"}
+	p.SetSkillExperience(model.SkillTypeAttack, 277)
+	p.SetInventoryItem(&model.Item{ID: 1351}, 1, 0)
+	p.SetEquippedItem(&model.Item{ID: 1333}, 1, model.EquipmentSlotTypeWeapon)
+	p.SetGameOption(1, "2")
+	p.SetQuestStatus(3, model.QuestStatusInProgress)
+	p.SetQuestFlag(3, 1, 5)
+	p.SetMusicTrackUnlocked(7, true)
+
+	require.NoError(t, d.CreatePlayer(p))
+	defer d.DeletePlayer(p.ID)
+
+	loaded, err := d.LoadPlayer(username)
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+
+	assert.Equal(t, p.ID, loaded.ID)
+	assert.Equal(t, p.GlobalPos, loaded.GlobalPos)
+	assert.True(t, loaded.Member)
+	assert.True(t, memberExpiry.Equal(*loaded.MemberExpiry))
+	assert.Equal(t, []string{friend}, loaded.Friends)
+	assert.Equal(t, p.Skills[model.SkillTypeAttack].Experience, loaded.Skills[model.SkillTypeAttack].Experience)
+	assert.Equal(t, 1351, loaded.Inventory[0].Item.ID)
+	assert.Equal(t, 1333, loaded.EquipmentSlot(model.EquipmentSlotTypeWeapon).Item.ID)
+	assert.Equal(t, "2", loaded.GameOptions[1])
+	assert.Equal(t, model.QuestStatusInProgress, loaded.QuestStatuses[3])
+	assert.Equal(t, 5, loaded.QuestFlags[3][1])
+	assert.True(t, loaded.MusicTracks[7])
+
+	// changes to the player should replace their previous data
+	loaded.GlobalPos.X = 3000
+	loaded.ClearInventoryItem(0)
+	loaded.SetMusicTrackUnlocked(8, true)
+	require.NoError(t, d.SavePlayer(loaded))
+
+	reloaded, err := d.LoadPlayer(username)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3000, reloaded.GlobalPos.X)
+	assert.Nil(t, reloaded.Inventory[0])
+	assert.Len(t, reloaded.MusicTracks, 2)
+}
+
+func Test_PostgresDriver_LoadPlayer_caseInsensitive(t *testing.T) {
+	d := newTestPostgresDriver(t)
+
+	username := fmt.Sprintf("Pg%d", time.Now().UnixNano()%1000000000)
+	p := model.NewPlayer(username)
+	require.NoError(t, d.CreatePlayer(p))
+	defer d.DeletePlayer(p.ID)
+
+	loaded, err := d.LoadPlayer(fmt.Sprintf("pG%s", username[2:]))
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, p.ID, loaded.ID)
+}
+
+// newTestPostgresDriver connects to the PostgreSQL database used for integration tests and applies all migrations. The
+// test is skipped if no database is configured.
+func newTestPostgresDriver(t *testing.T) Driver {
+	uri := os.Getenv(postgresTestURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", postgresTestURIEnv)
+	}
+
+	d, err := NewPostgresDriver(&config.PostgresDatabaseConfig{URI: uri}, config.StorePoolConfig{MaxOpenConns: 2})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = d.Close()
+	})
+
+	handle, err := d.Migration()
+	require.NoError(t, err)
+
+	m, err := migrate.NewWithDatabaseInstance("file://../../../migrations/postgres", "postgres", handle)
+	require.NoError(t, err)
+
+	err = m.Up()
+	if err != migrate.ErrNoChange {
+		require.NoError(t, err)
+	}
+
+	return d
+}
+
+func Test_PostgresDriver_worldState(t *testing.T) {
+	d := newTestPostgresDriver(t)
+
+	// use a world that won't collide with a real one sharing the database
+	worldID := -int(time.Now().UnixNano() % 1000000)
+	defer d.SaveWorldState(worldID, &model.WorldState{})
+
+	remaining := 60
+	require.NoError(t, d.SaveWorldState(worldID, &model.WorldState{
+		GroundItems: []*model.WorldGroundItem{
+			{ItemID: 995, Amount: 500, GlobalPos: model.Vector3D{X: 3222, Y: 3218}},
+			{ItemID: 1333, Amount: 1, GlobalPos: model.Vector3D{X: 3222, Y: 3218}, RemainingSeconds: &remaining},
+		},
+	}))
+
+	state, err := d.LoadWorldState(worldID)
+	require.NoError(t, err)
+	require.Len(t, state.GroundItems, 2)
+	assert.Nil(t, state.GroundItems[0].RemainingSeconds)
+	assert.Equal(t, 60, *state.GroundItems[1].RemainingSeconds)
+}
+
+func Test_PostgresDriver_playerSnapshots(t *testing.T) {
+	d := newTestPostgresDriver(t)
+
+	p := model.NewPlayer(fmt.Sprintf("pg%d", time.Now().UnixNano()%1000000000))
+	require.NoError(t, d.CreatePlayer(p))
+	defer d.DeletePlayer(p.ID)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, d.SavePlayerSnapshot(&model.PlayerSnapshot{
+			PlayerID:      p.ID,
+			SchemaVersion: 1,
+			Data:          []byte{byte(i)},
+			CreatedAt:     time.Now(),
+		}, 2))
+	}
+
+	snapshots, err := d.LoadPlayerSnapshots(p.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Nil(t, snapshots[0].Data)
+
+	snapshot, err := d.LoadPlayerSnapshot(p.ID, snapshots[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, snapshot.Data)
+
+	snapshot, err = d.LoadPlayerSnapshot(p.ID+1, snapshots[0].ID)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/mbpolan/openmcs/internal/config"
 	"github.com/mbpolan/openmcs/internal/model"
 	"github.com/pkg/errors"
-	"math"
 	_ "modernc.org/sqlite"
 	"strings"
 	"time"
@@ -200,144 +199,1016 @@ func (s *SQLite3Driver) SavePlayer(p *model.Player) error {
 	}
 	defer tx.Rollback()
 
-	// save the player's basic information
-	err = s.savePlayerInfo(p)
+	err = s.savePlayer(tx, p)
 	if err != nil {
 		return err
 	}
 
-	// save their equipment
-	err = s.savePlayerEquipment(p)
+	return tx.Commit()
+}
+
+// LoadPlayer loads information about a player from a SQLite3 database.
+func (s *SQLite3Driver) LoadPlayer(username string) (*model.Player, error) {
+	// prepare a player model for populating
+	p := model.NewPlayer(username)
+
+	// load their basic information first
+	err := s.loadPlayerInfo(username, p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	// load their equipped items
+	err = s.loadPlayerEquipment(p.ID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their appearance
+	err = s.loadPlayerAppearance(p.ID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their friends and ignored lists
+	err = s.loadPlayerLists(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their skills
+	err = s.loadPlayerSkills(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their inventory
+	err = s.loadPlayerInventory(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their game options
+	err = s.loadPlayerGameOptions(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their quest statuses
+	err = s.loadPlayerQuestStatuses(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their quest flags
+	err = s.loadPlayerQuestFlags(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// load their music tracks
+	err = s.loadPlayerMusicTracks(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// CreatePlayer creates a new player in a SQLite3 database, and assigns their ID.
+func (s *SQLite3Driver) CreatePlayer(p *model.Player) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var memberEndDate sql.NullString
+	if p.MemberExpiry != nil {
+		memberEndDate.String = p.MemberExpiry.UTC().Format(dateFormat)
+		memberEndDate.Valid = true
+	}
+
+	rs, err := tx.Exec(`
+		INSERT INTO PLAYER (
+		    USERNAME,
+		    USERNAME_NORMALIZED,
+		    PASSWORD_HASH,
+		    EMAIL,
+		    GLOBAL_X,
+		    GLOBAL_Y,
+		    GLOBAL_Z,
+		    GENDER,
+		    UPDATE_DESIGN,
+		    FLAGGED,
+		    MUTED,
+		    MOVEMENT_SPEED,
+		    RUN_ENERGY,
+		    PUBLIC_CHAT_MODE,
+		    PRIVATE_CHAT_MODE,
+		    INTERACTION_MODE,
+		    AUTO_RETALIATE,
+		    TYPE,
+		    MEMBER,
+		    MEMBER_END_DTTM
+		)
+		VALUES (?, ?, ?, '', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		p.Username,
+		model.NormalizeUsername(p.Username),
+		p.PasswordHash,
+		p.GlobalPos.X,
+		p.GlobalPos.Y,
+		p.GlobalPos.Z,
+		p.Appearance.Gender,
+		p.UpdateDesign,
+		p.Flagged,
+		p.Muted,
+		p.MovementSpeed,
+		p.RunEnergy,
+		p.Modes.PublicChat,
+		p.Modes.PrivateChat,
+		p.Modes.Interaction,
+		p.AutoRetaliate,
+		p.Type,
+		p.Member,
+		memberEndDate)
+	if err != nil {
+		return err
+	}
+
+	id, err := rs.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO PLAYER_APPEARANCE (
+		    PLAYER_ID,
+		    HEAD_ID,
+		    FACE_ID,
+		    BODY_ID,
+		    ARMS_ID,
+		    HANDS_ID,
+		    LEGS_ID,
+		    FEET_ID
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		id,
+		p.Appearance.Base.Head,
+		p.Appearance.Base.Face,
+		p.Appearance.Base.Body,
+		p.Appearance.Base.Arms,
+		p.Appearance.Base.Hands,
+		p.Appearance.Base.Legs,
+		p.Appearance.Base.Feet)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	p.ID = int(id)
+
+	// save the remainder of the player's data, removing the player if that fails so a partial account is not left
+	// behind
+	err = s.SavePlayer(p)
+	if err != nil {
+		_ = s.DeletePlayer(p.ID)
+		return err
+	}
+
+	return nil
+}
+
+// DeletePlayer removes a player and all of their data from a SQLite3 database.
+func (s *SQLite3Driver) DeletePlayer(playerID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// remove rows from tables that reference the player before removing the player itself
+	tables := []string{
+		"PLAYER_EQUIPMENT",
+		"PLAYER_APPEARANCE",
+		"PLAYER_SKILL",
+		"PLAYER_INVENTORY",
+		"PLAYER_GAME_OPTION",
+		"PLAYER_QUEST_FLAG",
+		"PLAYER_QUEST",
+		"PLAYER_MUSIC_TRACK",
+		"PLAYER_SNAPSHOT",
+	}
+
+	for _, table := range tables {
+		_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE PLAYER_ID = ?", table), playerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the player may also appear on the friends and ignored lists of other players
+	_, err = tx.Exec("DELETE FROM PLAYER_LIST WHERE PLAYER_ID = ? OR OTHER_ID = ?", playerID, playerID)
+	if err != nil {
+		return err
+	}
+
+	rs, err := tx.Exec("DELETE FROM PLAYER WHERE ID = ?", playerID)
+	if err != nil {
+		return err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count != 1 {
+		return fmt.Errorf("expected 1 row deleted for player ID %d, got %d", playerID, count)
+	}
+
+	return tx.Commit()
+}
+
+// SavePasswordHash updates the hash of a player's password in a SQLite3 database.
+func (s *SQLite3Driver) SavePasswordHash(playerID int, hash string) error {
+	stmt, err := s.db.Prepare(`
+		UPDATE
+			PLAYER
+		SET
+			PASSWORD_HASH = ?
+		WHERE
+			ID = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(hash, playerID)
+	return err
+}
+
+// ReplacePlayer overwrites the account details and all data of an existing player in a SQLite3 database in a single
+// transaction, keeping their ID.
+func (s *SQLite3Driver) ReplacePlayer(p *model.Player) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var memberEndDate sql.NullString
+	if p.MemberExpiry != nil {
+		memberEndDate.String = p.MemberExpiry.UTC().Format(dateFormat)
+		memberEndDate.Valid = true
+	}
+
+	// account details are not changed when a player is saved, so they are replaced first
+	_, err = tx.Exec(`
+		UPDATE
+			PLAYER
+		SET
+			USERNAME = ?,
+			USERNAME_NORMALIZED = ?,
+			PASSWORD_HASH = ?,
+			TYPE = ?,
+			MEMBER = ?,
+			MEMBER_END_DTTM = ?
+		WHERE
+			ID = ?
+	`,
+		p.Username,
+		model.NormalizeUsername(p.Username),
+		p.PasswordHash,
+		p.Type,
+		p.Member,
+		memberEndDate,
+		p.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.savePlayer(tx, p)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SavePlayerSnapshot creates a new snapshot of a player's data in a SQLite3 database, and removes the player's oldest
+// snapshots beyond the retain amount.
+func (s *SQLite3Driver) SavePlayerSnapshot(snapshot *model.PlayerSnapshot, retain int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rs, err := tx.Exec(`
+		INSERT INTO PLAYER_SNAPSHOT (
+			PLAYER_ID,
+			SCHEMA_VERSION,
+			DATA,
+			CREATED_DTTM
+		)
+		VALUES (?, ?, ?, ?)
+	`, snapshot.PlayerID, snapshot.SchemaVersion, snapshot.Data, snapshot.CreatedAt.UTC().Format(dateFormat))
+	if err != nil {
+		return err
+	}
+
+	id, err := rs.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM
+			PLAYER_SNAPSHOT
+		WHERE
+			PLAYER_ID = ?
+			AND ID NOT IN (
+				SELECT
+					ID
+				FROM
+					PLAYER_SNAPSHOT
+				WHERE
+					PLAYER_ID = ?
+				ORDER BY
+					ID DESC
+				LIMIT ?
+			)
+	`, snapshot.PlayerID, snapshot.PlayerID, retain)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	snapshot.ID = int(id)
+	return nil
+}
+
+// LoadPlayerSnapshots loads all snapshots of a player without their data from a SQLite3 database, newest first.
+func (s *SQLite3Driver) LoadPlayerSnapshots(playerID int) ([]*model.PlayerSnapshot, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ID,
+		    SCHEMA_VERSION,
+		    CREATED_DTTM
+		FROM
+		    PLAYER_SNAPSHOT
+		WHERE
+		    PLAYER_ID = ?
+		ORDER BY
+		    ID DESC
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var snapshots []*model.PlayerSnapshot
+	for rows.Next() {
+		snapshot := &model.PlayerSnapshot{PlayerID: playerID}
+		var createdAt string
+
+		err := rows.Scan(&snapshot.ID, &snapshot.SchemaVersion, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.CreatedAt, err = time.Parse(dateFormat, createdAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse PLAYER_SNAPSHOT CREATED_DTTM")
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// LoadPlayerSnapshot loads a snapshot of a player along with its data from a SQLite3 database.
+func (s *SQLite3Driver) LoadPlayerSnapshot(playerID, snapshotID int) (*model.PlayerSnapshot, error) {
+	snapshot := &model.PlayerSnapshot{ID: snapshotID, PlayerID: playerID}
+	var createdAt string
+
+	err := s.db.QueryRow(`
+		SELECT
+		    SCHEMA_VERSION,
+		    DATA,
+		    CREATED_DTTM
+		FROM
+		    PLAYER_SNAPSHOT
+		WHERE
+		    PLAYER_ID = ?
+		    AND ID = ?
+	`, playerID, snapshotID).Scan(&snapshot.SchemaVersion, &snapshot.Data, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	snapshot.CreatedAt, err = time.Parse(dateFormat, createdAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse PLAYER_SNAPSHOT CREATED_DTTM")
+	}
+
+	return snapshot, nil
+}
+
+// SavePendingRollback records a snapshot that a player is rolled back to the next time they log in in a SQLite3
+// database.
+func (s *SQLite3Driver) SavePendingRollback(playerID, snapshotID int) error {
+	var pending sql.NullInt64
+	if snapshotID != 0 {
+		pending = sql.NullInt64{Int64: int64(snapshotID), Valid: true}
+	}
+
+	rs, err := s.db.Exec(`
+		UPDATE
+			PLAYER
+		SET
+			PENDING_ROLLBACK_ID = ?
+		WHERE
+			ID = ?
+	`, pending, playerID)
+	if err != nil {
+		return err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count != 1 {
+		return fmt.Errorf("expected 1 row updated for player ID %d, got %d", playerID, count)
+	}
+
+	return nil
+}
+
+// LoadPendingRollback loads the ID of the snapshot that a player is rolled back to the next time they log in from a
+// SQLite3 database.
+func (s *SQLite3Driver) LoadPendingRollback(playerID int) (int, error) {
+	var pending sql.NullInt64
+
+	err := s.db.QueryRow(`
+		SELECT
+		    PENDING_ROLLBACK_ID
+		FROM
+		    PLAYER
+		WHERE
+		    ID = ?
+	`, playerID).Scan(&pending)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no player with ID %d", playerID)
+	} else if err != nil {
+		return 0, err
+	}
+
+	return int(pending.Int64), nil
+}
+
+// LoadLoginAttempts loads failed login attempts tracked against a username or network address from a SQLite3
+// database.
+func (s *SQLite3Driver) LoadLoginAttempts(source model.LoginAttemptSource, key string) (*model.LoginAttempts, error) {
+	stmt, err := s.db.Prepare(`
+		SELECT
+		    FAILURES,
+		    LOCKOUTS,
+		    LAST_FAILURE_DTTM,
+		    LOCKED_UNTIL_DTTM
+		FROM
+		    LOGIN_ATTEMPT
+		WHERE
+		    SOURCE = ?
+		    AND SOURCE_KEY = ?
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	var attempts model.LoginAttempts
+	var lastFailure string
+	var lockedUntil sql.NullString
+
+	err = stmt.QueryRow(source, key).Scan(&attempts.Failures, &attempts.Lockouts, &lastFailure, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	attempts.LastFailure, err = time.Parse(dateFormat, lastFailure)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse LOGIN_ATTEMPT LAST_FAILURE_DTTM")
+	}
+
+	if lockedUntil.Valid {
+		attempts.LockedUntil, err = time.Parse(dateFormat, lockedUntil.String)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse LOGIN_ATTEMPT LOCKED_UNTIL_DTTM")
+		}
+	}
+
+	return &attempts, nil
+}
+
+// SaveLoginAttempts saves failed login attempts tracked against a username or network address to a SQLite3 database.
+func (s *SQLite3Driver) SaveLoginAttempts(source model.LoginAttemptSource, key string, attempts *model.LoginAttempts) error {
+	stmt, err := s.db.Prepare(`
+		INSERT INTO LOGIN_ATTEMPT (
+			SOURCE,
+			SOURCE_KEY,
+			FAILURES,
+			LOCKOUTS,
+			LAST_FAILURE_DTTM,
+			LOCKED_UNTIL_DTTM
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (SOURCE, SOURCE_KEY) DO UPDATE SET
+			FAILURES = EXCLUDED.FAILURES,
+			LOCKOUTS = EXCLUDED.LOCKOUTS,
+			LAST_FAILURE_DTTM = EXCLUDED.LAST_FAILURE_DTTM,
+			LOCKED_UNTIL_DTTM = EXCLUDED.LOCKED_UNTIL_DTTM
+	`)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	var lockedUntil sql.NullString
+	if !attempts.LockedUntil.IsZero() {
+		lockedUntil.String = attempts.LockedUntil.UTC().Format(dateFormat)
+		lockedUntil.Valid = true
+	}
+
+	_, err = stmt.Exec(source, key, attempts.Failures, attempts.Lockouts,
+		attempts.LastFailure.UTC().Format(dateFormat), lockedUntil)
+	return err
+}
+
+// DeleteLoginAttempts removes all failed login attempts tracked against a username or network address from a SQLite3
+// database.
+func (s *SQLite3Driver) DeleteLoginAttempts(source model.LoginAttemptSource, key string) error {
+	stmt, err := s.db.Prepare(`
+		DELETE FROM
+			LOGIN_ATTEMPT
+		WHERE
+			SOURCE = ?
+			AND SOURCE_KEY = ?
+	`)
 	if err != nil {
 		return err
 	}
 
-	// save their appearance
-	err = s.savePlayerAppearance(p)
+	defer stmt.Close()
+
+	_, err = stmt.Exec(source, key)
+	return err
+}
+
+// SaveLoginAudit records a login attempt in a SQLite3 database.
+func (s *SQLite3Driver) SaveLoginAudit(entry *model.LoginAuditEntry) error {
+	stmt, err := s.db.Prepare(`
+		INSERT INTO LOGIN_AUDIT (
+			USERNAME,
+			ADDRESS,
+			CLIENT_UID,
+			RESULT
+		)
+		VALUES (?, ?, ?, ?)
+	`)
 	if err != nil {
 		return err
 	}
 
-	// save their friends and ignored lists
-	err = s.savePlayerLists(p)
+	defer stmt.Close()
+
+	_, err = stmt.Exec(entry.Username, entry.Address, entry.UID, entry.Result)
+	return err
+}
+
+// LoadBans loads all bans applied against a username, network address or client identifier from a SQLite3 database.
+func (s *SQLite3Driver) LoadBans(target model.BanTarget, value string) ([]*model.Ban, error) {
+	stmt, err := s.db.Prepare(`
+		SELECT
+		    ID,
+		    REASON,
+		    ISSUED_BY,
+		    ISSUED_DTTM,
+		    EXPIRES_DTTM
+		FROM
+		    BAN
+		WHERE
+		    TARGET = ?
+		    AND TARGET_VALUE = ?
+	`)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(target, value)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var bans []*model.Ban
+	for rows.Next() {
+		ban := &model.Ban{
+			Target: target,
+			Value:  value,
+		}
+
+		var issuedAt string
+		var expiresAt sql.NullString
+
+		err := rows.Scan(&ban.ID, &ban.Reason, &ban.IssuedBy, &issuedAt, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		ban.IssuedAt, err = time.Parse(dateFormat, issuedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse BAN ISSUED_DTTM")
+		}
+
+		if expiresAt.Valid {
+			expires, err := time.Parse(dateFormat, expiresAt.String)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to parse BAN EXPIRES_DTTM")
+			}
+
+			ban.ExpiresAt = &expires
+		}
+
+		bans = append(bans, ban)
 	}
 
-	// save their skills
-	err = s.savePlayerSkills(p)
+	return bans, rows.Err()
+}
+
+// SaveBan creates a new ban in a SQLite3 database, and assigns its ID.
+func (s *SQLite3Driver) SaveBan(ban *model.Ban) error {
+	stmt, err := s.db.Prepare(`
+		INSERT INTO BAN (
+			TARGET,
+			TARGET_VALUE,
+			REASON,
+			ISSUED_BY,
+			ISSUED_DTTM,
+			EXPIRES_DTTM
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
 	if err != nil {
 		return err
 	}
 
-	// save their inventory
-	err = s.savePlayerInventory(p)
+	defer stmt.Close()
+
+	var expiresAt sql.NullString
+	if ban.ExpiresAt != nil {
+		expiresAt.String = ban.ExpiresAt.UTC().Format(dateFormat)
+		expiresAt.Valid = true
+	}
+
+	rs, err := stmt.Exec(ban.Target, ban.Value, ban.Reason, ban.IssuedBy, ban.IssuedAt.UTC().Format(dateFormat),
+		expiresAt)
 	if err != nil {
 		return err
 	}
 
-	// save their game options
-	err = s.savePlayerGameOptions(p)
+	id, err := rs.LastInsertId()
 	if err != nil {
 		return err
 	}
 
-	// save their quest statuses
-	err = s.savePlayerQuestStatuses(p)
+	ban.ID = int(id)
+	return nil
+}
+
+// DeleteBans removes all bans applied against a username, network address or client identifier from a SQLite3
+// database.
+func (s *SQLite3Driver) DeleteBans(target model.BanTarget, value string) (int, error) {
+	stmt, err := s.db.Prepare(`
+		DELETE FROM
+			BAN
+		WHERE
+			TARGET = ?
+			AND TARGET_VALUE = ?
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	rs, err := stmt.Exec(target, value)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := rs.RowsAffected()
+	return int(count), err
+}
+
+// SaveMute updates whether a player is muted, and when their mute ends, in a SQLite3 database.
+func (s *SQLite3Driver) SaveMute(username string, muted bool, expiresAt *time.Time) (bool, error) {
+	stmt, err := s.db.Prepare(`
+		UPDATE
+			PLAYER
+		SET
+			MUTED = ?,
+			MUTE_END_DTTM = ?
+		WHERE
+			USERNAME = ? COLLATE NOCASE
+	`)
+	if err != nil {
+		return false, err
+	}
+
+	defer stmt.Close()
+
+	var muteEndDate sql.NullString
+	if expiresAt != nil {
+		muteEndDate.String = expiresAt.UTC().Format(dateFormat)
+		muteEndDate.Valid = true
+	}
+
+	rs, err := stmt.Exec(muted, muteEndDate, username)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := rs.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// SaveAbuseReport creates a new abuse report along with its attached chat messages in a SQLite3 database, and assigns
+// its ID.
+func (s *SQLite3Driver) SaveAbuseReport(report *model.AbuseReport) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	// save their quest flags
-	err = s.savePlayerQuestFlags(p)
+	rs, err := tx.Exec(`
+		INSERT INTO ABUSE_REPORT (
+			REPORTER,
+			OFFENDER,
+			REASON,
+			MUTED,
+			CREATED_DTTM
+		)
+		VALUES (?, ?, ?, ?, ?)
+	`, report.Reporter, report.Offender, report.Reason, report.Muted, report.CreatedAt.UTC().Format(dateFormat))
 	if err != nil {
 		return err
 	}
 
-	// save their music tracks
-	err = s.savePlayerMusicTracks(p)
+	id, err := rs.LastInsertId()
 	if err != nil {
 		return err
 	}
 
+	for i, entry := range report.Chat {
+		var recipient sql.NullString
+		if entry.Recipient != "" {
+			recipient.String = entry.Recipient
+			recipient.Valid = true
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO ABUSE_REPORT_CHAT (
+				REPORT_ID,
+				SEQUENCE,
+				RECIPIENT,
+				TEXT,
+				SENT_DTTM
+			)
+			VALUES (?, ?, ?, ?, ?)
+		`, id, i, recipient, entry.Text, entry.SentAt.UTC().Format(dateFormat))
+		if err != nil {
+			return err
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return err
 	}
 
+	report.ID = int(id)
 	return nil
 }
 
-// LoadPlayer loads information about a player from a SQLite3 database.
-func (s *SQLite3Driver) LoadPlayer(username string) (*model.Player, error) {
-	// prepare a player model for populating
-	p := model.NewPlayer(username)
-
-	// load their basic information first
-	err := s.loadPlayerInfo(username, p)
+// LoadOpenAbuseReports loads all abuse reports that have not been resolved from a SQLite3 database, oldest first.
+func (s *SQLite3Driver) LoadOpenAbuseReports() ([]*model.AbuseReport, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ID,
+		    REPORTER,
+		    OFFENDER,
+		    REASON,
+		    MUTED,
+		    CREATED_DTTM
+		FROM
+		    ABUSE_REPORT
+		WHERE
+		    RESOLVED_DTTM IS NULL
+		ORDER BY
+		    ID
+	`)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var reports []*model.AbuseReport
+	for rows.Next() {
+		report := &model.AbuseReport{}
+		var createdAt string
+
+		err := rows.Scan(&report.ID, &report.Reporter, &report.Offender, &report.Reason, &report.Muted, &createdAt)
+		if err != nil {
+			return nil, err
 		}
 
-		return nil, err
+		report.CreatedAt, err = time.Parse(dateFormat, createdAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse ABUSE_REPORT CREATED_DTTM")
+		}
+
+		reports = append(reports, report)
 	}
 
-	// load their equipped items
-	err = s.loadPlayerEquipment(p.ID, p)
+	err = rows.Err()
 	if err != nil {
 		return nil, err
 	}
 
-	// load their appearance
-	err = s.loadPlayerAppearance(p.ID, p)
-	if err != nil {
-		return nil, err
+	// load the chat messages attached to each report
+	for _, report := range reports {
+		report.Chat, err = s.loadAbuseReportChat(report.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// load their friends and ignored lists
-	err = s.loadPlayerLists(p)
+	return reports, nil
+}
+
+// ResolveAbuseReport marks an open abuse report as resolved in a SQLite3 database.
+func (s *SQLite3Driver) ResolveAbuseReport(id int, resolvedBy string, resolvedAt time.Time) (bool, error) {
+	stmt, err := s.db.Prepare(`
+		UPDATE
+			ABUSE_REPORT
+		SET
+			RESOLVED_BY = ?,
+			RESOLVED_DTTM = ?
+		WHERE
+			ID = ?
+			AND RESOLVED_DTTM IS NULL
+	`)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	// load their skills
-	err = s.loadPlayerSkills(p)
+	defer stmt.Close()
+
+	rs, err := stmt.Exec(resolvedBy, resolvedAt.UTC().Format(dateFormat), id)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	// load their inventory
-	err = s.loadPlayerInventory(p)
+	count, err := rs.RowsAffected()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	// load their game options
-	err = s.loadPlayerGameOptions(p)
+	return count > 0, nil
+}
+
+// LoadWorldState loads the mutable state of a game world's map from a SQLite3 database.
+func (s *SQLite3Driver) LoadWorldState(worldID int) (*model.WorldState, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    ITEM_ID,
+		    AMOUNT,
+		    GLOBAL_X,
+		    GLOBAL_Y,
+		    GLOBAL_Z,
+		    REMAINING_SECONDS
+		FROM
+		    WORLD_GROUND_ITEM
+		WHERE
+		    WORLD_ID = ?
+		ORDER BY
+		    SEQUENCE
+	`, worldID)
 	if err != nil {
 		return nil, err
 	}
 
-	// load their quest statuses
-	err = s.loadPlayerQuestStatuses(p)
-	if err != nil {
-		return nil, err
+	defer rows.Close()
+
+	state := &model.WorldState{}
+	for rows.Next() {
+		item := &model.WorldGroundItem{}
+		var remainingSeconds sql.NullInt32
+
+		err := rows.Scan(&item.ItemID, &item.Amount, &item.GlobalPos.X, &item.GlobalPos.Y, &item.GlobalPos.Z,
+			&remainingSeconds)
+		if err != nil {
+			return nil, err
+		}
+
+		if remainingSeconds.Valid {
+			remaining := int(remainingSeconds.Int32)
+			item.RemainingSeconds = &remaining
+		}
+
+		state.GroundItems = append(state.GroundItems, item)
 	}
 
-	// load their quest flags
-	err = s.loadPlayerQuestFlags(p)
+	return state, rows.Err()
+}
+
+// SaveWorldState replaces the mutable state of a game world's map in a SQLite3 database.
+func (s *SQLite3Driver) SaveWorldState(worldID int, state *model.WorldState) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer tx.Rollback()
 
-	// load their music tracks
-	err = s.loadPlayerMusicTracks(p)
+	_, err = tx.Exec("DELETE FROM WORLD_GROUND_ITEM WHERE WORLD_ID = ?", worldID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return p, nil
+	for i, item := range state.GroundItems {
+		var remainingSeconds sql.NullInt32
+		if item.RemainingSeconds != nil {
+			remainingSeconds.Int32 = int32(*item.RemainingSeconds)
+			remainingSeconds.Valid = true
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO WORLD_GROUND_ITEM (
+				WORLD_ID,
+				SEQUENCE,
+				ITEM_ID,
+				AMOUNT,
+				GLOBAL_X,
+				GLOBAL_Y,
+				GLOBAL_Z,
+				REMAINING_SECONDS
+			)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, worldID, i, item.ItemID, item.Amount, item.GlobalPos.X, item.GlobalPos.Y, item.GlobalPos.Z,
+			remainingSeconds)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 // Close cleans up resources used by the SQLite3 driver.
@@ -368,11 +1239,12 @@ func (s *SQLite3Driver) loadPlayerInfo(username string, p *model.Player) error {
 		    AUTO_RETALIATE,
 		    TYPE,
 		    MEMBER,
-		    MEMBER_END_DTTM
+		    MEMBER_END_DTTM,
+		    MUTE_END_DTTM
 		FROM
 		    PLAYER
 		WHERE
-		    USERNAME = ? COLLATE NOCASE
+		    USERNAME_NORMALIZED = ?
 	`)
 	if err != nil {
 		return err
@@ -381,9 +1253,9 @@ func (s *SQLite3Driver) loadPlayerInfo(username string, p *model.Player) error {
 	defer stmt.Close()
 
 	// expect exactly zero or one row
-	row := stmt.QueryRow(username)
+	row := stmt.QueryRow(model.NormalizeUsername(username))
 
-	var memberEndDate sql.NullString
+	var memberEndDate, muteEndDate sql.NullString
 
 	// extract their data into their model
 	err = row.Scan(
@@ -405,7 +1277,8 @@ func (s *SQLite3Driver) loadPlayerInfo(username string, p *model.Player) error {
 		&p.AutoRetaliate,
 		&p.Type,
 		&p.Member,
-		&memberEndDate)
+		&memberEndDate,
+		&muteEndDate)
 	if err != nil {
 		return err
 	}
@@ -417,12 +1290,65 @@ func (s *SQLite3Driver) loadPlayerInfo(username string, p *model.Player) error {
 			return errors.Wrap(err, "failed to parse player MEMBER_END_DTTM")
 		}
 
-		p.MemberDays = int(math.Floor(time.Now().Sub(endDate).Hours() * 24))
+		p.MemberExpiry = &endDate
+	}
+
+	// parse the mute end date if available
+	if muteEndDate.Valid {
+		endDate, err := time.Parse(dateFormat, muteEndDate.String)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse player MUTE_END_DTTM")
+		}
+
+		p.MuteExpiry = &endDate
 	}
 
 	return nil
 }
 
+// loadAbuseReportChat loads the chat messages attached to an abuse report.
+func (s *SQLite3Driver) loadAbuseReportChat(reportID int) ([]*model.ChatLogEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    RECIPIENT,
+		    TEXT,
+		    SENT_DTTM
+		FROM
+		    ABUSE_REPORT_CHAT
+		WHERE
+		    REPORT_ID = ?
+		ORDER BY
+		    SEQUENCE
+	`, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []*model.ChatLogEntry
+	for rows.Next() {
+		entry := &model.ChatLogEntry{}
+		var recipient sql.NullString
+		var sentAt string
+
+		err := rows.Scan(&recipient, &entry.Text, &sentAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Recipient = recipient.String
+		entry.SentAt, err = time.Parse(dateFormat, sentAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse ABUSE_REPORT_CHAT SENT_DTTM")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
 // loadPlayerEquipment loads a player's equipped items.
 func (s *SQLite3Driver) loadPlayerEquipment(id int, p *model.Player) error {
 	// query for each slot the player has an equipped item
@@ -783,9 +1709,34 @@ func (s *SQLite3Driver) loadPlayerMusicTracks(p *model.Player) error {
 	return nil
 }
 
+// savePlayer saves all of a player's data.
+func (s *SQLite3Driver) savePlayer(tx *sql.Tx, p *model.Player) error {
+	savers := []func(tx *sql.Tx, p *model.Player) error{
+		s.savePlayerInfo,
+		s.savePlayerEquipment,
+		s.savePlayerAppearance,
+		s.savePlayerLists,
+		s.savePlayerSkills,
+		s.savePlayerInventory,
+		s.savePlayerGameOptions,
+		s.savePlayerQuestStatuses,
+		s.savePlayerQuestFlags,
+		s.savePlayerMusicTracks,
+	}
+
+	for _, saver := range savers {
+		err := saver(tx, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // savePlayerInfo updates a player's basic information.
-func (s *SQLite3Driver) savePlayerInfo(p *model.Player) error {
-	stmt, err := s.db.Prepare(`
+func (s *SQLite3Driver) savePlayerInfo(tx *sql.Tx, p *model.Player) error {
+	stmt, err := tx.Prepare(`
 		UPDATE
 			PLAYER
 		SET
@@ -796,6 +1747,7 @@ func (s *SQLite3Driver) savePlayerInfo(p *model.Player) error {
 			UPDATE_DESIGN = ?,
 			FLAGGED = ?,
 			MUTED = ?,
+			MUTE_END_DTTM = ?,
 			MOVEMENT_SPEED = ?,
 			RUN_ENERGY = ?,
 			PUBLIC_CHAT_MODE =  ?,
@@ -812,6 +1764,12 @@ func (s *SQLite3Driver) savePlayerInfo(p *model.Player) error {
 
 	defer stmt.Close()
 
+	var muteEndDate sql.NullString
+	if p.MuteExpiry != nil {
+		muteEndDate.String = p.MuteExpiry.UTC().Format(dateFormat)
+		muteEndDate.Valid = true
+	}
+
 	rs, err := stmt.Exec(
 		p.GlobalPos.X,
 		p.GlobalPos.Y,
@@ -820,6 +1778,7 @@ func (s *SQLite3Driver) savePlayerInfo(p *model.Player) error {
 		p.UpdateDesign,
 		p.Flagged,
 		p.Muted,
+		muteEndDate,
 		p.MovementSpeed,
 		p.RunEnergy,
 		p.Modes.PublicChat,
@@ -844,9 +1803,9 @@ func (s *SQLite3Driver) savePlayerInfo(p *model.Player) error {
 }
 
 // savePlayerEquipment saves a player's equipment.
-func (s *SQLite3Driver) savePlayerEquipment(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerEquipment(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's equipment
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_EQUIPMENT
 		WHERE
@@ -896,7 +1855,7 @@ func (s *SQLite3Driver) savePlayerEquipment(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -912,8 +1871,8 @@ func (s *SQLite3Driver) savePlayerEquipment(p *model.Player) error {
 }
 
 // savePlayerAppearance saves a player's appearance information.
-func (s *SQLite3Driver) savePlayerAppearance(p *model.Player) error {
-	stmt, err := s.db.Prepare(`
+func (s *SQLite3Driver) savePlayerAppearance(tx *sql.Tx, p *model.Player) error {
+	stmt, err := tx.Prepare(`
 		UPDATE
 			PLAYER_APPEARANCE
 		SET
@@ -958,9 +1917,9 @@ func (s *SQLite3Driver) savePlayerAppearance(p *model.Player) error {
 }
 
 // savePlayerLists saves a player's friends and ignored lists.
-func (s *SQLite3Driver) savePlayerLists(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerLists(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's lists
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_LIST
 		WHERE
@@ -1017,7 +1976,7 @@ func (s *SQLite3Driver) savePlayerLists(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -1032,9 +1991,9 @@ func (s *SQLite3Driver) savePlayerLists(p *model.Player) error {
 	return nil
 }
 
-func (s *SQLite3Driver) savePlayerSkills(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerSkills(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's skills
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_SKILL
 		WHERE
@@ -1081,7 +2040,7 @@ func (s *SQLite3Driver) savePlayerSkills(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -1097,9 +2056,9 @@ func (s *SQLite3Driver) savePlayerSkills(p *model.Player) error {
 }
 
 // savePlayerInventory saves a player's inventory.
-func (s *SQLite3Driver) savePlayerInventory(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerInventory(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's inventory
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_INVENTORY
 		WHERE
@@ -1153,7 +2112,7 @@ func (s *SQLite3Driver) savePlayerInventory(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -1169,9 +2128,9 @@ func (s *SQLite3Driver) savePlayerInventory(p *model.Player) error {
 }
 
 // savePlayerGameOptions saves a player's game option preferences.
-func (s *SQLite3Driver) savePlayerGameOptions(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerGameOptions(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's game options
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_GAME_OPTION
 		WHERE
@@ -1219,7 +2178,7 @@ func (s *SQLite3Driver) savePlayerGameOptions(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -1235,9 +2194,9 @@ func (s *SQLite3Driver) savePlayerGameOptions(p *model.Player) error {
 }
 
 // savePlayerQuestStatuses saves a player's quest statuses.
-func (s *SQLite3Driver) savePlayerQuestStatuses(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerQuestStatuses(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's quest statuses
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_QUEST
 		WHERE
@@ -1285,7 +2244,7 @@ func (s *SQLite3Driver) savePlayerQuestStatuses(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -1301,9 +2260,9 @@ func (s *SQLite3Driver) savePlayerQuestStatuses(p *model.Player) error {
 }
 
 // savePlayerQuestFlags saves a player's quest flags.
-func (s *SQLite3Driver) savePlayerQuestFlags(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerQuestFlags(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's quest flags
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_QUEST_FLAG
 		WHERE
@@ -1355,7 +2314,7 @@ func (s *SQLite3Driver) savePlayerQuestFlags(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -1371,9 +2330,9 @@ func (s *SQLite3Driver) savePlayerQuestFlags(p *model.Player) error {
 }
 
 // savePlayerMusicTracks saves a player's music track unlock statuses.
-func (s *SQLite3Driver) savePlayerMusicTracks(p *model.Player) error {
+func (s *SQLite3Driver) savePlayerMusicTracks(tx *sql.Tx, p *model.Player) error {
 	// prepare a delete to clear out the player's music tracks
-	delStmt, err := s.db.Prepare(`
+	delStmt, err := tx.Prepare(`
 		DELETE FROM
 		    PLAYER_MUSIC_TRACK
 		WHERE
@@ -1421,7 +2380,7 @@ func (s *SQLite3Driver) savePlayerMusicTracks(p *model.Player) error {
 
 	// prepare the final insert query
 	insert := fmt.Sprintf(insertTemplate, strings.Join(bulk, ","))
-	stmt, err := s.db.Prepare(insert)
+	stmt, err := tx.Prepare(insert)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,135 @@
+package driver
+
+import (
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/mbpolan/openmcs/internal/config"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path"
+	"testing"
+	"time"
+)
+
+func Test_SQLite3Driver_CreatePlayer_normalizedUsername(t *testing.T) {
+	d := newTestSQLite3Driver(t)
+
+	p := model.NewPlayer("Bob Smith")
+	require.NoError(t, d.CreatePlayer(p))
+
+	// usernames that differ only in case, or in spaces and underscores, belong to the same player
+	for _, username := range []string{"bob smith", "BOB_SMITH", "bob_Smith"} {
+		loaded, err := d.LoadPlayer(username)
+		require.NoError(t, err)
+		require.NotNil(t, loaded, username)
+		assert.Equal(t, p.ID, loaded.ID)
+		assert.Equal(t, "Bob Smith", loaded.Username)
+
+		assert.Error(t, d.CreatePlayer(model.NewPlayer(username)), username)
+	}
+
+	require.NoError(t, d.CreatePlayer(model.NewPlayer("bob smith2")))
+}
+
+func Test_SQLite3Driver_ReplacePlayer(t *testing.T) {
+	d := newTestSQLite3Driver(t)
+
+	p := model.NewPlayer("Mike")
+	p.PasswordHash = "old"
+	require.NoError(t, d.CreatePlayer(p))
+
+	friend := model.NewPlayer("Hurz")
+	friend.Friends = []string{"Mike"}
+	require.NoError(t, d.CreatePlayer(friend))
+
+	require.NoError(t, d.SavePlayerSnapshot(&model.PlayerSnapshot{
+		PlayerID:      p.ID,
+		SchemaVersion: 1,
+		Data:          []byte{1},
+		CreatedAt:     time.Now(),
+	}, 5))
+
+	replacement := model.NewPlayer("MIKE")
+	replacement.ID = p.ID
+	replacement.PasswordHash = "new"
+	replacement.Type = model.PlayerModerator
+	replacement.SetInventoryItem(&model.Item{ID: 995}, 10, 0)
+	require.NoError(t, d.ReplacePlayer(replacement))
+
+	loaded, err := d.LoadPlayer("mike")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, p.ID, loaded.ID)
+	assert.Equal(t, "MIKE", loaded.Username)
+	assert.Equal(t, "new", loaded.PasswordHash)
+	assert.Equal(t, model.PlayerModerator, loaded.Type)
+	assert.Equal(t, 10, loaded.Inventory[0].Amount)
+
+	// the player's snapshots and their place on the friends lists of others are kept
+	snapshots, err := d.LoadPlayerSnapshots(p.ID)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+
+	loaded, err = d.LoadPlayer("Hurz")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MIKE"}, loaded.Friends)
+
+	// a failed replacement leaves the player as they were
+	replacement.Username = "Hurz"
+	replacement.PasswordHash = "failed"
+	assert.Error(t, d.ReplacePlayer(replacement))
+
+	loaded, err = d.LoadPlayer("mike")
+	require.NoError(t, err)
+	assert.Equal(t, "new", loaded.PasswordHash)
+}
+
+func Test_SQLite3Driver_pendingRollback(t *testing.T) {
+	d := newTestSQLite3Driver(t)
+
+	p := model.NewPlayer("Mike")
+	require.NoError(t, d.CreatePlayer(p))
+
+	pending, err := d.LoadPendingRollback(p.ID)
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+
+	require.NoError(t, d.SavePendingRollback(p.ID, 3))
+	pending, err = d.LoadPendingRollback(p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pending)
+
+	// saving the player's data leaves the pending rollback as it is
+	require.NoError(t, d.SavePlayer(p))
+	pending, err = d.LoadPendingRollback(p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pending)
+
+	require.NoError(t, d.SavePendingRollback(p.ID, 0))
+	pending, err = d.LoadPendingRollback(p.ID)
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+
+	assert.Error(t, d.SavePendingRollback(p.ID+1, 3))
+	_, err = d.LoadPendingRollback(p.ID + 1)
+	assert.Error(t, err)
+}
+
+// newTestSQLite3Driver creates a SQLite3 database in a temporary directory and applies all migrations.
+func newTestSQLite3Driver(t *testing.T) Driver {
+	d, err := NewSQLite3Driver(&config.SQLite3DatabaseConfig{URI: path.Join(t.TempDir(), "test.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = d.Close()
+	})
+
+	handle, err := d.Migration()
+	require.NoError(t, err)
+
+	m, err := migrate.NewWithDatabaseInstance("file://../../../migrations/sqlite3", "sqlite3", handle)
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+
+	return d
+}
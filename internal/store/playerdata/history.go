@@ -0,0 +1,196 @@
+package playerdata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store/driver"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// History keeps snapshots of a player's data each time they are saved, so that they can be rolled back if their data
+// is lost or corrupted.
+type History struct {
+	driver driver.Driver
+	now    func() time.Time
+	retain int
+}
+
+// NewHistory creates a history that keeps up to retain snapshots of each player in a store. If retain is zero, no new
+// snapshots are taken, but players can still be rolled back to existing ones.
+func NewHistory(d driver.Driver, retain int) *History {
+	return &History{
+		driver: d,
+		now:    time.Now,
+		retain: retain,
+	}
+}
+
+// Record takes a snapshot of a player's data, removing their oldest snapshots beyond the retention limit.
+func (h *History) Record(p *model.Player) error {
+	if h.retain <= 0 {
+		return nil
+	}
+
+	doc := New(p)
+
+	// password hashes are never restored, so there is no reason to keep old ones around
+	doc.Player.PasswordHash = ""
+
+	data, err := Encode(doc)
+	if err != nil {
+		return err
+	}
+
+	return h.driver.SavePlayerSnapshot(&model.PlayerSnapshot{
+		PlayerID:      p.ID,
+		SchemaVersion: doc.SchemaVersion,
+		Data:          data,
+		CreatedAt:     h.now(),
+	}, h.retain)
+}
+
+// List returns the snapshots of a player without their data, newest first.
+func (h *History) List(username string) ([]*model.PlayerSnapshot, error) {
+	p, err := h.load(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.driver.LoadPlayerSnapshots(p.ID)
+}
+
+// Schedule rolls a player back to one of their snapshots the next time they log in, returning the snapshot. Rolling a
+// player back when they log in, before their data is used, means the rollback is never overwritten by their data from
+// an earlier session being saved. Scheduling another rollback replaces the pending one.
+func (h *History) Schedule(username string, snapshotID int) (*model.PlayerSnapshot, error) {
+	p, err := h.load(username)
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure the snapshot can be restored now, rather than finding out when the player logs in
+	snapshot, _, err := h.decode(p, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.driver.SavePendingRollback(p.ID, snapshot.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save pending rollback")
+	}
+
+	return snapshot, nil
+}
+
+// ApplyPending replaces a player's data with the data in the snapshot they are scheduled to be rolled back to,
+// returning the snapshot that was restored. If no rollback is pending, nil is returned. The player's account details,
+// mute and flagged status are left as they are. A snapshot of the player's current data is taken beforehand, so a
+// rollback can itself be undone. The pending rollback is cleared even if it fails, so that a snapshot which can no
+// longer be restored does not keep the player from logging in.
+func (h *History) ApplyPending(p *model.Player) (*model.PlayerSnapshot, error) {
+	snapshotID, err := h.driver.LoadPendingRollback(p.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load pending rollback")
+	} else if snapshotID == 0 {
+		return nil, nil
+	}
+
+	err = h.driver.SavePendingRollback(p.ID, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to clear pending rollback")
+	}
+
+	snapshot, restored, err := h.decode(p, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored.ID = p.ID
+	restored.Username = p.Username
+	restored.PasswordHash = p.PasswordHash
+	restored.Type = p.Type
+	restored.Member = p.Member
+	restored.MemberExpiry = p.MemberExpiry
+	restored.Muted = p.Muted
+	restored.MuteExpiry = p.MuteExpiry
+	restored.Flagged = p.Flagged
+
+	err = h.Record(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to take snapshot of current data")
+	}
+
+	err = h.driver.SavePlayer(restored)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save player")
+	}
+
+	return snapshot, nil
+}
+
+// decode loads one of a player's snapshots and decodes the player's data within it.
+func (h *History) decode(p *model.Player, snapshotID int) (*model.PlayerSnapshot, *model.Player, error) {
+	snapshot, err := h.driver.LoadPlayerSnapshot(p.ID, snapshotID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load snapshot")
+	} else if snapshot == nil {
+		return nil, nil, fmt.Errorf("player %s has no snapshot #%d", p.Username, snapshotID)
+	}
+
+	doc, err := Decode(snapshot.Data)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to decode snapshot #%d", snapshotID)
+	}
+
+	restored, err := doc.ToModel()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid snapshot #%d", snapshotID)
+	}
+
+	return snapshot, restored, nil
+}
+
+// load loads an existing player, returning an error if they do not exist.
+func (h *History) load(username string) (*model.Player, error) {
+	p, err := h.driver.LoadPlayer(username)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load player")
+	} else if p == nil {
+		return nil, fmt.Errorf("player %s does not exist", username)
+	}
+
+	return p, nil
+}
+
+// Encode encodes a document as compressed JSON, for storing many documents compactly.
+func Encode(doc *Document) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+
+	err := json.NewEncoder(w).Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a document written by Encode, and validates that its schema version is supported.
+func Decode(data []byte) (*Document, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress document: %s", err)
+	}
+
+	defer r.Close()
+	return Read(r)
+}
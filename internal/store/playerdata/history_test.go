@@ -0,0 +1,180 @@
+package playerdata
+
+import (
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_History_Record(t *testing.T) {
+	d := newTestDriver(t, "Mike")
+	h := NewHistory(d, 2)
+
+	p, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+
+	// only the newest snapshots are kept
+	for i := 0; i < 3; i++ {
+		p.RunEnergy = i
+		require.NoError(t, h.Record(p))
+	}
+
+	snapshots, err := h.List("mike")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, 3, snapshots[0].ID)
+	assert.Equal(t, 2, snapshots[1].ID)
+	assert.Equal(t, SchemaVersion, snapshots[0].SchemaVersion)
+	assert.Nil(t, snapshots[0].Data)
+
+	snapshot, err := d.LoadPlayerSnapshot(p.ID, 3)
+	require.NoError(t, err)
+	doc, err := Decode(snapshot.Data)
+	require.NoError(t, err)
+	assert.Equal(t, 2, doc.Player.RunEnergy)
+
+	_, err = h.List("Hurz")
+	assert.Error(t, err)
+}
+
+func Test_History_Record_disabled(t *testing.T) {
+	d := newTestDriver(t, "Mike")
+	h := NewHistory(d, 0)
+
+	p, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	require.NoError(t, h.Record(p))
+
+	snapshots, err := h.List("Mike")
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func Test_History_Schedule(t *testing.T) {
+	d := newTestDriver(t, "Mike")
+	h := NewHistory(d, 10)
+
+	p, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	require.NoError(t, h.Record(p))
+
+	snapshot, err := h.Schedule("mike", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, snapshot.ID)
+
+	pending, err := d.LoadPendingRollback(p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	// unknown snapshots and players can't be scheduled, and leave the pending rollback as it is
+	_, err = h.Schedule("Mike", 99)
+	assert.Error(t, err)
+	_, err = h.Schedule("Hurz", 1)
+	assert.Error(t, err)
+
+	pending, err = d.LoadPendingRollback(p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+}
+
+func Test_History_ApplyPending(t *testing.T) {
+	d := newTestDriver(t, "Mike")
+	h := NewHistory(d, 10)
+
+	p, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	p.SetInventoryItem(&model.Item{ID: 995}, 1000, 0)
+	p.SetSkillExperience(model.SkillTypeAttack, model.SkillExperienceLevels[20])
+	require.NoError(t, d.SavePlayer(p))
+	require.NoError(t, h.Record(p))
+
+	// nothing happens until a rollback is scheduled
+	snapshot, err := h.ApplyPending(p)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	// the player loses their items, and is muted afterward
+	p.ClearInventoryItem(0)
+	p.SetSkillExperience(model.SkillTypeAttack, model.SkillExperienceLevels[30])
+	require.NoError(t, d.SavePlayer(p))
+	found, err := d.SaveMute("Mike", true, nil)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	_, err = h.Schedule("mike", 1)
+	require.NoError(t, err)
+
+	p, err = d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	snapshot, err = h.ApplyPending(p)
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, 1, snapshot.ID)
+
+	loaded, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	require.NotNil(t, loaded.Inventory[0])
+	assert.Equal(t, 1000, loaded.Inventory[0].Amount)
+	assert.Equal(t, 20, loaded.Skills[model.SkillTypeAttack].BaseLevel)
+	assert.True(t, loaded.Muted)
+
+	// the rollback is only applied once
+	snapshot, err = h.ApplyPending(loaded)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	// the data from before the rollback is kept in a new snapshot
+	snapshots, err := h.List("Mike")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+
+	_, err = h.Schedule("Mike", snapshots[0].ID)
+	require.NoError(t, err)
+	_, err = h.ApplyPending(loaded)
+	require.NoError(t, err)
+
+	loaded, err = d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	assert.Nil(t, loaded.Inventory[0])
+	assert.Equal(t, 30, loaded.Skills[model.SkillTypeAttack].BaseLevel)
+}
+
+func Test_History_ApplyPending_missingSnapshot(t *testing.T) {
+	d := newTestDriver(t, "Mike")
+	h := NewHistory(d, 1)
+
+	p, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	require.NoError(t, h.Record(p))
+
+	_, err = h.Schedule("Mike", 1)
+	require.NoError(t, err)
+
+	// the snapshot is removed once a newer one is taken
+	require.NoError(t, h.Record(p))
+
+	_, err = h.ApplyPending(p)
+	assert.Error(t, err)
+
+	// the rollback is not attempted again
+	snapshot, err := h.ApplyPending(p)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+}
+
+func Test_Encode(t *testing.T) {
+	doc := New(model.NewPlayer("Mike"))
+	doc.ExportedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	data, err := Encode(doc)
+	require.NoError(t, err)
+
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, doc, decoded)
+
+	_, err = Decode([]byte("{}"))
+	assert.Error(t, err)
+}
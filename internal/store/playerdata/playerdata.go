@@ -0,0 +1,336 @@
+package playerdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"io"
+	"sort"
+	"time"
+)
+
+// SchemaVersion is the version of the document format written by this package. Documents with a newer version cannot
+// be read, since they may contain data that would be silently dropped.
+const SchemaVersion = 1
+
+// Document is a portable copy of a player account and all of their persistent data.
+type Document struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	ExportedAt    time.Time `json:"exportedAt"`
+	Player        *Player   `json:"player"`
+}
+
+// Player contains a player account and their persistent data. Store-specific identifiers are not included, so a player
+// can be moved between stores.
+type Player struct {
+	Username      string              `json:"username"`
+	PasswordHash  string              `json:"passwordHash,omitempty"`
+	Type          int                 `json:"type"`
+	Position      Position            `json:"position"`
+	Appearance    Appearance          `json:"appearance"`
+	UpdateDesign  bool                `json:"updateDesign"`
+	Flagged       bool                `json:"flagged"`
+	Muted         bool                `json:"muted"`
+	MuteExpiry    *time.Time          `json:"muteExpiry,omitempty"`
+	Member        bool                `json:"member"`
+	MemberExpiry  *time.Time          `json:"memberExpiry,omitempty"`
+	Running       bool                `json:"running"`
+	RunEnergy     int                 `json:"runEnergy"`
+	AutoRetaliate bool                `json:"autoRetaliate"`
+	Modes         Modes               `json:"modes"`
+	Skills        []Skill             `json:"skills"`
+	Inventory     []Slot              `json:"inventory"`
+	Equipment     []Slot              `json:"equipment"`
+	Friends       []string            `json:"friends"`
+	Ignored       []string            `json:"ignored"`
+	GameOptions   map[int]string      `json:"gameOptions"`
+	QuestStatuses map[int]int         `json:"questStatuses"`
+	QuestFlags    map[int]map[int]int `json:"questFlags"`
+	MusicTracks   map[int]bool        `json:"musicTracks"`
+}
+
+// Position is a position in global coordinates.
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// Appearance contains the gender and body parts of a player's model.
+type Appearance struct {
+	Gender int `json:"gender"`
+	Head   int `json:"head"`
+	Face   int `json:"face"`
+	Body   int `json:"body"`
+	Arms   int `json:"arms"`
+	Hands  int `json:"hands"`
+	Legs   int `json:"legs"`
+	Feet   int `json:"feet"`
+}
+
+// Modes contains a player's chat and interaction settings.
+type Modes struct {
+	PublicChat  int `json:"publicChat"`
+	PrivateChat int `json:"privateChat"`
+	Interaction int `json:"interaction"`
+}
+
+// Skill contains a player's progress in a skill. The base level is derived from the experience.
+type Skill struct {
+	Skill      int     `json:"skill"`
+	StatLevel  int     `json:"statLevel"`
+	Experience float64 `json:"experience"`
+}
+
+// Slot contains an item in an inventory or equipment slot. Equipment slots use the model.EquipmentSlotType values.
+type Slot struct {
+	Slot   int `json:"slot"`
+	ItemID int `json:"itemId"`
+	Amount int `json:"amount"`
+}
+
+// New creates a document containing a copy of a player's persistent data.
+func New(p *model.Player) *Document {
+	data := &Player{
+		Username:     p.Username,
+		PasswordHash: p.PasswordHash,
+		Type:         int(p.Type),
+		Position:     Position{X: p.GlobalPos.X, Y: p.GlobalPos.Y, Z: p.GlobalPos.Z},
+		Appearance: Appearance{
+			Gender: int(p.Appearance.Gender),
+			Head:   p.Appearance.Base.Head,
+			Face:   p.Appearance.Base.Face,
+			Body:   p.Appearance.Base.Body,
+			Arms:   p.Appearance.Base.Arms,
+			Hands:  p.Appearance.Base.Hands,
+			Legs:   p.Appearance.Base.Legs,
+			Feet:   p.Appearance.Base.Feet,
+		},
+		UpdateDesign:  p.UpdateDesign,
+		Flagged:       p.Flagged,
+		Muted:         p.Muted,
+		MuteExpiry:    p.MuteExpiry,
+		Member:        p.Member,
+		MemberExpiry:  p.MemberExpiry,
+		Running:       p.MovementSpeed == model.MovementSpeedRun,
+		RunEnergy:     p.RunEnergy,
+		AutoRetaliate: p.AutoRetaliate,
+		Modes: Modes{
+			PublicChat:  int(p.Modes.PublicChat),
+			PrivateChat: int(p.Modes.PrivateChat),
+			Interaction: int(p.Modes.Interaction),
+		},
+		Friends:       append([]string{}, p.Friends...),
+		Ignored:       append([]string{}, p.Ignored...),
+		GameOptions:   map[int]string{},
+		QuestStatuses: map[int]int{},
+		QuestFlags:    map[int]map[int]int{},
+		MusicTracks:   map[int]bool{},
+	}
+
+	// skills are kept in a map, so sort them to produce the same document for the same player
+	for skillType, skill := range p.Skills {
+		data.Skills = append(data.Skills, Skill{
+			Skill:      int(skillType),
+			StatLevel:  skill.StatLevel,
+			Experience: skill.Experience,
+		})
+	}
+
+	sort.Slice(data.Skills, func(i, j int) bool {
+		return data.Skills[i].Skill < data.Skills[j].Skill
+	})
+
+	for i, slot := range p.Inventory {
+		if slot == nil || slot.Item == nil {
+			continue
+		}
+
+		data.Inventory = append(data.Inventory, Slot{Slot: i, ItemID: slot.Item.ID, Amount: slot.Amount})
+	}
+
+	for _, slotType := range model.EquipmentSlotTypes {
+		slot := p.EquipmentSlot(slotType)
+		if slot == nil || slot.Item == nil {
+			continue
+		}
+
+		data.Equipment = append(data.Equipment, Slot{Slot: int(slotType), ItemID: slot.Item.ID, Amount: slot.Amount})
+	}
+
+	for k, v := range p.GameOptions {
+		data.GameOptions[k] = v
+	}
+
+	for k, v := range p.QuestStatuses {
+		data.QuestStatuses[k] = int(v)
+	}
+
+	for questID, flags := range p.QuestFlags {
+		data.QuestFlags[questID] = map[int]int{}
+		for flagID, value := range flags {
+			data.QuestFlags[questID][flagID] = value
+		}
+	}
+
+	for k, v := range p.MusicTracks {
+		data.MusicTracks[k] = v
+	}
+
+	return &Document{
+		SchemaVersion: SchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Player:        data,
+	}
+}
+
+// Read decodes a document from JSON, and validates that its schema version is supported.
+func Read(r io.Reader) (*Document, error) {
+	var doc Document
+	err := json.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %s", err)
+	}
+
+	err = doc.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// Write encodes a document as indented JSON.
+func Write(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Validate returns an error if the document was written with an unsupported schema version, or if it does not
+// contain a player.
+func (d *Document) Validate() error {
+	if d.SchemaVersion < 1 {
+		return fmt.Errorf("document has no schema version")
+	} else if d.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("document has schema version %d, but only versions up to %d are supported",
+			d.SchemaVersion, SchemaVersion)
+	}
+
+	if d.Player == nil {
+		return fmt.Errorf("document does not contain a player")
+	} else if d.Player.Username == "" {
+		return fmt.Errorf("document player has no username")
+	}
+
+	return nil
+}
+
+// ToModel converts the document into a player with no ID, validating the player's data in the process.
+func (d *Document) ToModel() (*model.Player, error) {
+	err := d.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	data := d.Player
+	p := model.NewPlayer(data.Username)
+	p.PasswordHash = data.PasswordHash
+	p.Type = model.PlayerType(data.Type)
+	p.GlobalPos = model.Vector3D{X: data.Position.X, Y: data.Position.Y, Z: data.Position.Z}
+	p.Appearance.Gender = model.EntityGender(data.Appearance.Gender)
+	p.Appearance.Base = model.EntityBase{
+		Head:  data.Appearance.Head,
+		Face:  data.Appearance.Face,
+		Body:  data.Appearance.Body,
+		Arms:  data.Appearance.Arms,
+		Hands: data.Appearance.Hands,
+		Legs:  data.Appearance.Legs,
+		Feet:  data.Appearance.Feet,
+	}
+	p.UpdateDesign = data.UpdateDesign
+	p.Flagged = data.Flagged
+	p.Muted = data.Muted
+	p.MuteExpiry = data.MuteExpiry
+	p.Member = data.Member
+	p.MemberExpiry = data.MemberExpiry
+	p.RunEnergy = data.RunEnergy
+	p.AutoRetaliate = data.AutoRetaliate
+	p.Modes = model.PlayerModes{
+		PublicChat:  model.ChatMode(data.Modes.PublicChat),
+		PrivateChat: model.ChatMode(data.Modes.PrivateChat),
+		Interaction: model.InteractionMode(data.Modes.Interaction),
+	}
+	p.Friends = append([]string{}, data.Friends...)
+	p.Ignored = append([]string{}, data.Ignored...)
+
+	if data.Running {
+		p.MovementSpeed = model.MovementSpeedRun
+	}
+
+	for _, skill := range data.Skills {
+		skillType := model.SkillType(skill.Skill)
+		if _, ok := p.Skills[skillType]; !ok {
+			return nil, fmt.Errorf("unknown skill: %d", skill.Skill)
+		} else if skill.Experience < 0 {
+			return nil, fmt.Errorf("skill %d experience out of bounds: %f", skill.Skill, skill.Experience)
+		} else if skill.StatLevel < 0 {
+			return nil, fmt.Errorf("skill %d stat level out of bounds: %d", skill.Skill, skill.StatLevel)
+		}
+
+		p.SetSkillExperience(skillType, skill.Experience)
+		p.Skills[skillType].StatLevel = skill.StatLevel
+	}
+
+	for _, slot := range data.Inventory {
+		if slot.Slot < 0 || slot.Slot >= model.MaxInventorySlots {
+			return nil, fmt.Errorf("inventory slot out of bounds: %d", slot.Slot)
+		} else if slot.Amount < 1 {
+			return nil, fmt.Errorf("inventory slot %d has invalid amount: %d", slot.Slot, slot.Amount)
+		}
+
+		p.SetInventoryItem(&model.Item{ID: slot.ItemID}, slot.Amount, slot.Slot)
+	}
+
+	for _, slot := range data.Equipment {
+		slotType := model.EquipmentSlotType(slot.Slot)
+		if !validEquipmentSlot(slotType) {
+			return nil, fmt.Errorf("equipment slot out of bounds: %d", slot.Slot)
+		} else if slot.Amount < 1 {
+			return nil, fmt.Errorf("equipment slot %d has invalid amount: %d", slot.Slot, slot.Amount)
+		}
+
+		p.SetEquippedItem(&model.Item{ID: slot.ItemID}, slot.Amount, slotType)
+	}
+
+	for k, v := range data.GameOptions {
+		p.SetGameOption(k, v)
+	}
+
+	for k, v := range data.QuestStatuses {
+		p.SetQuestStatus(k, model.QuestStatus(v))
+	}
+
+	for questID, flags := range data.QuestFlags {
+		for flagID, value := range flags {
+			p.SetQuestFlag(questID, flagID, value)
+		}
+	}
+
+	for k, v := range data.MusicTracks {
+		p.SetMusicTrackUnlocked(k, v)
+	}
+
+	return p, nil
+}
+
+// validEquipmentSlot returns true if the equipment slot type is one that players can equip items in.
+func validEquipmentSlot(slotType model.EquipmentSlotType) bool {
+	for _, t := range model.EquipmentSlotTypes {
+		if t == slotType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,232 @@
+package playerdata
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_exportImport_roundTrip(t *testing.T) {
+	src := newTestDriver(t, "Hurz")
+	dst := newTestDriver(t, "Hurz")
+
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := model.NewPlayer("Mike")
+	p.PasswordHash = "hash"
+	p.Type = model.PlayerModerator
+	p.GlobalPos = model.Vector3D{X: 3222, Y: 3218, Z: 1}
+	p.Appearance.Gender = model.EntityFemale
+	p.Appearance.Base.Head = 45
+	p.Appearance.Base.Feet = 79
+	p.MovementSpeed = model.MovementSpeedRun
+	p.RunEnergy = 42
+	p.Member = true
+	p.MemberExpiry = &expiry
+	p.Modes.PrivateChat = model.ChatModeFriends
+	p.SetSkillExperience(model.SkillTypeAttack, model.SkillExperienceLevels[40])
+	p.Skills[model.SkillTypeAttack].StatLevel = 45
+	p.SetInventoryItem(&model.Item{ID: 995}, 1000, 27)
+	p.SetEquippedItem(&model.Item{ID: 1333}, 1, model.EquipmentSlotTypeWeapon)
+	p.SetQuestStatus(1, model.QuestStatusInProgress)
+	p.SetQuestFlag(1, 2, 3)
+	p.SetMusicTrackUnlocked(7, true)
+	p.SetGameOption(3, "1")
+	p.Friends = []string{"Hurz"}
+	require.NoError(t, src.CreatePlayer(p))
+
+	doc, err := Export(src, "mike", ExportOptions{IncludePasswordHash: true})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, doc))
+	doc, err = Read(buf)
+	require.NoError(t, err)
+
+	imported, err := Import(dst, doc, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Mike", imported.Username)
+
+	loaded, err := dst.LoadPlayer("Mike")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "hash", loaded.PasswordHash)
+	assert.Equal(t, model.PlayerModerator, loaded.Type)
+	assert.Equal(t, model.Vector3D{X: 3222, Y: 3218, Z: 1}, loaded.GlobalPos)
+	assert.Equal(t, model.EntityFemale, loaded.Appearance.Gender)
+	assert.Equal(t, 45, loaded.Appearance.Base.Head)
+	assert.Equal(t, 79, loaded.Appearance.Base.Feet)
+	assert.Equal(t, model.MovementSpeedRun, loaded.MovementSpeed)
+	assert.Equal(t, 42, loaded.RunEnergy)
+	assert.Equal(t, model.ChatModeFriends, loaded.Modes.PrivateChat)
+	assert.Equal(t, 40, loaded.Skills[model.SkillTypeAttack].BaseLevel)
+	assert.Equal(t, 45, loaded.Skills[model.SkillTypeAttack].StatLevel)
+	assert.Equal(t, 1000, loaded.Inventory[27].Amount)
+	assert.Equal(t, 1333, loaded.EquipmentSlot(model.EquipmentSlotTypeWeapon).Item.ID)
+	assert.Equal(t, model.QuestStatusInProgress, loaded.QuestStatuses[1])
+	assert.Equal(t, 3, loaded.QuestFlags[1][2])
+	assert.True(t, loaded.MusicTracks[7])
+	assert.Equal(t, "1", loaded.GameOptions[3])
+	assert.Equal(t, []string{"Hurz"}, loaded.Friends)
+}
+
+func Test_Read_schemaVersion(t *testing.T) {
+	_, err := Read(strings.NewReader(`{"player": {"username": "Mike"}}`))
+	assert.Error(t, err)
+
+	_, err = Read(strings.NewReader(fmt.Sprintf(`{"schemaVersion": %d, "player": {"username": "Mike"}}`,
+		SchemaVersion+1)))
+	assert.Error(t, err)
+
+	_, err = Read(strings.NewReader(fmt.Sprintf(`{"schemaVersion": %d}`, SchemaVersion)))
+	assert.Error(t, err)
+
+	doc, err := Read(strings.NewReader(fmt.Sprintf(`{"schemaVersion": %d, "player": {"username": "Mike"}}`,
+		SchemaVersion)))
+	require.NoError(t, err)
+	assert.Equal(t, "Mike", doc.Player.Username)
+}
+
+func Test_Document_ToModel_invalid(t *testing.T) {
+	tests := map[string]Player{
+		"unknown skill":    {Username: "Mike", Skills: []Skill{{Skill: 99}}},
+		"inventory slot":   {Username: "Mike", Inventory: []Slot{{Slot: model.MaxInventorySlots, ItemID: 995, Amount: 1}}},
+		"inventory amount": {Username: "Mike", Inventory: []Slot{{Slot: 0, ItemID: 995}}},
+		"equipment slot":   {Username: "Mike", Equipment: []Slot{{Slot: 6, ItemID: 1333, Amount: 1}}},
+	}
+
+	for name, player := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc := &Document{SchemaVersion: SchemaVersion, Player: &player}
+			_, err := doc.ToModel()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_Import_conflicts(t *testing.T) {
+	d := newTestDriver(t, "Mike", "Mike2", "LongUsernam2")
+
+	doc := newTestDocument("Mike")
+	doc.Player.RunEnergy = 7
+
+	_, err := Import(d, doc, ImportOptions{OnConflict: ConflictFail})
+	assert.Error(t, err)
+
+	p, err := Import(d, doc, ImportOptions{OnConflict: ConflictRename})
+	require.NoError(t, err)
+	assert.Equal(t, "Mike3", p.Username)
+
+	// renamed usernames are shortened to fit the longest username a client can send
+	doc.Player.Username = "LongUsername"
+	require.NoError(t, d.CreatePlayer(model.NewPlayer("LongUsername")))
+	p, err = Import(d, doc, ImportOptions{OnConflict: ConflictRename})
+	require.NoError(t, err)
+	assert.Equal(t, "LongUsernam3", p.Username)
+
+	// overwritten players keep their ID and snapshots
+	existing, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	require.NoError(t, d.SavePlayerSnapshot(&model.PlayerSnapshot{PlayerID: existing.ID, CreatedAt: time.Now()}, 5))
+
+	doc.Player.Username = "Mike"
+	p, err = Import(d, doc, ImportOptions{OnConflict: ConflictOverwrite})
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, p.ID)
+
+	loaded, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	assert.Equal(t, p.ID, loaded.ID)
+	assert.Equal(t, 7, loaded.RunEnergy)
+
+	snapshots, err := d.LoadPlayerSnapshots(p.ID)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+}
+
+func Test_Import_username(t *testing.T) {
+	d := newTestDriver(t)
+
+	doc := newTestDocument("Mike")
+	reject := func(username string) error {
+		if username == "Admin" {
+			return fmt.Errorf("reserved")
+		}
+
+		return nil
+	}
+
+	_, err := Import(d, doc, ImportOptions{Username: "Admin", ValidateUsername: reject})
+	assert.Error(t, err)
+
+	p, err := Import(d, doc, ImportOptions{Username: "Zezima", ValidateUsername: reject})
+	require.NoError(t, err)
+	assert.Equal(t, "Zezima", p.Username)
+
+	loaded, err := d.LoadPlayer("Mike")
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+}
+
+func Test_Import_passwordHash(t *testing.T) {
+	src := newTestDriver(t)
+	dst := newTestDriver(t)
+
+	p := model.NewPlayer("Mike")
+	p.PasswordHash = "hash"
+	require.NoError(t, src.CreatePlayer(p))
+
+	// password hashes are left out of exports by default
+	doc, err := Export(src, "Mike", ExportOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, doc.Player.PasswordHash)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, doc))
+	assert.NotContains(t, buf.String(), "passwordHash")
+
+	// a player without a password hash cannot be imported unless their password is reset
+	_, err = Import(dst, doc, ImportOptions{})
+	assert.Error(t, err)
+
+	_, err = Import(dst, doc, ImportOptions{PasswordHash: "reset"})
+	require.NoError(t, err)
+
+	loaded, err := dst.LoadPlayer("Mike")
+	require.NoError(t, err)
+	assert.Equal(t, "reset", loaded.PasswordHash)
+}
+
+func Test_ParseConflictPolicy(t *testing.T) {
+	policy, err := ParseConflictPolicy("Rename")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictRename, policy)
+
+	_, err = ParseConflictPolicy("merge")
+	assert.Error(t, err)
+}
+
+// newTestDocument returns a document containing a new player with a username and a password hash.
+func newTestDocument(username string) *Document {
+	p := model.NewPlayer(username)
+	p.PasswordHash = "hash"
+
+	return New(p)
+}
+
+// newTestDriver returns an in-memory driver containing players with usernames.
+func newTestDriver(t *testing.T, usernames ...string) driver.Driver {
+	d, err := driver.NewMemoryDriverWithFixtures()
+	require.NoError(t, err)
+
+	for _, username := range usernames {
+		require.NoError(t, d.CreatePlayer(model.NewPlayer(username)))
+	}
+
+	return d
+}
@@ -0,0 +1,186 @@
+package playerdata
+
+import (
+	"fmt"
+	"github.com/mbpolan/openmcs/internal/model"
+	"github.com/mbpolan/openmcs/internal/store/driver"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// maxUsernameLength is the longest username a client can send when logging in.
+const maxUsernameLength = 12
+
+// maxRenameAttempts is the number of numbered usernames tried when renaming an imported player.
+const maxRenameAttempts = 99
+
+// ConflictPolicy determines what happens when an imported player has the same username as an existing player.
+type ConflictPolicy int
+
+const (
+	// ConflictFail stops the import, leaving the existing player untouched.
+	ConflictFail ConflictPolicy = iota
+	// ConflictOverwrite replaces the existing player's account and all of their data, keeping their ID so that their
+	// snapshots and their place on the friends and ignored lists of others are kept.
+	ConflictOverwrite
+	// ConflictRename imports the player under the first available username formed by appending a number.
+	ConflictRename
+)
+
+// conflictPolicyNames maps the names of conflict policies to their enums.
+var conflictPolicyNames = map[string]ConflictPolicy{
+	"fail":      ConflictFail,
+	"overwrite": ConflictOverwrite,
+	"rename":    ConflictRename,
+}
+
+// ParseConflictPolicy returns the conflict policy with a name: fail, overwrite or rename.
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	policy, ok := conflictPolicyNames[strings.ToLower(name)]
+	if !ok {
+		return ConflictFail, fmt.Errorf("unknown conflict policy: %s", name)
+	}
+
+	return policy, nil
+}
+
+// ExportOptions control what is included when a player is exported from a store.
+type ExportOptions struct {
+	// IncludePasswordHash keeps the player's password hash in the document. Documents are often shared more widely
+	// than the store itself, so the hash is left out unless this is set.
+	IncludePasswordHash bool
+}
+
+// ImportOptions control how a player is imported into a store.
+type ImportOptions struct {
+	// Username replaces the username stored in the document, if set.
+	Username string
+	// PasswordHash replaces the password hash stored in the document, if set. A document without a password hash can
+	// only be imported if one is given, so that the player's password is reset.
+	PasswordHash string
+	// OnConflict determines what happens when a player with the same username already exists.
+	OnConflict ConflictPolicy
+	// ValidateUsername rejects usernames that cannot be registered. It is only applied to usernames that differ from
+	// the one stored in the document, so existing accounts can be moved as they are. If nil, all usernames are
+	// accepted.
+	ValidateUsername func(username string) error
+}
+
+// Export loads a player from a store and creates a document containing their persistent data.
+func Export(d driver.Driver, username string, opts ExportOptions) (*Document, error) {
+	p, err := d.LoadPlayer(username)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load player")
+	} else if p == nil {
+		return nil, fmt.Errorf("player %s does not exist", username)
+	}
+
+	doc := New(p)
+	if !opts.IncludePasswordHash {
+		doc.Player.PasswordHash = ""
+	}
+
+	return doc, nil
+}
+
+// Import creates a player in a store from a document, returning the player as they were created. A new player is
+// assigned a new ID, while an overwritten player keeps the ID of the player they replace.
+func Import(d driver.Driver, doc *Document, opts ImportOptions) (*model.Player, error) {
+	p, err := doc.ToModel()
+	if err != nil {
+		return nil, err
+	}
+
+	// a player without a password hash would be unable to log in, so a new password must be set
+	if opts.PasswordHash != "" {
+		p.PasswordHash = opts.PasswordHash
+	} else if p.PasswordHash == "" {
+		return nil, fmt.Errorf("player %s has no password hash, so a new password is required", p.Username)
+	}
+
+	if opts.Username != "" && opts.Username != p.Username {
+		err = validateUsername(opts, opts.Username)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Username = opts.Username
+	}
+
+	existing, err := d.LoadPlayer(p.Username)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check for existing player")
+	}
+
+	// resolve username collisions before creating the player
+	if existing != nil {
+		switch opts.OnConflict {
+		case ConflictOverwrite:
+			p.ID = existing.ID
+			err = d.ReplacePlayer(p)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to replace existing player")
+			}
+
+			return p, nil
+
+		case ConflictRename:
+			p.Username, err = availableUsername(d, p.Username, opts)
+			if err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("player %s already exists", p.Username)
+		}
+	}
+
+	err = d.CreatePlayer(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create player")
+	}
+
+	return p, nil
+}
+
+// availableUsername returns the first username not used by another player, formed by appending a number to a base
+// username. The base is shortened if needed so the username can still be sent by a client.
+func availableUsername(d driver.Driver, base string, opts ImportOptions) (string, error) {
+	for n := 2; n <= maxRenameAttempts+1; n++ {
+		suffix := strconv.Itoa(n)
+
+		prefix := []rune(base)
+		if len(prefix)+len(suffix) > maxUsernameLength {
+			prefix = prefix[:maxUsernameLength-len(suffix)]
+		}
+
+		candidate := strings.TrimSpace(string(prefix)) + suffix
+		if validateUsername(opts, candidate) != nil {
+			continue
+		}
+
+		existing, err := d.LoadPlayer(candidate)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to check for existing player")
+		} else if existing == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no username based on %s is available", base)
+}
+
+// validateUsername checks if a username can be registered.
+func validateUsername(opts ImportOptions, username string) error {
+	if opts.ValidateUsername == nil {
+		return nil
+	}
+
+	err := opts.ValidateUsername(username)
+	if err != nil {
+		return errors.Wrapf(err, "cannot import player as %s", username)
+	}
+
+	return nil
+}
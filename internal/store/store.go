@@ -10,6 +10,7 @@ import (
 	"github.com/mbpolan/openmcs/internal/store/driver"
 	"github.com/pkg/errors"
 	"strings"
+	"time"
 )
 
 // Store is a backend database used for persistent storage of game data.
@@ -31,6 +32,14 @@ func New(cfg *config.Config) (*Store, error) {
 		}
 
 		dbDriver, err = driver.NewSQLite3Driver(cfg.Store.SQLite3)
+	case "postgres":
+		if cfg.Store.Postgres == nil {
+			return nil, fmt.Errorf("missing database.postgres configuration")
+		}
+
+		dbDriver, err = driver.NewPostgresDriver(cfg.Store.Postgres, cfg.Store.Pool)
+	case "memory":
+		dbDriver, err = driver.NewMemoryDriver(cfg.Store.Memory)
 	default:
 		err = fmt.Errorf("unsupported driver: %s", cfg.Store.Driver)
 	}
@@ -39,10 +48,51 @@ func New(cfg *config.Config) (*Store, error) {
 		return nil, errors.Wrapf(err, "failed to initialize %s driver", cfg.Store.Driver)
 	}
 
+	return NewWithDriver(cfg, dbDriver), nil
+}
+
+// Open loads the server configuration from a directory, sets up logging and opens the persistent store, running any
+// pending migrations. This prepares command line tools to work with the store, even if the server has not been started
+// yet.
+func Open(configPath string) (*config.Config, *Store, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %s", err)
+	}
+
+	err = logger.Setup(logger.Options{
+		LogLevel: cfg.Server.LogLevel,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize logger: %s", err)
+	}
+
+	db, err := New(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create persistent store: %s", err)
+	}
+
+	// make sure the schema is up-to-date in case the server has not been started yet
+	err = db.Migrate()
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("failed to run persistent store migrations: %s", err)
+	}
+
+	return cfg, db, nil
+}
+
+// NewWithDriver creates a new persistent storage provider backed by an existing driver.
+func NewWithDriver(cfg *config.Config, dbDriver driver.Driver) *Store {
 	return &Store{
 		config: cfg,
 		driver: dbDriver,
-	}, nil
+	}
+}
+
+// Driver returns the backend driver used by the persistent store.
+func (s *Store) Driver() driver.Driver {
+	return s.driver
 }
 
 // Migrate runs migrations against the backend persistent store.
@@ -56,6 +106,12 @@ func (s *Store) Migrate() error {
 		return errors.Wrap(err, "failed to get handle to migration driver")
 	}
 
+	// some drivers have no schema, so there is nothing to migrate
+	if handle == nil {
+		logger.Debugf("%s driver does not use migrations", s.config.Store.Driver)
+		return nil
+	}
+
 	// use the same driver as the persistent store uses
 	m, err := migrate.NewWithDatabaseInstance(sourceDir, s.config.Store.Driver, handle)
 	if err != nil {
@@ -92,3 +148,117 @@ func (s *Store) SavePlayer(p *model.Player) error {
 func (s *Store) LoadPlayer(username string) (*model.Player, error) {
 	return s.driver.LoadPlayer(username)
 }
+
+// CreatePlayer creates a new player, and assigns their ID.
+func (s *Store) CreatePlayer(p *model.Player) error {
+	return s.driver.CreatePlayer(p)
+}
+
+// DeletePlayer removes a player and all of their data.
+func (s *Store) DeletePlayer(playerID int) error {
+	return s.driver.DeletePlayer(playerID)
+}
+
+// ReplacePlayer overwrites the account details and all data of an existing player with the same ID.
+func (s *Store) ReplacePlayer(p *model.Player) error {
+	return s.driver.ReplacePlayer(p)
+}
+
+// SavePasswordHash updates the hash of a player's password.
+func (s *Store) SavePasswordHash(playerID int, hash string) error {
+	return s.driver.SavePasswordHash(playerID, hash)
+}
+
+// SavePlayerSnapshot creates a new snapshot of a player's data, keeping only the most recent snapshots up to the
+// retain amount.
+func (s *Store) SavePlayerSnapshot(snapshot *model.PlayerSnapshot, retain int) error {
+	return s.driver.SavePlayerSnapshot(snapshot, retain)
+}
+
+// LoadPlayerSnapshots loads all snapshots of a player without their data, newest first.
+func (s *Store) LoadPlayerSnapshots(playerID int) ([]*model.PlayerSnapshot, error) {
+	return s.driver.LoadPlayerSnapshots(playerID)
+}
+
+// LoadPlayerSnapshot loads a snapshot of a player along with its data.
+func (s *Store) LoadPlayerSnapshot(playerID, snapshotID int) (*model.PlayerSnapshot, error) {
+	return s.driver.LoadPlayerSnapshot(playerID, snapshotID)
+}
+
+// SavePendingRollback records a snapshot that a player is rolled back to the next time they log in. A snapshot ID of
+// zero clears the pending rollback.
+func (s *Store) SavePendingRollback(playerID, snapshotID int) error {
+	return s.driver.SavePendingRollback(playerID, snapshotID)
+}
+
+// LoadPendingRollback loads the ID of the snapshot that a player is rolled back to the next time they log in.
+func (s *Store) LoadPendingRollback(playerID int) (int, error) {
+	return s.driver.LoadPendingRollback(playerID)
+}
+
+// LoadLoginAttempts loads failed login attempts tracked against a username or network address.
+func (s *Store) LoadLoginAttempts(source model.LoginAttemptSource, key string) (*model.LoginAttempts, error) {
+	return s.driver.LoadLoginAttempts(source, key)
+}
+
+// SaveLoginAttempts saves failed login attempts tracked against a username or network address.
+func (s *Store) SaveLoginAttempts(source model.LoginAttemptSource, key string, attempts *model.LoginAttempts) error {
+	return s.driver.SaveLoginAttempts(source, key, attempts)
+}
+
+// DeleteLoginAttempts removes all failed login attempts tracked against a username or network address.
+func (s *Store) DeleteLoginAttempts(source model.LoginAttemptSource, key string) error {
+	return s.driver.DeleteLoginAttempts(source, key)
+}
+
+// SaveLoginAudit records a login attempt.
+func (s *Store) SaveLoginAudit(entry *model.LoginAuditEntry) error {
+	return s.driver.SaveLoginAudit(entry)
+}
+
+// LoadBans loads all bans applied against a username, network address or client identifier.
+func (s *Store) LoadBans(target model.BanTarget, value string) ([]*model.Ban, error) {
+	return s.driver.LoadBans(target, value)
+}
+
+// SaveBan creates a new ban, and assigns its ID.
+func (s *Store) SaveBan(ban *model.Ban) error {
+	return s.driver.SaveBan(ban)
+}
+
+// DeleteBans removes all bans applied against a username, network address or client identifier, returning the number
+// of bans that were removed.
+func (s *Store) DeleteBans(target model.BanTarget, value string) (int, error) {
+	return s.driver.DeleteBans(target, value)
+}
+
+// SaveMute updates whether a player is muted, and when their mute ends. A nil expiry makes the mute permanent. If no
+// player has the username, false is returned.
+func (s *Store) SaveMute(username string, muted bool, expiresAt *time.Time) (bool, error) {
+	return s.driver.SaveMute(username, muted, expiresAt)
+}
+
+// SaveAbuseReport creates a new abuse report along with its attached chat messages, and assigns its ID.
+func (s *Store) SaveAbuseReport(report *model.AbuseReport) error {
+	return s.driver.SaveAbuseReport(report)
+}
+
+// LoadOpenAbuseReports loads all abuse reports that have not been resolved, oldest first.
+func (s *Store) LoadOpenAbuseReports() ([]*model.AbuseReport, error) {
+	return s.driver.LoadOpenAbuseReports()
+}
+
+// ResolveAbuseReport marks an open abuse report as resolved. If no open report has the ID, false is returned.
+func (s *Store) ResolveAbuseReport(id int, resolvedBy string, resolvedAt time.Time) (bool, error) {
+	return s.driver.ResolveAbuseReport(id, resolvedBy, resolvedAt)
+}
+
+// LoadWorldState loads the mutable state of a game world's map, such as ground items.
+func (s *Store) LoadWorldState(worldID int) (*model.WorldState, error) {
+	return s.driver.LoadWorldState(worldID)
+}
+
+// SaveWorldState replaces the mutable state of a game world's map.
+func (s *Store) SaveWorldState(worldID int, state *model.WorldState) error {
+	return s.driver.SaveWorldState(worldID, state)
+}
@@ -25,6 +25,12 @@ type prometheusTelemetry struct {
 	gameStateUpdateDuration prometheus.Histogram
 	// usersOnlineGauge counts the current online player count.
 	usersOnlineGauge prometheus.Gauge
+	// rateLimitExceededCounter counts clients disconnected for exceeding a rate limit, by limit and opcode.
+	rateLimitExceededCounter *prometheus.CounterVec
+	// playerSaveDuration measures how long saving a player takes to complete, by the reason for the save.
+	playerSaveDuration *prometheus.HistogramVec
+	// playerSaveFailedCounter counts players that could not be saved, by the reason for the save.
+	playerSaveFailedCounter *prometheus.CounterVec
 }
 
 // newPrometheusTelemetry creates a server for exposing Prometheus metrics.
@@ -56,17 +62,46 @@ func newPrometheusTelemetry(cfg *config.Config) (Telemetry, error) {
 		},
 	})
 
+	playerSaveDuration := promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "player_save_duration",
+		Help: "The amount of time (in nanoseconds) saving a player took to complete",
+		Buckets: []float64{
+			100000.0,
+			1000000.0,
+			5000000.0,
+			10000000.0,
+			50000000.0,
+			100000000.0,
+			500000000.0,
+			1000000000.0,
+		},
+	}, []string{"reason"})
+
 	// create gauge metrics
 	usersOnlineGauge := promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "users_online_total",
 		Help: "The total number of users connected to the server",
 	})
 
+	// create counter metrics
+	rateLimitExceededCounter := promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "packet_rate_limit_exceeded_total",
+		Help: "The total number of clients disconnected for exceeding a packet rate limit",
+	}, []string{"limit", "opcode"})
+
+	playerSaveFailedCounter := promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "player_save_failed_total",
+		Help: "The total number of times a player could not be saved",
+	}, []string{"reason"})
+
 	return &prometheusTelemetry{
-		bindAddress:             bindAddress,
-		server:                  server,
-		gameStateUpdateDuration: gameStateUpdateDuration,
-		usersOnlineGauge:        usersOnlineGauge,
+		bindAddress:              bindAddress,
+		server:                   server,
+		gameStateUpdateDuration:  gameStateUpdateDuration,
+		usersOnlineGauge:         usersOnlineGauge,
+		rateLimitExceededCounter: rateLimitExceededCounter,
+		playerSaveDuration:       playerSaveDuration,
+		playerSaveFailedCounter:  playerSaveFailedCounter,
 	}, nil
 }
 
@@ -89,7 +124,9 @@ func (p *prometheusTelemetry) Start() {
 func (p *prometheusTelemetry) Stop() error {
 	p.enabled = false
 
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
 	err := p.server.Shutdown(ctx)
 	if err != nil {
 		return err
@@ -124,3 +161,30 @@ func (p *prometheusTelemetry) RecordPlayerDisconnected() {
 
 	p.usersOnlineGauge.Dec()
 }
+
+// RecordRateLimitExceeded tracks a client that was disconnected for exceeding a packet rate limit.
+func (p *prometheusTelemetry) RecordRateLimitExceeded(limit string, opcode byte) {
+	if !p.enabled {
+		return
+	}
+
+	p.rateLimitExceededCounter.WithLabelValues(limit, fmt.Sprintf("%02x", opcode)).Inc()
+}
+
+// RecordPlayerSaved records the duration saving a player's persistent data took to complete.
+func (p *prometheusTelemetry) RecordPlayerSaved(reason string, duration float64) {
+	if !p.enabled {
+		return
+	}
+
+	p.playerSaveDuration.WithLabelValues(reason).Observe(duration)
+}
+
+// RecordPlayerSaveFailed tracks a player whose persistent data could not be saved.
+func (p *prometheusTelemetry) RecordPlayerSaveFailed(reason string) {
+	if !p.enabled {
+		return
+	}
+
+	p.playerSaveFailedCounter.WithLabelValues(reason).Inc()
+}
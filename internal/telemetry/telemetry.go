@@ -14,6 +14,12 @@ type Telemetry interface {
 	RecordPlayerConnected()
 	// RecordPlayerDisconnected tracks a player that was disconnected from the server.
 	RecordPlayerDisconnected()
+	// RecordRateLimitExceeded tracks a client that was disconnected for exceeding a packet rate limit.
+	RecordRateLimitExceeded(limit string, opcode byte)
+	// RecordPlayerSaved records the duration saving a player's persistent data took to complete.
+	RecordPlayerSaved(reason string, duration float64)
+	// RecordPlayerSaveFailed tracks a player whose persistent data could not be saved.
+	RecordPlayerSaveFailed(reason string)
 }
 
 // Setup creates a new metrics provider. If the provider cannot be created, an error is returned. You must call Start()
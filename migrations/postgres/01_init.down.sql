@@ -0,0 +1,21 @@
+-- Migration: 01_init.down.sql
+-- Description: rolls back baseline tables and database objects
+
+DROP TABLE IF EXISTS ABUSE_REPORT_CHAT;
+DROP TABLE IF EXISTS ABUSE_REPORT;
+DROP TABLE IF EXISTS BAN;
+DROP TABLE IF EXISTS LOGIN_AUDIT;
+DROP TABLE IF EXISTS LOGIN_ATTEMPT;
+DROP TABLE IF EXISTS PLAYER_MUSIC_TRACK;
+DROP TABLE IF EXISTS PLAYER_QUEST_FLAG;
+DROP TABLE IF EXISTS PLAYER_QUEST;
+DROP TABLE IF EXISTS PLAYER_GAME_OPTION;
+DROP TABLE IF EXISTS ITEM_ATTRIBUTES;
+DROP TABLE IF EXISTS PLAYER_INVENTORY;
+DROP TABLE IF EXISTS PLAYER_SKILL;
+DROP TABLE IF EXISTS SKILL_LOOKUP;
+DROP TABLE IF EXISTS PLAYER_LIST;
+DROP TABLE IF EXISTS PLAYER_APPEARANCE;
+DROP TABLE IF EXISTS PLAYER_EQUIPMENT;
+DROP TABLE IF EXISTS PLAYER;
+DROP FUNCTION IF EXISTS SET_UPDATED_DTTM;
@@ -0,0 +1,549 @@
+-- Migration: 01_init.up.sql
+-- Description: creates baseline tables and database objects
+
+-- ----------------------------------------------------------------------------
+-- Function: SET_UPDATED_DTTM
+-- ----------------------------------------------------------------------------
+
+-- create a trigger function to manage UPDATED_DTTM columns
+CREATE FUNCTION SET_UPDATED_DTTM() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.UPDATED_DTTM = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER
+-- ----------------------------------------------------------------------------
+
+-- create table for storing general player data
+CREATE TABLE PLAYER (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- player username
+    USERNAME TEXT NOT NULL,
+    -- hash of password
+    PASSWORD_HASH TEXT NOT NULL,
+    -- player email address
+    EMAIL TEXT NOT NULL,
+    -- position along x-axis in global coordinates
+    GLOBAL_X INTEGER NOT NULL,
+    -- position along y-axis in global coordinates
+    GLOBAL_Y INTEGER NOT NULL,
+    -- position along z-axis in global coordinates
+    GLOBAL_Z INTEGER NOT NULL,
+    -- character gender
+    GENDER INTEGER NOT NULL,
+    -- flag if the player should be shown the character designer interface
+    UPDATE_DESIGN BOOLEAN NOT NULL,
+    -- flag if the player's client should send anti-cheating metadata
+    FLAGGED BOOLEAN NOT NULL,
+    -- flag if the player is muted
+    MUTED BOOLEAN NOT NULL,
+    -- date time when the player's mute ends, or null if it is permanent
+    MUTE_END_DTTM TIMESTAMPTZ NULL,
+    -- whether the player is walking or running
+    MOVEMENT_SPEED INTEGER NOT NULL,
+    -- run energy in units
+    RUN_ENERGY INTEGER NOT NULL,
+    -- mode for player's public chat
+    PUBLIC_CHAT_MODE INTEGER NOT NULL,
+    -- mode for player's private chat
+    PRIVATE_CHAT_MODE INTEGER NOT NULL,
+    -- mode for player's interactions
+    INTERACTION_MODE INTEGER NOT NULL,
+    -- flag if the player has enabled auto-retaliate
+    AUTO_RETALIATE BOOLEAN NOT NULL,
+    -- access rights of the player (normal, mod, admin, etc.)
+    TYPE INTEGER NOT NULL,
+    -- membership status
+    MEMBER BOOLEAN NOT NULL,
+    -- date of membership expiration
+    MEMBER_END_DTTM TIMESTAMPTZ NULL,
+    -- date time when the player last logged in
+    LAST_LOGIN_DTTM TIMESTAMPTZ NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL
+);
+
+-- enforce unique usernames, ignoring case, since they will be queried on login
+CREATE UNIQUE INDEX IDX_PLAYER_USERNAME ON PLAYER (LOWER(USERNAME));
+
+-- create a trigger on player to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_UPDATED_DTTM BEFORE UPDATE ON PLAYER FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_EQUIPMENT
+-- ----------------------------------------------------------------------------
+
+-- create table for storing a player's equipped items
+CREATE TABLE PLAYER_EQUIPMENT (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- equipment slot id
+    SLOT_ID INTEGER NOT NULL,
+    -- equipped item id
+    ITEM_ID INTEGER NOT NULL,
+    -- stack amount for the item
+    AMOUNT INTEGER NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id and slot_id
+    UNIQUE (PLAYER_ID, SLOT_ID)
+);
+
+-- create a trigger on player_equipment to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_EQUIPMENT_UPDATED_DTTM BEFORE UPDATE ON PLAYER_EQUIPMENT
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_APPEARANCE
+-- ----------------------------------------------------------------------------
+
+-- create table for storing a player's character appearance
+CREATE TABLE PLAYER_APPEARANCE (
+    -- owning player
+    PLAYER_ID INTEGER PRIMARY KEY REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- head model id
+    HEAD_ID INTEGER NOT NULL,
+    -- face model id
+    FACE_ID INTEGER NOT NULL,
+    -- body model id
+    BODY_ID INTEGER NOT NULL,
+    -- arms model id
+    ARMS_ID INTEGER NOT NULL,
+    -- hands model id
+    HANDS_ID INTEGER NOT NULL,
+    -- legs model id
+    LEGS_ID INTEGER NOT NULL,
+    -- feet model id
+    FEET_ID INTEGER NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL
+);
+
+-- create a trigger on player_appearance to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_APPEARANCE_UPDATED_DTTM BEFORE UPDATE ON PLAYER_APPEARANCE
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_LIST
+-- ----------------------------------------------------------------------------
+
+-- create table for storing player friends and ignored lists
+CREATE TABLE PLAYER_LIST (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- referenced player
+    OTHER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- friend or ignored
+    TYPE INTEGER NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id, other_id and type
+    UNIQUE (PLAYER_ID, OTHER_ID, TYPE)
+);
+
+-- create an index on player_list.player_id since it will be queried on
+CREATE INDEX IDX_PLAYER_LIST_PLAYER_ID ON PLAYER_LIST (PLAYER_ID);
+
+-- create a trigger on player_list to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_LIST_UPDATED_DTTM BEFORE UPDATE ON PLAYER_LIST
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: SKILL_LOOKUP
+-- ----------------------------------------------------------------------------
+
+-- create a reference table for storing skills
+CREATE TABLE SKILL_LOOKUP (
+    -- primary key
+    ID INTEGER PRIMARY KEY,
+    -- skill name
+    NAME TEXT NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL
+);
+
+-- create a trigger on skill_lookup to manage the UPDATED_DTTM column
+CREATE TRIGGER SKILL_LOOKUP_UPDATED_DTTM BEFORE UPDATE ON SKILL_LOOKUP
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- skills are referenced by player skills, so they are required before any player can be saved
+INSERT INTO SKILL_LOOKUP (
+    ID, NAME
+) VALUES
+    (0, 'Attack'),
+    (1, 'Defense'),
+    (2, 'Strength'),
+    (3, 'Hitpoints'),
+    (4, 'Ranged'),
+    (5, 'Prayer'),
+    (6, 'Magic'),
+    (7, 'Cooking'),
+    (8, 'Woodcutting'),
+    (9, 'Fletching'),
+    (10, 'Fishing'),
+    (11, 'Firemaking'),
+    (12, 'Crafting'),
+    (13, 'Smithing'),
+    (14, 'Mining'),
+    (15, 'Herblore'),
+    (16, 'Agility'),
+    (17, 'Thieving'),
+    (18, 'Slayer'),
+    (19, 'Farming'),
+    (20, 'Runecraft')
+;
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_SKILL
+-- ----------------------------------------------------------------------------
+
+-- create table for storing player skill levels
+CREATE TABLE PLAYER_SKILL (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- skill id
+    SKILL_ID INTEGER NOT NULL REFERENCES SKILL_LOOKUP(ID) ON DELETE CASCADE,
+    -- stat level
+    STAT_LEVEL INTEGER NOT NULL,
+    -- skill level
+    LEVEL INTEGER NOT NULL,
+    -- skill experience
+    EXPERIENCE DOUBLE PRECISION NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id and skill_id
+    UNIQUE (PLAYER_ID, SKILL_ID)
+);
+
+-- create a trigger on player_skill to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_SKILL_UPDATED_DTTM BEFORE UPDATE ON PLAYER_SKILL
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_INVENTORY
+-- ----------------------------------------------------------------------------
+
+-- create table for storing player inventory items
+CREATE TABLE PLAYER_INVENTORY (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- slot id
+    SLOT_ID INTEGER NOT NULL CHECK (SLOT_ID >= 0 AND SLOT_ID < 28),
+    -- item id
+    ITEM_ID INTEGER NOT NULL,
+    -- stack size (amount)
+    AMOUNT INTEGER NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id and slot_id
+    UNIQUE (PLAYER_ID, SLOT_ID)
+);
+
+-- create a trigger on player_inventory to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_INVENTORY_UPDATED_DTTM BEFORE UPDATE ON PLAYER_INVENTORY
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: ITEM_ATTRIBUTES
+-- ----------------------------------------------------------------------------
+
+-- create table for storing item attributes
+CREATE TABLE ITEM_ATTRIBUTES (
+    -- primary key
+    ITEM_ID INTEGER PRIMARY KEY,
+    -- the slot this item is equipped into
+    EQUIP_SLOT_ID INTEGER NULL,
+    -- the speed at which this item deals damage
+    SPEED INTEGER NULL,
+    -- weapon attack style
+    WEAPON_STYLE TEXT NULL CHECK (
+        WEAPON_STYLE IN (
+            '2H_SWORD', 'AXE', 'BOW', 'BLUNT', 'CLAW', 'CROSSBOW', 'GUN', 'PICKAXE', 'POLEARM', 'POLESTAFF',
+            'SCYTHE', 'SLASH_SWORD', 'SPEAR', 'SPIKED', 'STAB_SWORD', 'STAFF', 'THROWN', 'WHIP'
+        )
+    ),
+    -- the weight of the item
+    WEIGHT DOUBLE PRECISION NOT NULL,
+    -- the in-game/shop value of the item, in coins
+    VALUE INTEGER NOT NULL DEFAULT 0,
+    -- the offensive stab bonus
+    ATTACK_STAB INTEGER NULL,
+    -- the offensive slash bonus
+    ATTACK_SLASH INTEGER NULL,
+    -- the offensive crush bonus
+    ATTACK_CRUSH INTEGER NULL,
+    -- the offensive magic bonus
+    ATTACK_MAGIC INTEGER NULL,
+    -- the offensive range bonus
+    ATTACK_RANGE INTEGER NULL,
+    -- the defensive stab bonus
+    DEFENSE_STAB INTEGER NULL,
+    -- the defensive slash bonus
+    DEFENSE_SLASH INTEGER NULL,
+    -- the defensive crush bonus
+    DEFENSE_CRUSH INTEGER NULL,
+    -- the defensive magic bonus
+    DEFENSE_MAGIC INTEGER NULL,
+    -- the defensive range bonus
+    DEFENSE_RANGE INTEGER NULL,
+    -- the strength bonus
+    STRENGTH_BONUS INTEGER NULL,
+    -- the prayer bonus
+    PRAYER_BONUS INTEGER NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL
+);
+
+-- create a trigger on item_attributes to manage the UPDATED_DTTM column
+CREATE TRIGGER ITEM_ATTRIBUTES_UPDATED_DTTM BEFORE UPDATE ON ITEM_ATTRIBUTES
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_GAME_OPTION
+-- ----------------------------------------------------------------------------
+
+-- create table for storing player game option preferences
+CREATE TABLE PLAYER_GAME_OPTION (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- option id
+    OPTION_ID INTEGER NOT NULL,
+    -- option value
+    OPTION_VALUE TEXT NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id and option_id
+    UNIQUE (PLAYER_ID, OPTION_ID)
+);
+
+-- create a trigger on player_game_option to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_GAME_OPTION_UPDATED_DTTM BEFORE UPDATE ON PLAYER_GAME_OPTION
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_QUEST
+-- ----------------------------------------------------------------------------
+
+-- create table for storing player quest completion
+CREATE TABLE PLAYER_QUEST (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- quest id
+    QUEST_ID INTEGER NOT NULL,
+    -- flag if the quest has been started, in progress or completed
+    STATUS INTEGER NOT NULL CHECK (STATUS >= 0 AND STATUS <= 2),
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id and quest_id
+    UNIQUE (PLAYER_ID, QUEST_ID)
+);
+
+-- create a trigger on player_quest to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_QUEST_UPDATED_DTTM BEFORE UPDATE ON PLAYER_QUEST
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_QUEST_FLAG
+-- ----------------------------------------------------------------------------
+
+-- create table for storing player quest step progress
+CREATE TABLE PLAYER_QUEST_FLAG (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- quest id
+    QUEST_ID INTEGER NOT NULL,
+    -- flag id
+    FLAG_ID INTEGER NOT NULL,
+    -- flag value
+    VALUE INTEGER NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id, quest_id and flag_id
+    UNIQUE (PLAYER_ID, QUEST_ID, FLAG_ID)
+);
+
+-- create a trigger on player_quest_flag to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_QUEST_FLAG_UPDATED_DTTM BEFORE UPDATE ON PLAYER_QUEST_FLAG
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_MUSIC_TRACK
+-- ----------------------------------------------------------------------------
+
+-- create table for storing music tracks unlocked by a player
+CREATE TABLE PLAYER_MUSIC_TRACK (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- music id
+    MUSIC_ID INTEGER NOT NULL,
+    -- enabled or disabled
+    UNLOCKED BOOLEAN NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- date time when the row was updated
+    UPDATED_DTTM TIMESTAMPTZ NULL,
+    -- enforce uniqueness on the player_id and music_id
+    UNIQUE (PLAYER_ID, MUSIC_ID)
+);
+
+-- create a trigger on player_music_track to manage the UPDATED_DTTM column
+CREATE TRIGGER PLAYER_MUSIC_TRACK_UPDATED_DTTM BEFORE UPDATE ON PLAYER_MUSIC_TRACK
+    FOR EACH ROW EXECUTE FUNCTION SET_UPDATED_DTTM();
+
+-- ----------------------------------------------------------------------------
+-- Table: LOGIN_ATTEMPT
+-- ----------------------------------------------------------------------------
+
+-- create table for tracking consecutive failed login attempts
+CREATE TABLE LOGIN_ATTEMPT (
+    -- what the attempts are tracked against (0 for username, 1 for network address)
+    SOURCE INTEGER NOT NULL,
+    -- the username or network address
+    SOURCE_KEY TEXT NOT NULL,
+    -- number of failed attempts since the last lockout or successful login
+    FAILURES INTEGER NOT NULL,
+    -- number of lockouts since the last successful login
+    LOCKOUTS INTEGER NOT NULL,
+    -- date time of the most recent failed attempt
+    LAST_FAILURE_DTTM TIMESTAMPTZ NOT NULL,
+    -- date time when the current lockout ends
+    LOCKED_UNTIL_DTTM TIMESTAMPTZ NULL,
+    PRIMARY KEY (SOURCE, SOURCE_KEY)
+);
+
+-- ----------------------------------------------------------------------------
+-- Table: LOGIN_AUDIT
+-- ----------------------------------------------------------------------------
+
+-- create table for recording every login attempt
+CREATE TABLE LOGIN_AUDIT (
+    -- primary key
+    ID BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- username the client attempted to log in with
+    USERNAME TEXT NOT NULL,
+    -- network address the attempt was made from
+    ADDRESS TEXT NOT NULL,
+    -- unique identifier reported by the client
+    CLIENT_UID BIGINT NOT NULL,
+    -- outcome of the attempt (0 for success, 1 for invalid credentials, 2 for locked out)
+    RESULT INTEGER NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- create indexes on login_audit since it will be queried by username and address
+CREATE INDEX IDX_LOGIN_AUDIT_USERNAME ON LOGIN_AUDIT (USERNAME);
+CREATE INDEX IDX_LOGIN_AUDIT_ADDRESS ON LOGIN_AUDIT (ADDRESS);
+
+-- ----------------------------------------------------------------------------
+-- Table: BAN
+-- ----------------------------------------------------------------------------
+
+-- create table for storing bans
+CREATE TABLE BAN (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- what the ban is applied against (0 for account, 1 for network address, 2 for client uid)
+    TARGET INTEGER NOT NULL,
+    -- the username, network address or client uid that is banned
+    TARGET_VALUE TEXT NOT NULL,
+    -- description of why the ban was issued
+    REASON TEXT NOT NULL,
+    -- username of the moderator that issued the ban
+    ISSUED_BY TEXT NOT NULL,
+    -- date time when the ban was issued
+    ISSUED_DTTM TIMESTAMPTZ NOT NULL,
+    -- date time when the ban expires, or null if it is permanent
+    EXPIRES_DTTM TIMESTAMPTZ NULL
+);
+
+-- create an index on ban since it will be queried on login
+CREATE INDEX IDX_BAN_TARGET ON BAN (TARGET, TARGET_VALUE);
+
+-- ----------------------------------------------------------------------------
+-- Table: ABUSE_REPORT
+-- ----------------------------------------------------------------------------
+
+-- create table for storing abuse reports filed by players
+CREATE TABLE ABUSE_REPORT (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- username of the player that filed the report
+    REPORTER TEXT NOT NULL,
+    -- username of the player that was reported
+    OFFENDER TEXT NOT NULL,
+    -- identifier of the rule the offender was reported for breaking
+    REASON INTEGER NOT NULL,
+    -- flag if the reporting moderator muted the offender
+    MUTED BOOLEAN NOT NULL,
+    -- username of the moderator that resolved the report
+    RESOLVED_BY TEXT NULL,
+    -- date time when the report was resolved, or null if it is still open
+    RESOLVED_DTTM TIMESTAMPTZ NULL,
+    -- date time when the report was filed
+    CREATED_DTTM TIMESTAMPTZ NOT NULL
+);
+
+-- create an index on abuse_report since open reports will be queried
+CREATE INDEX IDX_ABUSE_REPORT_RESOLVED ON ABUSE_REPORT (RESOLVED_DTTM);
+
+-- ----------------------------------------------------------------------------
+-- Table: ABUSE_REPORT_CHAT
+-- ----------------------------------------------------------------------------
+
+-- create table for storing the offender's recent chat messages attached to an abuse report
+CREATE TABLE ABUSE_REPORT_CHAT (
+    -- the report the message is attached to
+    REPORT_ID INTEGER NOT NULL REFERENCES ABUSE_REPORT(ID) ON DELETE CASCADE,
+    -- order of the message among those attached to the report
+    SEQUENCE INTEGER NOT NULL,
+    -- username of the recipient of a private message, or null for public chat
+    RECIPIENT TEXT NULL,
+    -- content of the message
+    TEXT TEXT NOT NULL,
+    -- date time when the message was sent
+    SENT_DTTM TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (REPORT_ID, SEQUENCE)
+);
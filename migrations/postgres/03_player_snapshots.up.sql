@@ -0,0 +1,23 @@
+-- Migration: 03_player_snapshots.up.sql
+-- Description: creates a table for copies of player data taken each time a player is saved
+
+-- ----------------------------------------------------------------------------
+-- Table: PLAYER_SNAPSHOT
+-- ----------------------------------------------------------------------------
+
+-- create table for storing snapshots of a player's data that they can be rolled back to
+CREATE TABLE PLAYER_SNAPSHOT (
+    -- primary key
+    ID INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    -- owning player
+    PLAYER_ID INTEGER NOT NULL REFERENCES PLAYER(ID) ON DELETE CASCADE,
+    -- version of the format the data is encoded with
+    SCHEMA_VERSION INTEGER NOT NULL,
+    -- compressed player data
+    DATA BYTEA NOT NULL,
+    -- date time when the snapshot was taken
+    CREATED_DTTM TIMESTAMPTZ NOT NULL
+);
+
+-- create an index on player_snapshot since a player's snapshots will be queried newest first
+CREATE INDEX IDX_PLAYER_SNAPSHOT_PLAYER_ID ON PLAYER_SNAPSHOT (PLAYER_ID, ID);
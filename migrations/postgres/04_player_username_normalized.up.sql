@@ -0,0 +1,12 @@
+-- Migration: 04_player_username_normalized.up.sql
+-- Description: enforces unique player usernames, treating spaces and underscores alike
+
+-- the client treats spaces and underscores in usernames as the same character, so usernames are compared in a
+-- normalized form: lowercase, with underscores replaced by spaces
+ALTER TABLE PLAYER ADD COLUMN USERNAME_NORMALIZED TEXT;
+
+UPDATE PLAYER SET USERNAME_NORMALIZED = LOWER(REPLACE(TRIM(USERNAME), '_', ' '));
+
+ALTER TABLE PLAYER ALTER COLUMN USERNAME_NORMALIZED SET NOT NULL;
+
+CREATE UNIQUE INDEX IDX_PLAYER_USERNAME_NORMALIZED ON PLAYER (USERNAME_NORMALIZED);
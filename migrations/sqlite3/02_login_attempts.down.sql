@@ -0,0 +1,5 @@
+-- Migration: 02_login_attempts.down.sql
+-- Description: rolls back tables for tracking failed login attempts and auditing logins
+
+DROP TABLE IF EXISTS LOGIN_AUDIT;
+DROP TABLE IF EXISTS LOGIN_ATTEMPT;
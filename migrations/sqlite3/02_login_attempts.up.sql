@@ -0,0 +1,47 @@
+-- Migration: 02_login_attempts.up.sql
+-- Description: creates tables for tracking failed login attempts and auditing logins
+
+-- ----------------------------------------------------------------------------
+-- Table: LOGIN_ATTEMPT
+-- ----------------------------------------------------------------------------
+
+-- create table for tracking consecutive failed login attempts
+CREATE TABLE LOGIN_ATTEMPT (
+    -- what the attempts are tracked against (0 for username, 1 for network address)
+    SOURCE INTEGER NOT NULL,
+    -- the username or network address
+    SOURCE_KEY TEXT NOT NULL,
+    -- number of failed attempts since the last lockout or successful login
+    FAILURES INTEGER NOT NULL,
+    -- number of lockouts since the last successful login
+    LOCKOUTS INTEGER NOT NULL,
+    -- date time of the most recent failed attempt
+    LAST_FAILURE_DTTM TEXT NOT NULL,
+    -- date time when the current lockout ends
+    LOCKED_UNTIL_DTTM TEXT NULL,
+    PRIMARY KEY (SOURCE, SOURCE_KEY)
+);
+
+-- ----------------------------------------------------------------------------
+-- Table: LOGIN_AUDIT
+-- ----------------------------------------------------------------------------
+
+-- create table for recording every login attempt
+CREATE TABLE LOGIN_AUDIT (
+    -- primary key
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- username the client attempted to log in with
+    USERNAME TEXT NOT NULL,
+    -- network address the attempt was made from
+    ADDRESS TEXT NOT NULL,
+    -- unique identifier reported by the client
+    CLIENT_UID INTEGER NOT NULL,
+    -- outcome of the attempt (0 for success, 1 for invalid credentials, 2 for locked out)
+    RESULT INTEGER NOT NULL,
+    -- date time when the row was inserted
+    CREATED_DTTM TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- create indexes on login_audit since it will be queried by username and address
+CREATE INDEX IDX_LOGIN_AUDIT_USERNAME ON LOGIN_AUDIT (USERNAME);
+CREATE INDEX IDX_LOGIN_AUDIT_ADDRESS ON LOGIN_AUDIT (ADDRESS);
@@ -0,0 +1,5 @@
+-- Migration: 03_player_username_unique.down.sql
+-- Description: rolls back unique player usernames
+
+DROP INDEX IF EXISTS IDX_PLAYER_USERNAME;
+CREATE INDEX IDX_PLAYER_USERNAME ON PLAYER (USERNAME);
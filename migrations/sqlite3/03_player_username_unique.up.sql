@@ -0,0 +1,6 @@
+-- Migration: 03_player_username_unique.up.sql
+-- Description: enforces unique player usernames, ignoring case
+
+-- replace the username index with a unique one so that accounts registered concurrently cannot share a username
+DROP INDEX IF EXISTS IDX_PLAYER_USERNAME;
+CREATE UNIQUE INDEX IDX_PLAYER_USERNAME ON PLAYER (USERNAME COLLATE NOCASE);
@@ -0,0 +1,4 @@
+-- Migration: 04_bans.down.sql
+-- Description: rolls back the table for bans
+
+DROP TABLE IF EXISTS BAN;
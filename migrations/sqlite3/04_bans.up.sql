@@ -0,0 +1,27 @@
+-- Migration: 04_bans.up.sql
+-- Description: creates a table for bans against player accounts, network addresses and client machines
+
+-- ----------------------------------------------------------------------------
+-- Table: BAN
+-- ----------------------------------------------------------------------------
+
+-- create table for storing bans
+CREATE TABLE BAN (
+    -- primary key
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- what the ban is applied against (0 for account, 1 for network address, 2 for client uid)
+    TARGET INTEGER NOT NULL,
+    -- the username, network address or client uid that is banned
+    TARGET_VALUE TEXT NOT NULL,
+    -- description of why the ban was issued
+    REASON TEXT NOT NULL,
+    -- username of the moderator that issued the ban
+    ISSUED_BY TEXT NOT NULL,
+    -- date time when the ban was issued
+    ISSUED_DTTM TEXT NOT NULL,
+    -- date time when the ban expires, or null if it is permanent
+    EXPIRES_DTTM TEXT NULL
+);
+
+-- create an index on ban since it will be queried on login
+CREATE INDEX IDX_BAN_TARGET ON BAN (TARGET, TARGET_VALUE);
@@ -0,0 +1,6 @@
+-- Migration: 05_mutes_and_reports.down.sql
+-- Description: rolls back mute expiry times and tables for abuse reports
+
+DROP TABLE IF EXISTS ABUSE_REPORT_CHAT;
+DROP TABLE IF EXISTS ABUSE_REPORT;
+ALTER TABLE PLAYER DROP COLUMN MUTE_END_DTTM;
@@ -0,0 +1,51 @@
+-- Migration: 05_mutes_and_reports.up.sql
+-- Description: adds mute expiry times and creates tables for abuse reports
+
+-- date time when a player's mute ends, or null if it is permanent
+ALTER TABLE PLAYER ADD COLUMN MUTE_END_DTTM TEXT NULL;
+
+-- ----------------------------------------------------------------------------
+-- Table: ABUSE_REPORT
+-- ----------------------------------------------------------------------------
+
+-- create table for storing abuse reports filed by players
+CREATE TABLE ABUSE_REPORT (
+    -- primary key
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- username of the player that filed the report
+    REPORTER TEXT NOT NULL,
+    -- username of the player that was reported
+    OFFENDER TEXT NOT NULL,
+    -- identifier of the rule the offender was reported for breaking
+    REASON INTEGER NOT NULL,
+    -- flag if the reporting moderator muted the offender
+    MUTED INTEGER NOT NULL,
+    -- username of the moderator that resolved the report
+    RESOLVED_BY TEXT NULL,
+    -- date time when the report was resolved, or null if it is still open
+    RESOLVED_DTTM TEXT NULL,
+    -- date time when the report was filed
+    CREATED_DTTM TEXT NOT NULL
+);
+
+-- create an index on abuse_report since open reports will be queried
+CREATE INDEX IDX_ABUSE_REPORT_RESOLVED ON ABUSE_REPORT (RESOLVED_DTTM);
+
+-- ----------------------------------------------------------------------------
+-- Table: ABUSE_REPORT_CHAT
+-- ----------------------------------------------------------------------------
+
+-- create table for storing the offender's recent chat messages attached to an abuse report
+CREATE TABLE ABUSE_REPORT_CHAT (
+    -- the report the message is attached to
+    REPORT_ID INTEGER NOT NULL REFERENCES ABUSE_REPORT(ID) ON DELETE CASCADE,
+    -- order of the message among those attached to the report
+    SEQUENCE INTEGER NOT NULL,
+    -- username of the recipient of a private message, or null for public chat
+    RECIPIENT TEXT NULL,
+    -- content of the message
+    TEXT TEXT NOT NULL,
+    -- date time when the message was sent
+    SENT_DTTM TEXT NOT NULL,
+    PRIMARY KEY (REPORT_ID, SEQUENCE)
+);
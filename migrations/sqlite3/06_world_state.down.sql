@@ -0,0 +1,4 @@
+-- Migration: 06_world_state.down.sql
+-- Description: rolls back the table for ground items
+
+DROP TABLE IF EXISTS WORLD_GROUND_ITEM;
@@ -0,0 +1,27 @@
+-- Migration: 06_world_state.up.sql
+-- Description: creates a table for ground items kept across server restarts
+
+-- ----------------------------------------------------------------------------
+-- Table: WORLD_GROUND_ITEM
+-- ----------------------------------------------------------------------------
+
+-- create table for storing items placed on the ground in a game world
+CREATE TABLE WORLD_GROUND_ITEM (
+    -- the game world the item is placed in
+    WORLD_ID INTEGER NOT NULL,
+    -- order of the item among those in the game world, oldest first
+    SEQUENCE INTEGER NOT NULL,
+    -- the item id
+    ITEM_ID INTEGER NOT NULL,
+    -- stack size of the item
+    AMOUNT INTEGER NOT NULL,
+    -- x-coordinate of the tile in global coordinates
+    GLOBAL_X INTEGER NOT NULL,
+    -- y-coordinate of the tile in global coordinates
+    GLOBAL_Y INTEGER NOT NULL,
+    -- z-coordinate of the tile in global coordinates
+    GLOBAL_Z INTEGER NOT NULL,
+    -- seconds left until the item is removed, or null if it stays on the ground indefinitely
+    REMAINING_SECONDS INTEGER NULL,
+    PRIMARY KEY (WORLD_ID, SEQUENCE)
+);
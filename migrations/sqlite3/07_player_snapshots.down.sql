@@ -0,0 +1,4 @@
+-- Migration: 07_player_snapshots.down.sql
+-- Description: rolls back the table for player snapshots
+
+DROP TABLE IF EXISTS PLAYER_SNAPSHOT;
@@ -0,0 +1,5 @@
+-- Migration: 08_player_username_normalized.down.sql
+-- Description: rolls back unique normalized player usernames
+
+DROP INDEX IF EXISTS IDX_PLAYER_USERNAME_NORMALIZED;
+ALTER TABLE PLAYER DROP COLUMN USERNAME_NORMALIZED;
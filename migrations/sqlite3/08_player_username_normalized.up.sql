@@ -0,0 +1,10 @@
+-- Migration: 08_player_username_normalized.up.sql
+-- Description: enforces unique player usernames, treating spaces and underscores alike
+
+-- the client treats spaces and underscores in usernames as the same character, so usernames are compared in a
+-- normalized form: lowercase, with underscores replaced by spaces
+ALTER TABLE PLAYER ADD COLUMN USERNAME_NORMALIZED TEXT NOT NULL DEFAULT '';
+
+UPDATE PLAYER SET USERNAME_NORMALIZED = LOWER(REPLACE(TRIM(USERNAME), '_', ' '));
+
+CREATE UNIQUE INDEX IDX_PLAYER_USERNAME_NORMALIZED ON PLAYER (USERNAME_NORMALIZED);
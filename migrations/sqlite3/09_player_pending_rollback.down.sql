@@ -0,0 +1,4 @@
+-- Migration: 09_player_pending_rollback.down.sql
+-- Description: rolls back tracking of pending player rollbacks
+
+ALTER TABLE PLAYER DROP COLUMN PENDING_ROLLBACK_ID;
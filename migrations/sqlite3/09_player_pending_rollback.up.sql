@@ -0,0 +1,6 @@
+-- Migration: 09_player_pending_rollback.up.sql
+-- Description: tracks rollbacks that are applied the next time a player logs in
+
+-- rollbacks are deferred until a player logs in, so that they can't race with the player's data being saved. a
+-- snapshot may be removed in the meantime, so the column does not reference the snapshot table.
+ALTER TABLE PLAYER ADD COLUMN PENDING_ROLLBACK_ID INTEGER NULL;
@@ -0,0 +1,31 @@
+-- Seed: 99_sample.sql
+-- Description: sample data to bootstrap test players
+
+-- add a player with username "mike" and password "mike"
+INSERT INTO PLAYER (
+    ID, USERNAME, PASSWORD_HASH, EMAIL, GLOBAL_X, GLOBAL_Y, GLOBAL_Z, GENDER, UPDATE_DESIGN, FLAGGED, MUTED,
+    MOVEMENT_SPEED, RUN_ENERGY, PUBLIC_CHAT_MODE, PRIVATE_CHAT_MODE, INTERACTION_MODE, AUTO_RETALIATE, TYPE, MEMBER
+) OVERRIDING SYSTEM VALUE VALUES
+    (1, 'Mike', 'ab2341a2a5ec2b5ebd0ba195499408ac4ff54e63b52fa25b0b506d9f0a67cd35', 'mike@example.com', 3209, 3429, 0, 0, TRUE, FALSE, FALSE, 0, 100, 0, 0, 0, TRUE, 0, FALSE),
+    (2, 'Hurz', 'cdb162dd4fa9ae4245525a9ec9c2868f19578e00c500cb9f192ea12c9330191d', 'mike@example.com', 3222, 3428, 0, 0, TRUE, FALSE, FALSE, 0, 100, 0, 0, 0, TRUE, 0, FALSE)
+ON CONFLICT DO NOTHING;
+
+-- move the identity past the seeded players so new accounts don't collide with them
+SELECT setval(pg_get_serial_sequence('PLAYER', 'id'), (SELECT MAX(ID) FROM PLAYER));
+
+-- add appearance data for seed players
+INSERT INTO PLAYER_APPEARANCE (
+    PLAYER_ID, HEAD_ID, FACE_ID, BODY_ID, ARMS_ID, HANDS_ID, LEGS_ID, FEET_ID
+) VALUES
+    -- mike
+    (1, 0, 10, 18, 26, 33, 36, 42),
+    -- hurz
+    (2, 0, 10, 18, 26, 33, 36, 42)
+ON CONFLICT DO NOTHING;
+
+-- initialize skills for players
+INSERT INTO PLAYER_SKILL (PLAYER_ID, SKILL_ID, STAT_LEVEL, LEVEL, EXPERIENCE)
+SELECT p.ID, s.ID, 1, 1, 0
+FROM PLAYER p CROSS JOIN SKILL_LOOKUP s
+WHERE p.ID IN (1, 2)
+ON CONFLICT DO NOTHING;